@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"goblin-terminal/internal/game"
+	"goblin-terminal/pkg/docker"
+)
+
+// runCheckImage builds the image and reports every tool the quests need
+// that it lacks. Returns the number of missing tools.
+func runCheckImage(quests []game.Quest, manager *docker.Manager) (int, error) {
+	fmt.Println("Building image...")
+	if err := manager.BuildImage(); err != nil {
+		return 0, err
+	}
+
+	needed := game.RequiredTools(quests)
+	names := make([]string, len(needed))
+	for i, tool := range needed {
+		names[i] = tool.Name
+	}
+	missing, err := manager.MissingTools(names)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, tool := range needed {
+		status := "OK  "
+		if slices.Contains(missing, tool.Name) {
+			status = "FAIL"
+		}
+		fmt.Printf("%s  %s\n", status, formatToolUse(tool))
+	}
+	return len(missing), nil
+}
+
+// runListDeps prints the programs the quests need, without touching the
+// container runtime, for keeping a Dockerfile in step with a quest pack
+func runListDeps(quests []game.Quest) {
+	for _, tool := range game.RequiredTools(quests) {
+		fmt.Println(formatToolUse(tool))
+	}
+}
+
+// formatToolUse is a tool and the quests that need it
+func formatToolUse(tool game.ToolUse) string {
+	ids := make([]string, len(tool.Quests))
+	for i, id := range tool.Quests {
+		ids[i] = fmt.Sprint(id)
+	}
+	return fmt.Sprintf("%-10s quests %s", tool.Name, strings.Join(ids, ", "))
+}
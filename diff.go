@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"goblin-terminal/pkg/docker"
+)
+
+// fsChangeNames label each kind of change in --diff-fs
+var fsChangeNames = map[byte]string{
+	docker.FSAdded:   "added",
+	docker.FSChanged: "changed",
+	docker.FSDeleted: "deleted",
+}
+
+// runDiffFS prints what the running game container changed relative to its
+// image, the player's home first since that is usually what a quest checks
+func runDiffFS(manager *docker.Manager) error {
+	changes, err := manager.DiffFilesystem()
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Println("The container matches its image.")
+		return nil
+	}
+
+	home := manager.HomeDir()
+	var inHome, elsewhere []docker.FSChange
+	counts := make(map[byte]int)
+	for _, c := range changes {
+		counts[c.Kind]++
+		if c.Path == home || strings.HasPrefix(c.Path, home+"/") {
+			inHome = append(inHome, c)
+		} else {
+			elsewhere = append(elsewhere, c)
+		}
+	}
+
+	if len(inHome) > 0 {
+		fmt.Printf("Under %s:\n", home)
+		printFSChanges(inHome, "* ")
+		fmt.Println()
+	}
+	if len(elsewhere) > 0 {
+		fmt.Println("Elsewhere:")
+		printFSChanges(elsewhere, "  ")
+		fmt.Println()
+	}
+	fmt.Printf("%d added, %d changed, %d deleted.\n", counts[docker.FSAdded], counts[docker.FSChanged], counts[docker.FSDeleted])
+	fmt.Printf("Files inside %s are kept in the game's storage, not the container, so they aren't listed. Type 'inventory' in the game to see those.\n", home)
+	return nil
+}
+
+// printFSChanges prints one change per line after marker
+func printFSChanges(changes []docker.FSChange, marker string) {
+	for _, c := range changes {
+		fmt.Printf("%s%-8s %s\n", marker, fsChangeNames[c.Kind], c.Path)
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"fmt"
+
+	"goblin-terminal/pkg/docker"
+)
+
+// runDoctor prints the environment health report and returns the number of failed checks
+func runDoctor(manager *docker.Manager) int {
+	failures := 0
+	for _, r := range manager.Diagnose() {
+		fmt.Printf("%-4s  %-8s %s\n", r.Status, r.Name, r.Detail)
+		if r.Status == docker.CheckFail {
+			failures++
+		}
+	}
+	return failures
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"goblin-terminal/internal/game"
+	"goblin-terminal/internal/ui"
+	"goblin-terminal/pkg/docker"
+)
+
+// execFileFailed is the exit status when the commands couldn't be run at all,
+// kept clear of any real quest index
+const execFileFailed = 255
+
+// runExecFile replays a command file through the game without the UI and
+// returns the process exit status: the final quest index, or execFileFailed
+func runExecFile(path string, quests []game.Quest, manager *docker.Manager, state game.GameState, startQuestIdx int, opts ui.Options) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading command file: %v\n", err)
+		return execFileFailed
+	}
+
+	model := ui.NewModel(quests, manager, state, startQuestIdx, opts)
+	idx, err := ui.RunScript(model, ui.ParseScript(string(data)), os.Stdout)
+	if err := manager.StopContainer(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return execFileFailed
+	}
+	return idx
+}
@@ -0,0 +1,127 @@
+package game
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+)
+
+// pathConditions are the checks whose result depends only on their Target path
+var pathConditions = map[WinConditionType]bool{
+	DirExists:     true,
+	FileExists:    true,
+	DirNotExists:  true,
+	FileNotExists: true,
+	FileContains:  true,
+	FileEquals:    true,
+}
+
+// ValidationCache reuses the result of path-based checks while nothing about
+// the target or its parent directories changed, judged by their stat times.
+// It is safe to share between concurrent checks. A nil cache checks everything.
+type ValidationCache struct {
+	mu      sync.Mutex
+	entries map[WinCondition]cachedTrace
+}
+
+type cachedTrace struct {
+	fingerprint string
+	trace       CheckTrace
+}
+
+// NewValidationCache returns an empty cache
+func NewValidationCache() *ValidationCache {
+	return &ValidationCache{entries: make(map[WinCondition]cachedTrace)}
+}
+
+// cacheable reports whether wc's result can be tied to its target path.
+// A command check opts in by naming the path it inspects as its target.
+func cacheable(wc WinCondition) bool {
+	if wc.Target == "" || wc.RootCheck {
+		return false
+	}
+	return pathConditions[wc.Type] || wc.Type == CommandOut
+}
+
+// watchedPaths is the target and every parent up to the root or the working
+// directory: creating, removing or renaming the target changes one of them
+func watchedPaths(target string) []string {
+	paths := []string{target}
+	for dir := path.Dir(target); ; dir = path.Dir(dir) {
+		paths = append(paths, dir)
+		if dir == "/" || dir == "." {
+			return paths
+		}
+	}
+}
+
+// fingerprints stats the watched paths of each condition in one exec. Missing
+// paths print an error, which is as good a fingerprint as a timestamp.
+func fingerprints(conditions []WinCondition, v Validator) []string {
+	var script strings.Builder
+	for i, wc := range conditions {
+		fmt.Fprintf(&script, "echo '@%d'; stat -c '%%n|%%s|%%y|%%z' -- %s 2>&1; ", i, strings.Join(watchedPaths(wc.Target), " "))
+	}
+	out, _ := v.ExecuteValidation(script.String())
+
+	prints := make([]string, len(conditions))
+	for i := range conditions {
+		marker := fmt.Sprintf("@%d\n", i)
+		start := strings.Index(out, marker)
+		if start < 0 {
+			continue // Empty means unknown, which never matches a cached entry
+		}
+		rest := out[start+len(marker):]
+		if end := strings.Index(rest, fmt.Sprintf("@%d\n", i+1)); end >= 0 {
+			rest = rest[:end]
+		}
+		prints[i] = rest
+	}
+	return prints
+}
+
+// CheckQuest is the package CheckQuest, reusing cached results for path-based
+// conditions whose paths are unchanged since they were last checked
+func (c *ValidationCache) CheckQuest(conditions []WinCondition, v Validator, lastOutput, currentDir string) QuestCheck {
+	if c == nil {
+		return CheckQuest(conditions, v, lastOutput, currentDir)
+	}
+
+	var watched []WinCondition
+	for _, wc := range conditions {
+		if cacheable(wc) {
+			watched = append(watched, wc)
+		}
+	}
+	current := make(map[WinCondition]string, len(watched))
+	if len(watched) > 0 {
+		for i, print := range fingerprints(watched, v) {
+			if print != "" {
+				// Relative targets name a different file from another directory
+				current[watched[i]] = currentDir + "\n" + print
+			}
+		}
+	}
+
+	check := QuestCheck{Conditions: conditions}
+	for _, wc := range conditions {
+		print, watch := current[wc]
+		c.mu.Lock()
+		entry, hit := c.entries[wc]
+		c.mu.Unlock()
+		if watch && hit && entry.fingerprint == print {
+			check.Traces = append(check.Traces, entry.trace)
+			continue
+		}
+
+		trace := TraceWinCondition(wc, v, lastOutput, currentDir)
+		if watch {
+			c.mu.Lock()
+			c.entries[wc] = cachedTrace{fingerprint: print, trace: trace}
+			c.mu.Unlock()
+		}
+		check.Traces = append(check.Traces, trace)
+	}
+	return check
+}
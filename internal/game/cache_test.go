@@ -0,0 +1,88 @@
+package game
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// statValidator answers the cache's stat probe with whatever stats holds and
+// counts the real checks that get past it
+type statValidator struct {
+	fakeValidator
+	stats  string
+	checks int
+}
+
+func (s *statValidator) ExecuteValidation(command string) (string, error) {
+	if strings.HasPrefix(command, "echo '@0'") {
+		return "@0\n" + s.stats, nil
+	}
+	s.checks++
+	return s.fakeValidator.ExecuteValidation(command)
+}
+
+func TestValidationCache(t *testing.T) {
+	v := &statValidator{
+		fakeValidator: fakeValidator{outputs: map[string]string{"test -d /home/player/hut && echo yes": "yes\n"}},
+		stats:         "/home/player/hut|4096|2026-01-01 10:00:00.1|2026-01-01 10:00:00.1\n",
+	}
+	conditions := []WinCondition{
+		{Type: DirExists, Target: "/home/player/hut"},
+		{Type: UserOutputContains, Expected: "hi"}, // Depends on the last output, never cached
+	}
+	cache := NewValidationCache()
+
+	first := cache.CheckQuest(conditions, v, "hi", "/home/player")
+	if !first.Passed() || v.checks != 1 {
+		t.Fatalf("first check passed=%v with %d execs, want a pass after 1", first.Passed(), v.checks)
+	}
+
+	// Nothing changed: the cached trace is reused without running test -d
+	second := cache.CheckQuest(conditions, v, "hi", "/home/player")
+	if v.checks != 1 || !reflect.DeepEqual(first.Traces, second.Traces) {
+		t.Errorf("unchanged paths ran %d checks, want the cached result", v.checks-1)
+	}
+
+	// Another directory could resolve a relative target differently
+	cache.CheckQuest(conditions, v, "hi", "/tmp")
+	if v.checks != 2 {
+		t.Errorf("changed working directory ran %d checks, want 1", v.checks-1)
+	}
+
+	// The directory was removed: the stat output changes and the check reruns
+	delete(v.outputs, "test -d /home/player/hut && echo yes")
+	v.stats = "stat: cannot statx '/home/player/hut': No such file or directory\n"
+	if cache.CheckQuest(conditions, v, "hi", "/tmp").Passed() {
+		t.Error("removed directory still passes from the cache")
+	}
+	if v.checks != 3 {
+		t.Errorf("changed paths ran %d checks in total, want 3", v.checks)
+	}
+}
+
+func TestValidationCacheNil(t *testing.T) {
+	v := &statValidator{fakeValidator: fakeValidator{outputs: map[string]string{"test -d /x && echo yes": "yes\n"}}}
+	var cache *ValidationCache
+	conditions := []WinCondition{{Type: DirExists, Target: "/x"}}
+	for range 2 {
+		if !cache.CheckQuest(conditions, v, "", "/").Passed() {
+			t.Fatal("nil cache failed a passing check")
+		}
+	}
+	if v.checks != 2 {
+		t.Errorf("nil cache ran %d checks, want every check run", v.checks)
+	}
+}
+
+func TestWatchedPaths(t *testing.T) {
+	tests := map[string][]string{
+		"/home/player/hut": {"/home/player/hut", "/home/player", "/home", "/"},
+		"hut/door":         {"hut/door", "hut", "."},
+	}
+	for target, want := range tests {
+		if got := watchedPaths(target); !reflect.DeepEqual(got, want) {
+			t.Errorf("watchedPaths(%q) = %q, want %q", target, got, want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package game
+
+// DefaultCategory is used for quests without a category
+const DefaultCategory = "general"
+
+// CategoryProgress is the completion count for one skill area
+type CategoryProgress struct {
+	Name  string `json:"name"`
+	Done  int    `json:"done"`
+	Total int    `json:"total"`
+}
+
+// QuestCategory returns the quest's category, or DefaultCategory when unset
+func (q Quest) QuestCategory() string {
+	if q.Category == "" {
+		return DefaultCategory
+	}
+	return q.Category
+}
+
+// ProgressByCategory counts completed quests per category, in the order categories
+// first appear. Quests before index completed count as done.
+func ProgressByCategory(quests []Quest, completed int) []CategoryProgress {
+	var result []CategoryProgress
+	index := make(map[string]int)
+
+	for i, q := range quests {
+		name := q.QuestCategory()
+		pos, ok := index[name]
+		if !ok {
+			pos = len(result)
+			index[name] = pos
+			result = append(result, CategoryProgress{Name: name})
+		}
+		result[pos].Total++
+		if i < completed {
+			result[pos].Done++
+		}
+	}
+	return result
+}
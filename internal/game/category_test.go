@@ -0,0 +1,29 @@
+package game
+
+import "testing"
+
+func TestProgressByCategory(t *testing.T) {
+	quests := []Quest{
+		{ID: 1, Category: "filesystem"},
+		{ID: 2, Category: "filesystem"},
+		{ID: 3, Category: "users"},
+		{ID: 4},
+		{ID: 5, Category: "filesystem"},
+	}
+
+	progress := ProgressByCategory(quests, 3)
+	want := []CategoryProgress{
+		{Name: "filesystem", Done: 2, Total: 3},
+		{Name: "users", Done: 1, Total: 1},
+		{Name: DefaultCategory, Done: 0, Total: 1},
+	}
+
+	if len(progress) != len(want) {
+		t.Fatalf("Expected %d categories, got %v", len(want), progress)
+	}
+	for i := range want {
+		if progress[i] != want[i] {
+			t.Errorf("Category %d: expected %+v, got %+v", i, want[i], progress[i])
+		}
+	}
+}
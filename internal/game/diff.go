@@ -0,0 +1,86 @@
+package game
+
+import "strings"
+
+// DiffOp marks a line in a diff
+type DiffOp byte
+
+const (
+	DiffSame   DiffOp = ' '
+	DiffRemove DiffOp = '-' // Expected but missing
+	DiffAdd    DiffOp = '+' // Present but not expected
+)
+
+// DiffLine is one line of a line-based diff
+type DiffLine struct {
+	Op   DiffOp
+	Text string
+}
+
+// maxDiffLines bounds the LCS table; bigger files are compared as a whole block
+const maxDiffLines = 1000
+
+// DiffLines returns a minimal line diff turning expected into actual
+func DiffLines(expected, actual string) []DiffLine {
+	a := splitDiffLines(expected)
+	b := splitDiffLines(actual)
+
+	if len(a) > maxDiffLines || len(b) > maxDiffLines {
+		var out []DiffLine
+		for _, l := range a {
+			out = append(out, DiffLine{DiffRemove, l})
+		}
+		for _, l := range b {
+			out = append(out, DiffLine{DiffAdd, l})
+		}
+		return out
+	}
+
+	// lcs[i][j] is the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []DiffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, DiffLine{DiffSame, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, DiffLine{DiffRemove, a[i]})
+			i++
+		default:
+			out = append(out, DiffLine{DiffAdd, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, DiffLine{DiffRemove, a[i]})
+	}
+	for ; j < len(b); j++ {
+		out = append(out, DiffLine{DiffAdd, b[j]})
+	}
+	return out
+}
+
+// splitDiffLines splits on newlines, ignoring the final one
+func splitDiffLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,26 @@
+package game
+
+import "testing"
+
+func TestDiffLines(t *testing.T) {
+	got := DiffLines("alpha\nbeta\ngamma\n", "alpha\nBeta\ngamma\ndelta\n")
+	want := []DiffLine{
+		{DiffSame, "alpha"},
+		{DiffRemove, "beta"},
+		{DiffAdd, "Beta"},
+		{DiffSame, "gamma"},
+		{DiffAdd, "delta"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Line %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+
+	if d := DiffLines("same\n", "same"); len(d) != 1 || d[0].Op != DiffSame {
+		t.Errorf("Expected a missing final newline to be ignored, got %v", d)
+	}
+}
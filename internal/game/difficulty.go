@@ -0,0 +1,74 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Difficulty levels, for --difficulty
+const (
+	DifficultyEasy   = "easy"
+	DifficultyNormal = "normal"
+	DifficultyHard   = "hard"
+)
+
+// DifficultyNames lists the levels, easiest first
+var DifficultyNames = []string{DifficultyEasy, DifficultyNormal, DifficultyHard}
+
+// Difficulty is everything one difficulty level sets, so a single choice
+// tunes the game instead of a handful of flags
+type Difficulty struct {
+	Name            string
+	HardMode        bool          // Objectives hide the commands to use, and Glitch offers no help
+	HintCost        int           // XP a hint costs; 0 makes hints free
+	SuggestCommands bool          // The quest's suggested command is typed into the prompt
+	CommandTimeout  time.Duration // How long a player command may run before it is killed
+	XPPercent       int           // Scales quest rewards and bonuses
+	Guard           bool          // Ask before a command that would wipe the home directory
+}
+
+// Difficulties holds each level's settings by name. Hard is the classic Hard
+// Mode; normal is the game as it always played.
+var Difficulties = map[string]Difficulty{
+	DifficultyEasy: {
+		Name:            DifficultyEasy,
+		HintCost:        0,
+		SuggestCommands: true,
+		CommandTimeout:  15 * time.Second,
+		XPPercent:       50,
+		Guard:           true,
+	},
+	DifficultyNormal: {
+		Name:            DifficultyNormal,
+		HintCost:        HintCost,
+		SuggestCommands: true,
+		CommandTimeout:  5 * time.Second,
+		XPPercent:       100,
+		Guard:           true,
+	},
+	DifficultyHard: {
+		Name:           DifficultyHard,
+		HardMode:       true,
+		HintCost:       HintCost,
+		CommandTimeout: 5 * time.Second,
+		XPPercent:      100,
+	},
+}
+
+// LookupDifficulty returns the settings for a level by name; empty is normal
+func LookupDifficulty(name string) (Difficulty, error) {
+	if name == "" {
+		name = DifficultyNormal
+	}
+	d, ok := Difficulties[name]
+	if !ok {
+		return Difficulty{}, fmt.Errorf("unknown difficulty %q (expected one of: %s)", name, strings.Join(DifficultyNames, ", "))
+	}
+	return d, nil
+}
+
+// XP scales a quest reward or bonus for the level
+func (d Difficulty) XP(reward int) int {
+	return reward * d.XPPercent / 100
+}
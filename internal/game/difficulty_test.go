@@ -0,0 +1,41 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDifficulties(t *testing.T) {
+	cases := []struct {
+		name           string
+		hardMode       bool
+		hintCost       int
+		suggest, guard bool
+		timeout        time.Duration
+		xpFor100       int
+	}{
+		{DifficultyEasy, false, 0, true, true, 15 * time.Second, 50},
+		{DifficultyNormal, false, HintCost, true, true, 5 * time.Second, 100},
+		{DifficultyHard, true, HintCost, false, false, 5 * time.Second, 100},
+	}
+	for _, tc := range cases {
+		d, err := LookupDifficulty(tc.name)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if d.Name != tc.name || d.HardMode != tc.hardMode || d.HintCost != tc.hintCost ||
+			d.SuggestCommands != tc.suggest || d.Guard != tc.guard || d.CommandTimeout != tc.timeout {
+			t.Errorf("%s: unexpected settings %+v", tc.name, d)
+		}
+		if got := d.XP(100); got != tc.xpFor100 {
+			t.Errorf("%s: a 100 XP quest pays %d, want %d", tc.name, got, tc.xpFor100)
+		}
+	}
+
+	if d, err := LookupDifficulty(""); err != nil || d.Name != DifficultyNormal {
+		t.Errorf("Expected no choice to mean normal, got %q, %v", d.Name, err)
+	}
+	if _, err := LookupDifficulty("nightmare"); err == nil {
+		t.Error("Expected an unknown difficulty to be refused")
+	}
+}
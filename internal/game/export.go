@@ -0,0 +1,78 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ExportFormatVersion is bumped whenever the export layout changes
+const ExportFormatVersion = 1
+
+// ExportFile is the portable progress file written by --export
+type ExportFile struct {
+	FormatVersion int       `json:"format_version"`
+	State         GameState `json:"state"`
+}
+
+// ExportState writes the active save to path as a portable progress file
+func ExportState(path string) error {
+	state, err := LoadState()
+	if err != nil {
+		return fmt.Errorf("failed to load save: %w", err)
+	}
+
+	data, err := json.MarshalIndent(ExportFile{FormatVersion: ExportFormatVersion, State: state}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// ReadExport parses and validates a portable progress file
+func ReadExport(path string) (GameState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return GameState{}, err
+	}
+
+	var file ExportFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return GameState{}, fmt.Errorf("invalid progress file: %w", err)
+	}
+	if file.FormatVersion == 0 {
+		return GameState{}, errors.New("invalid progress file: missing format_version")
+	}
+	if file.FormatVersion > ExportFormatVersion {
+		return GameState{}, fmt.Errorf("progress file format %d is newer than supported (%d); please update the game", file.FormatVersion, ExportFormatVersion)
+	}
+	if file.State.CurrentQuestID < 0 {
+		return GameState{}, fmt.Errorf("invalid progress file: negative quest ID %d", file.State.CurrentQuestID)
+	}
+	if err := file.State.migrate(); err != nil {
+		return GameState{}, err
+	}
+	return file.State, nil
+}
+
+// ImportState replaces the active save with the progress file at path.
+// An existing save is only overwritten when force is set.
+func ImportState(path string, force bool) error {
+	state, err := ReadExport(path)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		savePath, err := GetSavePath()
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(savePath); err == nil {
+			return errors.New("a save already exists; use --force to overwrite it")
+		}
+	}
+
+	return SaveState(state)
+}
@@ -0,0 +1,39 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadExport(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	good := write("good.json", `{"format_version": 1, "state": {"current_quest_id": 7, "total_xp": 120}}`)
+	state, err := ReadExport(good)
+	if err != nil {
+		t.Fatalf("Expected valid export to load: %v", err)
+	}
+	if state.CurrentQuestID != 7 || state.TotalXP != 120 {
+		t.Errorf("Unexpected state: %+v", state)
+	}
+
+	for name, body := range map[string]string{
+		"missing_version.json": `{"state": {"current_quest_id": 1}}`,
+		"future.json":          `{"format_version": 99, "state": {}}`,
+		"negative.json":        `{"format_version": 1, "state": {"current_quest_id": -1}}`,
+		"garbage.json":         `not json`,
+	} {
+		if _, err := ReadExport(write(name, body)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
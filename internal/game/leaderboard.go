@@ -0,0 +1,88 @@
+package game
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// MaxLeaderboardEntries caps the runs kept on the leaderboard; slower ones drop off
+const MaxLeaderboardEntries = 50
+
+// LeaderboardEntry is one full playthrough
+type LeaderboardEntry struct {
+	Profile string        `json:"profile"`
+	Time    time.Duration `json:"time"`
+	XP      int           `json:"xp"`
+	Hints   int           `json:"hints"`
+	Date    time.Time     `json:"date"`
+}
+
+// LeaderboardPath is the local high score file, next to the save
+func LeaderboardPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "goblin-terminal", "leaderboard.json"), nil
+}
+
+// LoadLeaderboard returns the leaderboard, best run first. No file yet means
+// no runs.
+func LoadLeaderboard() ([]LeaderboardEntry, error) {
+	path, err := LeaderboardPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []LeaderboardEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	rankLeaderboard(entries)
+	return entries, nil
+}
+
+// AddLeaderboardEntry records a run and returns its rank, 1 being the best,
+// or 0 if it was too slow to make the board
+func AddLeaderboardEntry(entry LeaderboardEntry) (int, error) {
+	entries, err := LoadLeaderboard()
+	if err != nil {
+		return 0, err
+	}
+	entries = append(entries, entry)
+	rankLeaderboard(entries)
+	entries = entries[:min(len(entries), MaxLeaderboardEntries)]
+
+	path, err := LeaderboardPath()
+	if err != nil {
+		return 0, err
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return 0, err
+	}
+	return slices.Index(entries, entry) + 1, nil
+}
+
+// rankLeaderboard orders runs fastest first, then by more XP, then fewer
+// hints. Equal runs keep the earlier one ahead.
+func rankLeaderboard(entries []LeaderboardEntry) {
+	slices.SortStableFunc(entries, func(a, b LeaderboardEntry) int {
+		return cmp.Or(cmp.Compare(a.Time, b.Time), cmp.Compare(b.XP, a.XP), cmp.Compare(a.Hints, b.Hints))
+	})
+}
@@ -0,0 +1,55 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeaderboard(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	if entries, err := LoadLeaderboard(); err != nil || len(entries) != 0 {
+		t.Fatalf("Expected an empty leaderboard without a file, got %v, %v", entries, err)
+	}
+
+	runs := []LeaderboardEntry{
+		{Profile: "slow", Time: 30 * time.Minute, XP: 500},
+		{Profile: "fast", Time: 10 * time.Minute, XP: 400},
+		{Profile: "rich", Time: 10 * time.Minute, XP: 600, Hints: 2},
+	}
+	wantRanks := []int{1, 1, 1}
+	for i, run := range runs {
+		rank, err := AddLeaderboardEntry(run)
+		if err != nil || rank != wantRanks[i] {
+			t.Errorf("AddLeaderboardEntry(%s) = %d, %v, want rank %d", run.Profile, rank, err, wantRanks[i])
+		}
+	}
+
+	entries, err := LoadLeaderboard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	for _, e := range entries {
+		order = append(order, e.Profile)
+	}
+	if len(order) != 3 || order[0] != "rich" || order[1] != "fast" || order[2] != "slow" {
+		t.Errorf("Expected ties broken by XP, got %v", order)
+	}
+
+	// A full board drops the slowest runs
+	for i := range MaxLeaderboardEntries {
+		if _, err := AddLeaderboardEntry(LeaderboardEntry{Profile: "quick", Time: time.Duration(i+1) * time.Second}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, _ = LoadLeaderboard()
+	if len(entries) != MaxLeaderboardEntries || entries[len(entries)-1].Profile != "quick" {
+		t.Errorf("Expected the board capped at %d quick runs, got %d", MaxLeaderboardEntries, len(entries))
+	}
+	if rank, err := AddLeaderboardEntry(LeaderboardEntry{Profile: "late", Time: time.Hour}); err != nil || rank != 0 {
+		t.Errorf("Expected a run off the board to rank 0, got %d, %v", rank, err)
+	}
+}
@@ -3,21 +3,69 @@ package game
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"gopkg.in/yaml.v3"
 )
 
+// questFile is the extended quest file layout with a shared setup library.
+// A plain top-level list of quests is still accepted.
+type questFile struct {
+	Setups map[string][]string `yaml:"setups"`
+	Quests []Quest             `yaml:"quests"`
+}
+
 // LoadQuests parses a YAML file containing a list of quests
-func LoadQuests(filepath string) ([]Quest, error) {
-	data, err := os.ReadFile(filepath)
+func LoadQuests(path string) ([]Quest, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read quest file: %w", err)
 	}
 
-	var quests []Quest
-	if err := yaml.Unmarshal(data, &quests); err != nil {
+	return ParseQuests(data)
+}
+
+// ParseQuests decodes quest YAML, either a bare list of quests or a mapping
+// with "setups" and "quests" keys, and resolves each quest's setup_ref
+func ParseQuests(data []byte) ([]Quest, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse quests YAML: %w", err)
+	}
+
+	var file questFile
+	if len(root.Content) > 0 && root.Content[0].Kind == yaml.MappingNode {
+		if err := root.Decode(&file); err != nil {
+			return nil, fmt.Errorf("failed to parse quests YAML: %w", err)
+		}
+	} else if err := root.Decode(&file.Quests); err != nil {
 		return nil, fmt.Errorf("failed to parse quests YAML: %w", err)
 	}
 
-	return quests, nil
+	for i := range file.Quests {
+		q := &file.Quests[i]
+		if q.SetupRef == "" {
+			continue
+		}
+		setup, ok := file.Setups[q.SetupRef]
+		if !ok {
+			return nil, fmt.Errorf("quest %d references unknown setup %q", q.ID, q.SetupRef)
+		}
+		// Library commands run first, then any quest-specific ones
+		q.SetupCommands = append(append([]string{}, setup...), q.SetupCommands...)
+	}
+
+	return file.Quests, nil
+}
+
+// LocalizedQuestPath returns quests.<lang>.yaml inside dir if it exists,
+// otherwise the default quests.yaml
+func LocalizedQuestPath(dir, lang string) string {
+	if lang != "" && lang != "en" {
+		localized := filepath.Join(dir, fmt.Sprintf("quests.%s.yaml", lang))
+		if _, err := os.Stat(localized); err == nil {
+			return localized
+		}
+	}
+	return filepath.Join(dir, "quests.yaml")
 }
@@ -23,3 +23,65 @@ func TestLoadQuests(t *testing.T) {
 		t.Errorf("Expected first quest ID to be 1, got %d", quests[0].ID)
 	}
 }
+
+func TestLocalizedQuestPath(t *testing.T) {
+	dir := filepath.Join("..", "..", "quests")
+
+	if got := LocalizedQuestPath(dir, "en"); got != filepath.Join(dir, "quests.yaml") {
+		t.Errorf("Expected default quests file for en, got %s", got)
+	}
+
+	// Missing translations fall back to the default file
+	if got := LocalizedQuestPath(dir, "xx"); got != filepath.Join(dir, "quests.yaml") {
+		t.Errorf("Expected fallback to default quests file, got %s", got)
+	}
+}
+
+func TestParseQuestsSetupLibrary(t *testing.T) {
+	data := []byte(`
+setups:
+  artifacts:
+    - "touch /tmp/a"
+    - "touch /tmp/b"
+quests:
+  - id: 1
+    title: "One"
+    setup_ref: "artifacts"
+    setup_commands:
+      - "touch /tmp/c"
+  - id: 2
+    title: "Two"
+`)
+
+	quests, err := ParseQuests(data)
+	if err != nil {
+		t.Fatalf("Failed to parse quests: %v", err)
+	}
+
+	if len(quests) != 2 {
+		t.Fatalf("Expected 2 quests, got %d", len(quests))
+	}
+
+	want := []string{"touch /tmp/a", "touch /tmp/b", "touch /tmp/c"}
+	got := quests[0].SetupCommands
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d setup commands, got %v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Setup command %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+func TestParseQuestsUnknownSetup(t *testing.T) {
+	data := []byte(`
+quests:
+  - id: 1
+    setup_ref: "missing"
+`)
+
+	if _, err := ParseQuests(data); err == nil {
+		t.Error("Expected error for unknown setup reference")
+	}
+}
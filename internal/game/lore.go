@@ -0,0 +1,20 @@
+package game
+
+import "strings"
+
+// MaxLoreEntries caps the story log kept in the save; the oldest beats go first
+const MaxLoreEntries = 300
+
+// AddLore records a story beat (Glitch's lines, success texts) in the save's
+// message log so it can be re-read later. Blank text and an immediate repeat,
+// like the same intro shown again after a resume, are skipped.
+func (s *GameState) AddLore(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" || (len(s.MsgLog) > 0 && s.MsgLog[len(s.MsgLog)-1] == text) {
+		return
+	}
+	s.MsgLog = append(s.MsgLog, text)
+	if over := len(s.MsgLog) - MaxLoreEntries; over > 0 {
+		s.MsgLog = s.MsgLog[over:]
+	}
+}
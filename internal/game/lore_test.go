@@ -0,0 +1,25 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestAddLore(t *testing.T) {
+	var s GameState
+	s.AddLore("Welcome, grunt.\n")
+	s.AddLore("Welcome, grunt.")
+	s.AddLore("   ")
+	s.AddLore("Nice hut.")
+	if want := []string{"Welcome, grunt.", "Nice hut."}; !slices.Equal(s.MsgLog, want) {
+		t.Errorf("MsgLog = %q, want %q", s.MsgLog, want)
+	}
+
+	for i := range MaxLoreEntries {
+		s.AddLore(fmt.Sprint("beat ", i))
+	}
+	if len(s.MsgLog) != MaxLoreEntries || s.MsgLog[0] != "beat 0" {
+		t.Errorf("Expected the log capped at %d with the oldest dropped, got %d starting %q", MaxLoreEntries, len(s.MsgLog), s.MsgLog[0])
+	}
+}
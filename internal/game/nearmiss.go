@@ -0,0 +1,65 @@
+package game
+
+import "strings"
+
+// Ways an output check can miss by a little, for NearMiss
+const (
+	NearCase       = "case"       // Right apart from capitalization
+	NearWhitespace = "whitespace" // Right apart from spacing or line breaks
+	NearTypo       = "typo"       // A character or two off
+)
+
+// maxTypoDistance caps the edits a near miss may be from the answer, however
+// long the answer is
+const maxTypoDistance = 3
+
+// NearMiss tells how a failed output check came close to passing, or returns
+// "" when it wasn't close. Only exact-match output checks are judged, and the
+// check's verdict stands either way: this is only for a friendlier nudge.
+func NearMiss(wc WinCondition, t CheckTrace) string {
+	if t.Passed || t.Observed == "" || (wc.Type != UserOutputMatch && wc.Type != CommandOut) {
+		return ""
+	}
+	observed, expected := strings.TrimSpace(t.Observed), strings.TrimSpace(t.Expected)
+	squeeze := func(s string) string { return strings.Join(strings.Fields(s), " ") }
+	switch {
+	case strings.EqualFold(observed, expected):
+		return NearCase
+	case squeeze(observed) == squeeze(expected):
+		return NearWhitespace
+	case strings.EqualFold(squeeze(observed), squeeze(expected)):
+		return NearCase
+	}
+	// Short answers like "700" have no room for typos: 755 is just wrong
+	allowed := min(len([]rune(expected))/10, maxTypoDistance)
+	if allowed > 0 && editDistance(observed, expected, allowed) <= allowed {
+		return NearTypo
+	}
+	return ""
+}
+
+// editDistance is the Levenshtein distance between a and b, counting runes.
+// Past limit it only reports something above limit.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > limit || -d > limit {
+		return limit + 1
+	}
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,32 @@
+package game
+
+import "testing"
+
+func TestNearMiss(t *testing.T) {
+	match := WinCondition{Type: UserOutputMatch}
+	cases := []struct {
+		wc                 WinCondition
+		observed, expected string
+		want               string
+	}{
+		{match, "Hello Goblin", "hello goblin", NearCase},
+		{match, "hello   goblin\n", "hello goblin", NearWhitespace},
+		{match, "HELLO\ngoblin", "hello goblin", NearCase},
+		{match, "the goblin hides in the cavr", "the goblin hides in the cave", NearTypo},
+		{match, "the goblin sleeps", "the goblin hides in the cave", ""},
+		{WinCondition{Type: CommandOut}, "755", "700", ""},
+		{WinCondition{Type: CommandOut}, "Player", "player", NearCase},
+		{WinCondition{Type: FileContains}, "Hello", "hello", ""},
+		{match, "", "hello", ""},
+	}
+	for _, tc := range cases {
+		got := NearMiss(tc.wc, CheckTrace{Observed: tc.observed, Expected: tc.expected})
+		if got != tc.want {
+			t.Errorf("NearMiss(%q, %q) = %q, want %q", tc.observed, tc.expected, got, tc.want)
+		}
+	}
+
+	if got := NearMiss(match, CheckTrace{Passed: true, Observed: "x", Expected: "x"}); got != "" {
+		t.Errorf("Expected no nudge for a pass, got %q", got)
+	}
+}
@@ -2,13 +2,81 @@ package game
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"time"
 )
 
+// CurrentStateVersion is the save format written by this build.
+// Bump it and add a step to migrate when GameState changes shape.
+const CurrentStateVersion = 2
+
 type GameState struct {
+	Version        int      `json:"version"` // Save format version; missing means v0
 	CurrentQuestID int      `json:"current_quest_id"`
-	MsgLog         []string `json:"msg_log"` // Optional: save history? For now just quest ID is key.
+	MsgLog         []string `json:"msg_log"` // Story beats seen so far, for the lore command (see AddLore)
+
+	// XP economy
+	TotalXP     int          `json:"total_xp"`
+	SpentXP     int          `json:"spent_xp"`
+	HintsBought map[int]bool `json:"hints_bought,omitempty"`
+	// BonusesEarned lists, per quest ID, the optional objectives already rewarded
+	BonusesEarned map[int][]int `json:"bonuses_earned,omitempty"`
+
+	// Speedrun records
+	BestQuestTimes map[int]time.Duration `json:"best_quest_times,omitempty"`
+	BestTotalTime  time.Duration         `json:"best_total_time,omitempty"`
+
+	// SeenOnboarding is set once the first-run tutorial has been dismissed
+	SeenOnboarding bool `json:"seen_onboarding,omitempty"`
+
+	// CurrentDir is the player's working directory at the last save, restored on resume
+	CurrentDir string `json:"current_dir,omitempty"`
+
+	// QuestCount is how many quests the game had at the last save, so an
+	// update that adds quests can be told apart from a save mid-campaign
+	QuestCount int `json:"quest_count,omitempty"`
+
+	// History is the player's most recent commands, for the up arrow and the
+	// recap shown on resume
+	History []string `json:"history,omitempty"`
+
+	// Difficulty is the level last chosen with --difficulty, kept for later launches
+	Difficulty string `json:"difficulty,omitempty"`
+}
+
+// SavedHistoryLimit caps the commands kept in the save
+const SavedHistoryLimit = 200
+
+// RememberHistory keeps the newest SavedHistoryLimit commands of history
+func (s *GameState) RememberHistory(history []string) {
+	s.History = slices.Clone(history[max(len(history)-SavedHistoryLimit, 0):])
+}
+
+// NewQuestCount is how many quests were added since a player who had finished
+// every quest last saved, or 0 if they hadn't finished or nothing was added
+func (s GameState) NewQuestCount(total int) int {
+	if s.QuestCount == 0 || s.CurrentQuestID < s.QuestCount || total <= s.QuestCount {
+		return 0
+	}
+	return total - s.QuestCount
+}
+
+// ResumeIndex is the quest index to continue from with total quests loaded.
+// A finished player picks up at the first quest added since, or stays
+// finished (total) if there are none, and a save that points past a shorter
+// quest list is clamped to the last quest.
+func (s GameState) ResumeIndex(total int) int {
+	if s.NewQuestCount(total) > 0 {
+		return s.QuestCount
+	}
+	if s.CurrentQuestID > total {
+		return total - 1
+	}
+	return max(s.CurrentQuestID, 0)
 }
 
 func GetSavePath() (string, error) {
@@ -35,6 +103,7 @@ func SaveState(state GameState) error {
 	}
 	defer file.Close()
 
+	state.Version = CurrentStateVersion
 	encoder := json.NewEncoder(file)
 	return encoder.Encode(state)
 }
@@ -51,10 +120,47 @@ func LoadState() (GameState, error) {
 	}
 	defer file.Close()
 
+	return decodeState(file)
+}
+
+// decodeState reads a save and upgrades it to CurrentStateVersion
+func decodeState(r io.Reader) (GameState, error) {
 	var state GameState
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&state)
-	return state, err
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return state, err
+	}
+	if err := state.migrate(); err != nil {
+		return GameState{}, err
+	}
+	return state, nil
+}
+
+// migrate upgrades an older save one version at a time
+func (s *GameState) migrate() error {
+	if s.Version > CurrentStateVersion {
+		return fmt.Errorf("save format %d is newer than supported (%d); please update the game", s.Version, CurrentStateVersion)
+	}
+
+	for s.Version < CurrentStateVersion {
+		switch s.Version {
+		case 0:
+			// v0 only stored the quest index. XP, hints and records start empty.
+			if s.CurrentQuestID < 0 {
+				s.CurrentQuestID = 0
+			}
+			if s.HintsBought == nil {
+				s.HintsBought = make(map[int]bool)
+			}
+			if s.BestQuestTimes == nil {
+				s.BestQuestTimes = make(map[int]time.Duration)
+			}
+		case 1:
+			// Anyone with a v1 save has already played, so skip the tutorial
+			s.SeenOnboarding = true
+		}
+		s.Version++
+	}
+	return nil
 }
 
 func ResetState() error {
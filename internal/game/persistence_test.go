@@ -0,0 +1,84 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDecodeStateMigratesV0(t *testing.T) {
+	// A save written before versioning: only the quest index and message log
+	v0 := `{"current_quest_id": 12, "msg_log": null}`
+
+	state, err := decodeState(strings.NewReader(v0))
+	if err != nil {
+		t.Fatalf("Failed to decode v0 save: %v", err)
+	}
+
+	if state.Version != CurrentStateVersion {
+		t.Errorf("Expected version %d, got %d", CurrentStateVersion, state.Version)
+	}
+	if state.CurrentQuestID != 12 {
+		t.Errorf("Expected quest ID to be kept, got %d", state.CurrentQuestID)
+	}
+	if state.TotalXP != 0 || state.Balance() != 0 {
+		t.Errorf("Expected XP to default to 0, got total=%d balance=%d", state.TotalXP, state.Balance())
+	}
+	if state.HintsBought == nil || state.BestQuestTimes == nil {
+		t.Error("Expected maps to be initialized by migration")
+	}
+	if !state.SeenOnboarding {
+		t.Error("Expected existing players to skip onboarding")
+	}
+}
+
+func TestDecodeStateRejectsFutureVersion(t *testing.T) {
+	future := `{"version": 999, "current_quest_id": 1}`
+
+	if _, err := decodeState(strings.NewReader(future)); err == nil {
+		t.Error("Expected error for a save from a newer version")
+	}
+}
+
+func TestResumeIndex(t *testing.T) {
+	cases := []struct {
+		name      string
+		state     GameState
+		total     int
+		wantIdx   int
+		wantAdded int
+	}{
+		{"mid campaign", GameState{CurrentQuestID: 4, QuestCount: 10}, 10, 4, 0},
+		{"finished, nothing new", GameState{CurrentQuestID: 10, QuestCount: 10}, 10, 10, 0},
+		{"finished, quests added", GameState{CurrentQuestID: 10, QuestCount: 10}, 13, 10, 3},
+		{"mid campaign, quests added", GameState{CurrentQuestID: 4, QuestCount: 10}, 13, 4, 0},
+		{"quest list shrank", GameState{CurrentQuestID: 12, QuestCount: 20}, 8, 7, 0},
+		{"old save without a count", GameState{CurrentQuestID: 30}, 25, 24, 0},
+	}
+	for _, tc := range cases {
+		if got := tc.state.ResumeIndex(tc.total); got != tc.wantIdx {
+			t.Errorf("%s: expected to resume at %d, got %d", tc.name, tc.wantIdx, got)
+		}
+		if got := tc.state.NewQuestCount(tc.total); got != tc.wantAdded {
+			t.Errorf("%s: expected %d new quests, got %d", tc.name, tc.wantAdded, got)
+		}
+	}
+}
+
+func TestRememberHistory(t *testing.T) {
+	var s GameState
+	history := make([]string, SavedHistoryLimit+5)
+	for i := range history {
+		history[i] = fmt.Sprint("cmd ", i)
+	}
+	s.RememberHistory(history)
+	if len(s.History) != SavedHistoryLimit || s.History[0] != "cmd 5" {
+		t.Errorf("Expected the newest %d commands, got %d starting %q", SavedHistoryLimit, len(s.History), s.History[0])
+	}
+
+	// The save keeps its own copy
+	history[len(history)-1] = "changed"
+	if s.History[len(s.History)-1] == "changed" {
+		t.Error("Expected the saved history not to alias the live one")
+	}
+}
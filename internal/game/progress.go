@@ -0,0 +1,65 @@
+package game
+
+// Progress is a point-in-time summary of a run, for external dashboards
+type Progress struct {
+	QuestID    int                `json:"quest_id"` // 0 once every quest is done
+	QuestTitle string             `json:"quest_title,omitempty"`
+	Completed  int                `json:"completed"`
+	Total      int                `json:"total"`
+	Percent    int                `json:"percent"` // Weighted by quest effort, see WeightedProgress
+	Finished   bool               `json:"finished"`
+	TotalXP    int                `json:"total_xp"`
+	Balance    int                `json:"xp_balance"`
+	Categories []CategoryProgress `json:"categories"`
+}
+
+// SummarizeProgress describes the player's position at quest index current
+func SummarizeProgress(quests []Quest, state GameState, current int) Progress {
+	completed := current
+	if completed > len(quests) {
+		completed = len(quests)
+	}
+	if completed < 0 {
+		completed = 0
+	}
+
+	p := Progress{
+		Completed:  completed,
+		Total:      len(quests),
+		Finished:   completed == len(quests),
+		Percent:    int(WeightedProgress(quests, completed) * 100),
+		TotalXP:    state.TotalXP,
+		Balance:    state.Balance(),
+		Categories: ProgressByCategory(quests, completed),
+	}
+	if completed < len(quests) {
+		p.QuestID = quests[completed].ID
+		p.QuestTitle = quests[completed].Title
+	}
+	return p
+}
+
+// QuestWeight returns the quest's share of the progress bar, at least 1
+func (q Quest) QuestWeight() int {
+	if q.Weight < 1 {
+		return 1
+	}
+	return q.Weight
+}
+
+// WeightedProgress is the completed share of the total quest weight, from 0 to 1.
+// Quests before index completed count as done. With every weight at 1 it's
+// simply completed over the quest count.
+func WeightedProgress(quests []Quest, completed int) float64 {
+	done, total := 0, 0
+	for i, q := range quests {
+		total += q.QuestWeight()
+		if i < completed {
+			done += q.QuestWeight()
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(done) / float64(total)
+}
@@ -0,0 +1,55 @@
+package game
+
+import "testing"
+
+func TestSummarizeProgress(t *testing.T) {
+	quests := []Quest{
+		{ID: 1, Title: "Wake Up", Category: "filesystem"},
+		{ID: 2, Title: "Look Around", Category: "filesystem"},
+		{ID: 3, Title: "Ping", Category: "networking"},
+	}
+	state := GameState{TotalXP: 30, SpentXP: 10}
+
+	p := SummarizeProgress(quests, state, 1)
+	if p.QuestID != 2 || p.QuestTitle != "Look Around" {
+		t.Errorf("Expected quest 2 to be current, got %d %q", p.QuestID, p.QuestTitle)
+	}
+	if p.Completed != 1 || p.Total != 3 || p.Finished {
+		t.Errorf("Expected 1 of 3 done, got %+v", p)
+	}
+	if p.TotalXP != 30 || p.Balance != 20 {
+		t.Errorf("Expected XP 30 with balance 20, got %d/%d", p.TotalXP, p.Balance)
+	}
+	if len(p.Categories) != 2 || p.Categories[0].Done != 1 {
+		t.Errorf("Expected per-category counts, got %+v", p.Categories)
+	}
+
+	done := SummarizeProgress(quests, state, 3)
+	if !done.Finished || done.QuestID != 0 {
+		t.Errorf("Expected a finished run with no current quest, got %+v", done)
+	}
+}
+
+func TestWeightedProgress(t *testing.T) {
+	// Unweighted quests match the simple count
+	plain := []Quest{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}
+	if got := WeightedProgress(plain, 1); got != 0.25 {
+		t.Errorf("Expected 1 of 4 to be 0.25, got %v", got)
+	}
+
+	// A trivial quest done ahead of a heavy one is a small share
+	weighted := []Quest{{ID: 1}, {ID: 2, Weight: 3}}
+	if got := WeightedProgress(weighted, 1); got != 0.25 {
+		t.Errorf("Expected weight 1 of 4 to be 0.25, got %v", got)
+	}
+	if got := WeightedProgress(weighted, 2); got != 1 {
+		t.Errorf("Expected a finished run to be 1, got %v", got)
+	}
+	if got := WeightedProgress(nil, 0); got != 0 {
+		t.Errorf("Expected no quests to be 0, got %v", got)
+	}
+
+	if p := SummarizeProgress(weighted, GameState{}, 1); p.Percent != 25 {
+		t.Errorf("Expected the summary to carry the weighted percent, got %d", p.Percent)
+	}
+}
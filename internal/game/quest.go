@@ -1,16 +1,30 @@
 package game
 
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
 // WinConditionType defines how we check if a quest is done
 type WinConditionType string
 
 const (
 	DirExists          WinConditionType = "directory_exists"
 	FileExists         WinConditionType = "file_exists"
+	DirNotExists       WinConditionType = "directory_not_exists"
+	FileNotExists      WinConditionType = "file_not_exists"
 	FileContains       WinConditionType = "file_content_contains"
+	FileEquals         WinConditionType = "file_content_equals"
+	FileModified       WinConditionType = "file_modified_recently"
 	CommandOut         WinConditionType = "command_output_matches"
 	UserOutputMatch    WinConditionType = "user_output_matches"
 	UserOutputContains WinConditionType = "user_output_contains"
 	CurrentDirMatch    WinConditionType = "current_working_directory"
+	UserExists         WinConditionType = "user_exists"
+	GroupExists        WinConditionType = "group_exists"
+	UserInGroup        WinConditionType = "user_in_group"
+	ScriptRuns         WinConditionType = "script_runs"
 	Custom             WinConditionType = "custom_check"
 )
 
@@ -21,18 +35,206 @@ type WinCondition struct {
 	Content  string           `yaml:"content,omitempty"`
 	Command  string           `yaml:"command,omitempty"`
 	Expected string           `yaml:"expected_output,omitempty"`
+	// StrictNewlines makes FileEquals compare trailing newlines too
+	StrictNewlines bool `yaml:"strict_newlines,omitempty"`
+	// RootCheck runs the validation commands as root, for targets the player can't read
+	RootCheck bool `yaml:"root_check,omitempty"`
 }
 
 // Quest represents a single level/objective in the game
 type Quest struct {
 	ID            int          `yaml:"id"`
 	Title         string       `yaml:"title"`
+	Category      string       `yaml:"category,omitempty"` // Skill area, e.g. "filesystem" or "networking"
 	IntroText     string       `yaml:"intro_text"`
+	Banner        string       `yaml:"banner,omitempty"` // Optional ASCII art shown when the quest begins
 	Objective     string       `yaml:"objective"`
 	HardObjective string       `yaml:"hard_objective"`
+	Hint          string       `yaml:"hint,omitempty"`     // Falls back to Objective when empty
+	Solution      []string     `yaml:"solution,omitempty"` // Commands that complete the quest, used by demo mode
 	WinCondition  WinCondition `yaml:"win_condition"`
-	SuccessText   string       `yaml:"success_text"`
-	XPReward      int          `yaml:"xp_reward"`
-	Environment   string       `yaml:"environment"` // "local" or "container_image:..."
-	SetupCommands []string     `yaml:"setup_commands,omitempty"`
+	// Checklist adds conditions that must pass along with WinCondition; the
+	// player sees how many of them are done so far
+	Checklist []WinCondition `yaml:"checklist,omitempty"`
+	// NoPingWinCondition replaces WinCondition when the container couldn't get NET_RAW
+	NoPingWinCondition *WinCondition `yaml:"no_ping_win_condition,omitempty"`
+	SuccessText        string        `yaml:"success_text"`
+	XPReward           int           `yaml:"xp_reward"`
+	Weight             int           `yaml:"weight,omitempty"` // Share of the progress bar; defaults to 1
+	Environment        string        `yaml:"environment"`      // "local" or "container_image:..."
+	// SetupCommands may be re-run mid-quest with 'redo-setup', so write them to be
+	// idempotent: mkdir -p, truncate before appending, skip starting what's running.
+	SetupCommands []string `yaml:"setup_commands,omitempty"`
+	SetupRef      string   `yaml:"setup_ref,omitempty"` // Name of a block in the top-level "setups" library
+	// SetupCommandsRoot run as root before SetupCommands, for state the player
+	// couldn't create (users, files under /etc). The same idempotency rule applies.
+	SetupCommandsRoot []string `yaml:"setup_commands_root,omitempty"`
+	// RequiresRoot marks quests that need sudo or root exec; safe mode skips them
+	RequiresRoot bool `yaml:"requires_root,omitempty"`
+	// RestartContainer gives the quest a fresh container before setup runs.
+	// The bind-mounted home persists, so only processes/system state reset.
+	RestartContainer bool `yaml:"restart_container,omitempty"`
+	// RestartPlayer is RestartContainer for the player container alone: the
+	// gateway and scenario hosts keep running. Ignored when RestartContainer is set.
+	RestartPlayer bool `yaml:"restart_player,omitempty"`
+	// ResetPolicy is how much earlier quests' state the quest starts with:
+	// ResetNone (the default), ResetHome or ResetContainer
+	ResetPolicy string `yaml:"reset_policy,omitempty"`
+	// Process names highlighted by the 'processes' helper
+	RelevantProcesses []string `yaml:"relevant_processes,omitempty"`
+	// SuggestedCommand is typed into the prompt when the quest begins, for the
+	// player to edit and run themselves. Never used in Hard Mode.
+	SuggestedCommand string `yaml:"suggested_command,omitempty"`
+	// PollIntervalSeconds re-checks the win condition on a timer, for quests
+	// finished by something other than the player's command (cron, services).
+	// Zero means only check after commands.
+	PollIntervalSeconds int `yaml:"poll_interval_seconds,omitempty"`
+	// TimeLimitSeconds makes the quest a challenge that must be finished in time.
+	// When it runs out the environment is reset and the quest retried, up to
+	// MaxAttempts tries (0 means unlimited), after asking if ConfirmRetry is set.
+	TimeLimitSeconds int  `yaml:"time_limit_seconds,omitempty"`
+	MaxAttempts      int  `yaml:"max_attempts,omitempty"`
+	ConfirmRetry     bool `yaml:"confirm_retry,omitempty"`
+	// Containers replaces the default SSH gateway with the quest's own hosts
+	// (say a web server and a database) while the quest runs
+	Containers []ScenarioHost `yaml:"containers,omitempty"`
+	// Host runs the player's commands on one of the quest's hosts (GatewayHost
+	// unless Containers names others) and shows it in the prompt, as though
+	// they had ssh'd there. Empty keeps them on their own machine.
+	Host string `yaml:"host,omitempty"`
+	// OptionalObjectives earn extra XP when they are also met as the quest
+	// completes. They are never needed to advance.
+	OptionalObjectives []BonusObjective `yaml:"optional_objectives,omitempty"`
+}
+
+// Reset policies, for Quest.ResetPolicy
+const (
+	ResetNone      = "none"      // Carry everything over from earlier quests
+	ResetHome      = "home"      // Put the player's home back to the image's pristine copy
+	ResetContainer = "container" // Fresh containers, as with restart_container
+)
+
+// GatewayHost is the hostname of the default SSH gateway
+const GatewayHost = "gateway"
+
+// BonusObjective is an optional condition checked when its quest completes
+type BonusObjective struct {
+	Description  string `yaml:"description"` // Shown when earned, e.g. "Locked it down to 700"
+	XPBonus      int    `yaml:"xp_bonus"`
+	WinCondition `yaml:",inline"`
+}
+
+// ScenarioHost is an extra container a quest runs on the game network
+type ScenarioHost struct {
+	Name    string `yaml:"name"`              // Hostname the player connects to, e.g. "web"
+	Image   string `yaml:"image,omitempty"`   // Defaults to the game image
+	IP      string `yaml:"ip"`                // Static IP inside the game subnet
+	Command string `yaml:"command,omitempty"` // Startup command, run as root
+}
+
+// TimeLimit is the challenge time limit, zero when the quest has none
+func (q Quest) TimeLimit() time.Duration {
+	return time.Duration(q.TimeLimitSeconds) * time.Second
+}
+
+// ContentEquals compares a file body against the expected content for FileEquals.
+// Line endings are normalized to \n, and unless strict is set, trailing
+// newlines on either side are ignored.
+func ContentEquals(actual, expected string, strict bool) bool {
+	actual = strings.ReplaceAll(actual, "\r\n", "\n")
+	expected = strings.ReplaceAll(expected, "\r\n", "\n")
+	if !strict {
+		actual = strings.TrimRight(actual, "\n")
+		expected = strings.TrimRight(expected, "\n")
+	}
+	return actual == expected
+}
+
+// ActiveWinCondition picks the win condition to check, using the no-ping
+// fallback when ping is unavailable in the container
+func (q Quest) ActiveWinCondition(pingAvailable bool) WinCondition {
+	if !pingAvailable && q.NoPingWinCondition != nil {
+		return *q.NoPingWinCondition
+	}
+	return q.WinCondition
+}
+
+// ActiveWinConditions is every condition the quest needs: the active win
+// condition followed by the checklist
+func (q Quest) ActiveWinConditions(pingAvailable bool) []WinCondition {
+	return append([]WinCondition{q.ActiveWinCondition(pingAvailable)}, q.Checklist...)
+}
+
+// RestartsContainer reports whether the quest starts in fresh containers,
+// by restart_container or reset_policy "container"
+func (q Quest) RestartsContainer() bool {
+	return q.RestartContainer || q.ResetPolicy == ResetContainer
+}
+
+// ResetsHome reports whether the player's home is restored before the quest
+func (q Quest) ResetsHome() bool {
+	return q.ResetPolicy == ResetHome
+}
+
+// NeedsRoot reports whether safe mode has to skip the quest: it needs sudo
+// or root exec, or its setup runs as root
+func (q Quest) NeedsRoot() bool {
+	return q.RequiresRoot || len(q.SetupCommandsRoot) > 0
+}
+
+// SetupResult is one setup command's output, and its error if it failed
+type SetupResult struct {
+	Command string
+	Root    bool // Ran from setup_commands_root
+	Output  string
+	Err     error
+}
+
+// RunSetup runs the quest's setup commands, the root ones first so the
+// player's can build on them, and reports on each. A failed step doesn't stop
+// the rest, but it can leave the quest unwinnable, so callers should say so.
+func (q Quest) RunSetup(v Validator) []SetupResult {
+	var results []SetupResult
+	for _, cmd := range q.SetupCommandsRoot {
+		out, err := v.RunAsRoot(cmd)
+		results = append(results, SetupResult{Command: cmd, Root: true, Output: out, Err: err})
+	}
+	for _, cmd := range q.SetupCommands {
+		out, err := v.ExecuteValidationStrict(cmd)
+		results = append(results, SetupResult{Command: cmd, Output: out, Err: err})
+	}
+	return results
+}
+
+// SetupFailures picks out the setup steps that failed
+func SetupFailures(results []SetupResult) []SetupResult {
+	var failed []SetupResult
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// hasHost reports whether name is one of the hosts running during the quest:
+// its own containers, or the gateway when it has none
+func (q Quest) hasHost(name string) bool {
+	if len(q.Containers) == 0 {
+		return name == GatewayHost
+	}
+	return slices.ContainsFunc(q.Containers, func(h ScenarioHost) bool { return h.Name == name })
+}
+
+// allConditions is every condition the quest declares, whichever is active,
+// including the optional objectives
+func (q Quest) allConditions() []WinCondition {
+	all := append([]WinCondition{q.WinCondition}, q.Checklist...)
+	if q.NoPingWinCondition != nil {
+		all = append(all, *q.NoPingWinCondition)
+	}
+	for _, b := range q.OptionalObjectives {
+		all = append(all, b.WinCondition)
+	}
+	return all
 }
@@ -0,0 +1,96 @@
+package game
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestContentEquals(t *testing.T) {
+	cases := []struct {
+		name     string
+		actual   string
+		expected string
+		strict   bool
+		want     bool
+	}{
+		{"exact", "port=22\nuser=glitch\n", "port=22\nuser=glitch\n", false, true},
+		{"missing trailing newline", "port=22\nuser=glitch", "port=22\nuser=glitch\n", false, true},
+		{"crlf line endings", "port=22\r\nuser=glitch\r\n", "port=22\nuser=glitch\n", false, true},
+		{"near match", "port=23\nuser=glitch\n", "port=22\nuser=glitch\n", false, false},
+		{"extra line", "port=22\nuser=glitch\nextra\n", "port=22\nuser=glitch\n", false, false},
+		{"leading space", " port=22\n", "port=22\n", false, false},
+		{"strict trailing newline", "port=22", "port=22\n", true, false},
+		{"strict exact", "port=22\n", "port=22\n", true, true},
+	}
+
+	for _, tc := range cases {
+		if got := ContentEquals(tc.actual, tc.expected, tc.strict); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+// setupRecorder logs which setup commands ran and as whom
+type setupRecorder struct {
+	fakeValidator
+	ran *[]string
+}
+
+func (r setupRecorder) ExecuteValidationStrict(command string) (string, error) {
+	*r.ran = append(*r.ran, "player: "+command)
+	if strings.HasPrefix(command, "chgrp") {
+		return "", errors.New("exit status 1: chgrp: invalid group: 'goblins'")
+	}
+	return "", nil
+}
+
+func (r setupRecorder) RunAsRoot(command string) (string, error) {
+	*r.ran = append(*r.ran, "root: "+command)
+	return "", nil
+}
+
+func TestRunSetup(t *testing.T) {
+	q := Quest{
+		SetupCommands:     []string{"mkdir -p ~/camp", "chgrp goblins ~/camp"},
+		SetupCommandsRoot: []string{"groupadd -f goblins"},
+	}
+	var ran []string
+	results := q.RunSetup(setupRecorder{ran: &ran})
+
+	want := []string{"root: groupadd -f goblins", "player: mkdir -p ~/camp", "player: chgrp goblins ~/camp"}
+	if !slices.Equal(ran, want) {
+		t.Errorf("RunSetup ran %q, want %q", ran, want)
+	}
+
+	// A failed step is reported, not swallowed, and the rest still ran
+	failed := SetupFailures(results)
+	if len(results) != 3 || !results[0].Root || len(failed) != 1 || failed[0].Command != "chgrp goblins ~/camp" {
+		t.Errorf("Expected the chgrp step reported as failed, got %+v", results)
+	}
+	if !q.NeedsRoot() || (Quest{SetupCommands: q.SetupCommands}).NeedsRoot() {
+		t.Error("Expected only root setup to make a quest need root")
+	}
+}
+
+func TestResetPolicy(t *testing.T) {
+	cases := []struct {
+		quest            Quest
+		restarts, resets bool
+	}{
+		{Quest{}, false, false},
+		{Quest{ResetPolicy: ResetNone}, false, false},
+		{Quest{ResetPolicy: ResetHome}, false, true},
+		{Quest{ResetPolicy: ResetContainer}, true, false},
+		{Quest{RestartContainer: true}, true, false},
+	}
+	for _, tc := range cases {
+		if got := tc.quest.RestartsContainer(); got != tc.restarts {
+			t.Errorf("%q: RestartsContainer() = %v, want %v", tc.quest.ResetPolicy, got, tc.restarts)
+		}
+		if got := tc.quest.ResetsHome(); got != tc.resets {
+			t.Errorf("%q: ResetsHome() = %v, want %v", tc.quest.ResetPolicy, got, tc.resets)
+		}
+	}
+}
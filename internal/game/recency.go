@@ -0,0 +1,55 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultRecentSeconds is the max age a file_modified_recently check allows
+// when the quest gives none: long enough to run the command and have it checked
+const DefaultRecentSeconds = 60
+
+// recentSkewSeconds forgives clocks that disagree a little, e.g. a home bind
+// mounted from a VM whose clock runs slightly ahead of the container's
+const recentSkewSeconds = 5
+
+// recentMaxAge is wc's allowed age in seconds, from Expected or the default
+func recentMaxAge(wc WinCondition) (int, error) {
+	if strings.TrimSpace(wc.Expected) == "" {
+		return DefaultRecentSeconds, nil
+	}
+	seconds, err := strconv.Atoi(strings.TrimSpace(wc.Expected))
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("max age %q is not a positive number of seconds", wc.Expected)
+	}
+	return seconds, nil
+}
+
+// checkRecency passes when the file at wc.Target was modified in the last
+// max age seconds. Both times come from the container, in one exec, so the
+// host's clock never enters into it. A missing file is simply not passed.
+func checkRecency(wc WinCondition, run func(string) (string, error)) CheckTrace {
+	maxAge, err := recentMaxAge(wc)
+	if err != nil {
+		return CheckTrace{Expected: "a valid max age", Observed: err.Error()}
+	}
+	t := CheckTrace{Expected: fmt.Sprintf("modified within %ds", maxAge), Observed: observedMissing}
+
+	out, _ := run(fmt.Sprintf("stat -c %%Y %s 2>/dev/null && date +%%s", wc.Target))
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return t
+	}
+	modified, err1 := strconv.ParseInt(fields[0], 10, 64)
+	now, err2 := strconv.ParseInt(fields[1], 10, 64)
+	if err1 != nil || err2 != nil {
+		return t
+	}
+
+	age := now - modified
+	t.Observed = fmt.Sprintf("modified %ds ago", age)
+	// A timestamp slightly in the future is skew, not a file from tomorrow
+	t.Passed = age >= -recentSkewSeconds && age <= int64(maxAge)+recentSkewSeconds
+	return t
+}
@@ -0,0 +1,42 @@
+package game
+
+import "testing"
+
+func TestFileModifiedRecently(t *testing.T) {
+	probe := "stat -c %Y hut/bed.txt 2>/dev/null && date +%s"
+	cases := []struct {
+		name     string
+		expected string
+		out      string
+		want     bool
+	}{
+		{"just touched", "", "1000\n1010\n", true},
+		{"too old for the default", "", "1000\n1100\n", false},
+		{"within a custom max age", "300", "1000\n1100\n", true},
+		{"past a custom max age", "30", "1000\n1040\n", false},
+		{"slightly in the future", "", "1003\n1000\n", true},
+		{"far in the future", "", "2000\n1000\n", false},
+		{"missing", "", "", false},
+	}
+	for _, tc := range cases {
+		v := fakeValidator{outputs: map[string]string{probe: tc.out}}
+		wc := WinCondition{Type: FileModified, Target: "hut/bed.txt", Expected: tc.expected}
+		if got := CheckWinCondition(wc, v, "", ""); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+
+	// A max age that isn't a number never passes and is caught by validation
+	bad := WinCondition{Type: FileModified, Target: "hut/bed.txt", Expected: "soon"}
+	v := fakeValidator{outputs: map[string]string{probe: "1000\n1000\n"}}
+	if CheckWinCondition(bad, v, "", "") {
+		t.Error("Expected an invalid max age to fail")
+	}
+	if err := ValidateQuests([]Quest{{ID: 1, Title: "Touch", WinCondition: bad}}); err == nil {
+		t.Error("Expected an invalid max age to fail validation")
+	}
+	good := WinCondition{Type: FileModified, Target: "hut/bed.txt"}
+	if err := ValidateQuests([]Quest{{ID: 1, Title: "Touch", Checklist: []WinCondition{good}, WinCondition: good}}); err != nil {
+		t.Errorf("Expected file_modified_recently to be a known type, got %v", err)
+	}
+}
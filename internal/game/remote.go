@@ -0,0 +1,122 @@
+package game
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxQuestPackSize bounds a downloaded quest pack
+const maxQuestPackSize = 5 << 20
+
+// remoteClient fetches quest packs; replaced in tests
+var remoteClient = &http.Client{Timeout: 15 * time.Second}
+
+// IsRemoteQuestSource reports whether source is a URL rather than a file path
+func IsRemoteQuestSource(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+// LoadRemoteQuests downloads and validates a quest pack, caching it so later
+// launches work offline. If the download fails, the cached copy is used and
+// cached is true. Plain http is refused unless insecure is set.
+func LoadRemoteQuests(url string, insecure bool) (quests []Quest, cached bool, err error) {
+	if strings.HasPrefix(url, "http://") && !insecure {
+		return nil, false, fmt.Errorf("refusing to fetch quests over plain http (use https or --insecure)")
+	}
+
+	cachePath, cacheErr := questCachePath(url)
+
+	data, fetchErr := fetchQuestPack(url)
+	if fetchErr == nil {
+		quests, err = parseAndValidate(data)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid quest pack from %s: %w", url, err)
+		}
+		if cacheErr == nil {
+			_ = writeFileAtomic(cachePath, data)
+		}
+		return quests, false, nil
+	}
+
+	// Offline: fall back to the last good download
+	if cacheErr != nil {
+		return nil, false, fetchErr
+	}
+	data, err = os.ReadFile(cachePath)
+	if err != nil {
+		return nil, false, fetchErr
+	}
+	quests, err = parseAndValidate(data)
+	if err != nil {
+		return nil, false, fmt.Errorf("cached quest pack is invalid: %w", err)
+	}
+	return quests, true, nil
+}
+
+func fetchQuestPack(url string) ([]byte, error) {
+	resp, err := remoteClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch quests: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch quests: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxQuestPackSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch quests: %w", err)
+	}
+	if len(data) > maxQuestPackSize {
+		return nil, fmt.Errorf("quest pack is larger than %d MB", maxQuestPackSize>>20)
+	}
+	return data, nil
+}
+
+func parseAndValidate(data []byte) ([]Quest, error) {
+	quests, err := ParseQuests(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateQuests(quests); err != nil {
+		return nil, err
+	}
+	return quests, nil
+}
+
+// questCachePath is where the pack from url is cached, keyed by a hash of the URL
+func questCachePath(url string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(cacheDir, "goblin-terminal", "quest-packs", hex.EncodeToString(sum[:8])+".yaml"), nil
+}
+
+// writeFileAtomic writes via a temp file so a crash never leaves a half-written file
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,93 @@
+package game
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const packYAML = `
+- id: 1
+  title: "Hello"
+  objective: "Run pwd"
+  win_condition:
+    type: "user_output_matches"
+    expected_output: "/home/player"
+`
+
+func TestLoadRemoteQuests(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	online := true
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !online {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(packYAML))
+	}))
+	defer srv.Close()
+
+	old := remoteClient
+	remoteClient = srv.Client()
+	defer func() { remoteClient = old }()
+
+	quests, cached, err := LoadRemoteQuests(srv.URL+"/pack.yaml", false)
+	if err != nil || cached || len(quests) != 1 {
+		t.Fatalf("Expected a fresh download, got %d quests cached=%v err=%v", len(quests), cached, err)
+	}
+
+	// Offline launches fall back to the cache
+	online = false
+	quests, cached, err = LoadRemoteQuests(srv.URL+"/pack.yaml", false)
+	if err != nil || !cached || len(quests) != 1 {
+		t.Errorf("Expected the cached pack, got %d quests cached=%v err=%v", len(quests), cached, err)
+	}
+
+	// Nothing cached for another URL
+	if _, _, err := LoadRemoteQuests(srv.URL+"/other.yaml", false); err == nil {
+		t.Error("Expected an error with no download and no cache")
+	}
+}
+
+func TestLoadRemoteQuestsRefusesHTTP(t *testing.T) {
+	if _, _, err := LoadRemoteQuests("http://example.com/pack.yaml", false); err == nil {
+		t.Error("Expected plain http to be refused without --insecure")
+	}
+}
+
+func TestValidateQuests(t *testing.T) {
+	ok := Quest{ID: 1, Title: "A", WinCondition: WinCondition{Type: FileExists}}
+	cases := map[string][]Quest{
+		"empty":        nil,
+		"no id":        {{Title: "A", WinCondition: ok.WinCondition}},
+		"duplicate id": {ok, ok},
+		"no title":     {{ID: 1, WinCondition: ok.WinCondition}},
+		"unknown type": {{ID: 1, Title: "A", WinCondition: WinCondition{Type: "telepathy"}}},
+		"bad policy":   {{ID: 1, Title: "A", WinCondition: ok.WinCondition, ResetPolicy: "everything"}},
+		"unknown host": {{ID: 1, Title: "A", WinCondition: ok.WinCondition, Host: "web"}},
+	}
+	for name, quests := range cases {
+		if err := ValidateQuests(quests); err == nil {
+			t.Errorf("%s: expected a validation error", name)
+		}
+	}
+	onGateway := ok
+	onGateway.Host = GatewayHost
+	if err := ValidateQuests([]Quest{ok}); err != nil {
+		t.Errorf("Expected a valid pack, got %v", err)
+	}
+	if err := ValidateQuests([]Quest{onGateway}); err != nil {
+		t.Errorf("Expected a quest on the gateway to be valid, got %v", err)
+	}
+}
+
+func TestBundledQuestsValidate(t *testing.T) {
+	quests, err := LoadQuests("../../quests/quests.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateQuests(quests); err != nil {
+		t.Errorf("Bundled quests fail validation: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package game
+
+import "fmt"
+
+// knownWinConditions are the win condition types the game can evaluate
+var knownWinConditions = map[WinConditionType]bool{
+	DirExists: true, FileExists: true, DirNotExists: true, FileNotExists: true,
+	FileContains: true, FileEquals: true, FileModified: true,
+	CommandOut: true, UserOutputMatch: true, UserOutputContains: true,
+	CurrentDirMatch: true, UserExists: true, GroupExists: true, UserInGroup: true,
+	ScriptRuns: true, Custom: true,
+}
+
+// ValidateQuests checks a quest pack for problems that would break play:
+// missing or duplicate IDs, missing titles, unknown win condition types or
+// reset policies and scenario containers without a name or IP
+func ValidateQuests(quests []Quest) error {
+	if len(quests) == 0 {
+		return fmt.Errorf("quest pack contains no quests")
+	}
+
+	seen := make(map[int]bool)
+	for i, q := range quests {
+		if q.ID <= 0 {
+			return fmt.Errorf("quest #%d has no id", i+1)
+		}
+		if seen[q.ID] {
+			return fmt.Errorf("duplicate quest id %d", q.ID)
+		}
+		seen[q.ID] = true
+		if q.Title == "" {
+			return fmt.Errorf("quest %d has no title", q.ID)
+		}
+		if !knownWinConditions[q.WinCondition.Type] {
+			return fmt.Errorf("quest %d has unknown win condition type %q", q.ID, q.WinCondition.Type)
+		}
+		if q.NoPingWinCondition != nil && !knownWinConditions[q.NoPingWinCondition.Type] {
+			return fmt.Errorf("quest %d has unknown no-ping win condition type %q", q.ID, q.NoPingWinCondition.Type)
+		}
+		for _, wc := range q.Checklist {
+			if !knownWinConditions[wc.Type] {
+				return fmt.Errorf("quest %d has unknown checklist condition type %q", q.ID, wc.Type)
+			}
+		}
+		for _, b := range q.OptionalObjectives {
+			if !knownWinConditions[b.Type] {
+				return fmt.Errorf("quest %d has unknown optional objective type %q", q.ID, b.Type)
+			}
+		}
+		for _, wc := range q.allConditions() {
+			if wc.Type != FileModified {
+				continue
+			}
+			if _, err := recentMaxAge(wc); err != nil {
+				return fmt.Errorf("quest %d: %s: %v", q.ID, wc.Type, err)
+			}
+		}
+		switch q.ResetPolicy {
+		case "", ResetNone, ResetHome, ResetContainer:
+		default:
+			return fmt.Errorf("quest %d has unknown reset policy %q (expected %s, %s or %s)", q.ID, q.ResetPolicy, ResetNone, ResetHome, ResetContainer)
+		}
+		for _, host := range q.Containers {
+			if host.Name == "" || host.IP == "" {
+				return fmt.Errorf("quest %d has a container without a name or ip", q.ID)
+			}
+		}
+		if q.Host != "" && !q.hasHost(q.Host) {
+			return fmt.Errorf("quest %d runs commands on unknown host %q", q.ID, q.Host)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package game
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// SecretPlaceholder is replaced with a fresh random token each time a quest
+// starts, so CTF-style quests can hide a flag that can't be memorized
+const SecretPlaceholder = "{{secret}}"
+
+// NewSecret returns a random token for one quest run
+func NewSecret() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand doesn't fail on supported platforms; a fixed token still plays
+		return "GOBLIN-000000000000"
+	}
+	return "GOBLIN-" + strings.ToUpper(hex.EncodeToString(b))
+}
+
+// WithSecret returns a copy of the quest with SecretPlaceholder replaced by
+// secret in its setup commands, solution and win conditions
+func (q Quest) WithSecret(secret string) Quest {
+	r := strings.NewReplacer(SecretPlaceholder, secret)
+	replaceAll := func(list []string) []string {
+		if list == nil {
+			return nil
+		}
+		out := make([]string, len(list))
+		for i, s := range list {
+			out[i] = r.Replace(s)
+		}
+		return out
+	}
+	replaceWC := func(wc WinCondition) WinCondition {
+		wc.Target = r.Replace(wc.Target)
+		wc.Content = r.Replace(wc.Content)
+		wc.Command = r.Replace(wc.Command)
+		wc.Expected = r.Replace(wc.Expected)
+		return wc
+	}
+
+	q.SetupCommands = replaceAll(q.SetupCommands)
+	q.SetupCommandsRoot = replaceAll(q.SetupCommandsRoot)
+	q.Solution = replaceAll(q.Solution)
+	q.WinCondition = replaceWC(q.WinCondition)
+	if q.NoPingWinCondition != nil {
+		wc := replaceWC(*q.NoPingWinCondition)
+		q.NoPingWinCondition = &wc
+	}
+	return q
+}
@@ -0,0 +1,31 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithSecret(t *testing.T) {
+	q := Quest{
+		ID:            40,
+		SetupCommands: []string{"echo '{{secret}}' > .vault/flag.txt"},
+		Solution:      []string{"cat .vault/flag.txt"},
+		WinCondition:  WinCondition{Type: UserOutputMatch, Expected: "{{secret}}"},
+	}
+
+	got := q.WithSecret("GOBLIN-ABC")
+	if got.SetupCommands[0] != "echo 'GOBLIN-ABC' > .vault/flag.txt" {
+		t.Errorf("Expected setup to embed the secret, got %q", got.SetupCommands[0])
+	}
+	if got.WinCondition.Expected != "GOBLIN-ABC" {
+		t.Errorf("Expected win condition to compare against the secret, got %q", got.WinCondition.Expected)
+	}
+	if q.SetupCommands[0] != "echo '{{secret}}' > .vault/flag.txt" {
+		t.Error("Expected the original quest to be left untouched")
+	}
+
+	a, b := NewSecret(), NewSecret()
+	if a == b || !strings.HasPrefix(a, "GOBLIN-") {
+		t.Errorf("Expected distinct random tokens, got %q and %q", a, b)
+	}
+}
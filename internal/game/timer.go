@@ -0,0 +1,44 @@
+package game
+
+import (
+	"fmt"
+	"time"
+)
+
+// FormatDuration renders a duration as MM:SS, or H:MM:SS once it passes an hour
+func FormatDuration(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	total := int(d / time.Second)
+	hours := total / 3600
+	minutes := (total % 3600) / 60
+	seconds := total % 60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%02d:%02d", minutes, seconds)
+}
+
+// RecordQuestTime stores d as the best time for questID if it beats the previous best.
+// Returns true when a new best was set.
+func (s *GameState) RecordQuestTime(questID int, d time.Duration) bool {
+	if s.BestQuestTimes == nil {
+		s.BestQuestTimes = make(map[int]time.Duration)
+	}
+	if best, ok := s.BestQuestTimes[questID]; ok && best <= d {
+		return false
+	}
+	s.BestQuestTimes[questID] = d
+	return true
+}
+
+// RecordTotalTime stores d as the best full-run time if it beats the previous best.
+// Returns true when a new best was set.
+func (s *GameState) RecordTotalTime(d time.Duration) bool {
+	if s.BestTotalTime != 0 && s.BestTotalTime <= d {
+		return false
+	}
+	s.BestTotalTime = d
+	return true
+}
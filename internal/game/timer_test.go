@@ -0,0 +1,50 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDuration(t *testing.T) {
+	cases := map[time.Duration]string{
+		0:                                     "00:00",
+		-5 * time.Second:                      "00:00",
+		59*time.Second + 900*time.Millisecond: "00:59",
+		61 * time.Second:                      "01:01",
+		time.Hour + 2*time.Minute + 3*time.Second: "1:02:03",
+	}
+
+	for in, want := range cases {
+		if got := FormatDuration(in); got != want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRecordQuestTime(t *testing.T) {
+	var state GameState
+
+	if !state.RecordQuestTime(1, 30*time.Second) {
+		t.Error("Expected first time to be a new best")
+	}
+	if state.RecordQuestTime(1, 45*time.Second) {
+		t.Error("Expected slower time not to be a new best")
+	}
+	if !state.RecordQuestTime(1, 20*time.Second) {
+		t.Error("Expected faster time to be a new best")
+	}
+	if state.BestQuestTimes[1] != 20*time.Second {
+		t.Errorf("Expected best time 20s, got %v", state.BestQuestTimes[1])
+	}
+}
+
+func TestRecordTotalTime(t *testing.T) {
+	var state GameState
+
+	if !state.RecordTotalTime(10 * time.Minute) {
+		t.Error("Expected first run to be a new best")
+	}
+	if state.RecordTotalTime(11 * time.Minute) {
+		t.Error("Expected slower run not to be a new best")
+	}
+}
@@ -0,0 +1,144 @@
+package game
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ToolUse is a program the container must have, and the quests whose win
+// conditions run it
+type ToolUse struct {
+	Name   string
+	Quests []int
+}
+
+// typeTools are the programs each condition type runs by itself. Existence
+// checks only need the shell's own test, and the user_output types never
+// touch the container.
+var typeTools = map[WinConditionType][]string{
+	FileContains: {"grep"},
+	FileEquals:   {"cat"},
+	FileModified: {"stat", "date"},
+	UserExists:   {"id"},
+	GroupExists:  {"getent"},
+	UserInGroup:  {"id"},
+}
+
+// shellBuiltins need no binary, and nothing after them is a command
+var shellBuiltins = map[string]bool{
+	"test": true, "[": true, "[[": true, "echo": true, "printf": true, "cd": true, "true": true,
+	"false": true, "fi": true, "done": true, "esac": true, "for": true, "case": true, "read": true,
+	"exit": true, "export": true, "local": true, "set": true, "eval": true, "command": true,
+	"type": true, ":": true, "}": true,
+}
+
+// shellKeywords come before a command, which is looked at next
+var shellKeywords = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "while": true, "until": true,
+	"do": true, "!": true, "{": true, "time": true,
+}
+
+// wrapperTools run the command that follows them, after their own options
+// and, for timeout, a duration
+var wrapperTools = map[string]bool{"sudo": true, "timeout": true, "env": true, "nice": true, "nohup": true}
+
+// wrapperValueOptions are the wrappers' options that take a value, like sudo -u glitch
+var wrapperValueOptions = map[string]bool{"-u": true, "-g": true, "-n": true, "-s": true, "-k": true}
+
+var (
+	// quotedText is dropped before splitting so quoted arguments (ssh's remote
+	// command, grep patterns) aren't mistaken for commands
+	quotedText = regexp.MustCompile(`'[^']*'|"[^"]*"`)
+	// commandSeparator splits a command line into simple commands
+	commandSeparator = regexp.MustCompile(`\|\||&&|[|;&()]|\$\(|` + "`")
+)
+
+// RequiredTools lists every program the quests run in the container, from
+// their win conditions and setup commands, sorted by name. Commands are read
+// for the programs they start; anything given by path (a quest's own script)
+// is the quest's to provide.
+func RequiredTools(quests []Quest) []ToolUse {
+	users := make(map[string][]int)
+	for _, q := range quests {
+		for _, tool := range questTools(q) {
+			if !slices.Contains(users[tool], q.ID) {
+				users[tool] = append(users[tool], q.ID)
+			}
+		}
+	}
+
+	tools := make([]ToolUse, 0, len(users))
+	for name, ids := range users {
+		tools = append(tools, ToolUse{Name: name, Quests: ids})
+	}
+	slices.SortFunc(tools, func(a, b ToolUse) int { return strings.Compare(a.Name, b.Name) })
+	return tools
+}
+
+// RequiredBinaries is the names from RequiredTools: every program the quests
+// need in the image, sorted
+func RequiredBinaries(quests []Quest) []string {
+	var names []string
+	for _, tool := range RequiredTools(quests) {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+// questTools lists the programs q's setup and win conditions run
+func questTools(q Quest) []string {
+	var tools []string
+	for _, command := range slices.Concat(q.SetupCommandsRoot, q.SetupCommands) {
+		tools = append(tools, commandTools(command)...)
+	}
+	for _, wc := range q.allConditions() {
+		tools = append(tools, conditionTools(wc)...)
+	}
+	return tools
+}
+
+// conditionTools lists the programs checking wc runs
+func conditionTools(wc WinCondition) []string {
+	tools := slices.Clone(typeTools[wc.Type])
+	if wc.Type == CommandOut || wc.Type == Custom {
+		tools = append(tools, commandTools(wc.Command)...)
+	}
+	return tools
+}
+
+// commandTools finds the program each simple command in a shell command
+// line starts, looking through wrappers like sudo and timeout
+func commandTools(command string) []string {
+	var tools []string
+	for _, part := range commandSeparator.Split(quotedText.ReplaceAllString(command, "''"), -1) {
+		wrapped, skipValue := false, false
+		for _, word := range strings.Fields(part) {
+			if skipValue {
+				skipValue = false
+				continue
+			}
+			if strings.HasPrefix(word, "-") || strings.Contains(word, "=") || isNumber(word) {
+				// A wrapper's options, or a variable assignment
+				skipValue = wrapped && wrapperValueOptions[word]
+				continue
+			}
+			if shellKeywords[word] {
+				continue
+			}
+			if shellBuiltins[word] || strings.ContainsAny(word, "/'<>$") {
+				break
+			}
+			tools = append(tools, word)
+			if wrapped = wrapperTools[word]; !wrapped {
+				break
+			}
+		}
+	}
+	return tools
+}
+
+// isNumber reports whether word is a plain duration or count, like timeout's "3"
+func isNumber(word string) bool {
+	return strings.Trim(word, "0123456789.smhd") == "" && word[0] >= '0' && word[0] <= '9'
+}
@@ -0,0 +1,52 @@
+package game
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCommandTools(t *testing.T) {
+	cases := map[string][]string{
+		"stat -c %U /home/player/.safe_house":                        {"stat"},
+		"pgrep scanner_daemon || echo killed":                        {"pgrep"},
+		"sudo chage -l glitch | grep -q 'password must be changed'":  {"sudo", "chage", "grep"},
+		"timeout 3 bash -c 'echo > /dev/tcp/gateway/22' && echo yes": {"timeout", "bash"},
+		"ssh -i id_rsa player@gateway 'test -f glitch.tar.gz && ls'": {"ssh"},
+		"if ! crontab -l | grep -q date; then exit 1; fi":            {"crontab", "grep"},
+		"sudo -u glitch cat /etc/motd":                               {"sudo", "cat"},
+		"LANG=C sort notes.txt | uniq -c":                            {"sort", "uniq"},
+		"./check.sh && test -d hut":                                  nil,
+		"n=$(wc -l < log.txt); [ \"$n\" -gt 3 ] && echo yes":         {"wc"},
+	}
+	for command, want := range cases {
+		if got := commandTools(command); !slices.Equal(got, want) {
+			t.Errorf("commandTools(%q) = %q, want %q", command, got, want)
+		}
+	}
+}
+
+func TestRequiredTools(t *testing.T) {
+	quests := []Quest{
+		{ID: 1, WinCondition: WinCondition{Type: DirExists, Target: "hut"}},
+		{ID: 2, WinCondition: WinCondition{Type: FileContains, Target: "a", Content: "b"},
+			Checklist: []WinCondition{{Type: CommandOut, Command: "stat -c %a a"}}},
+		{ID: 3, WinCondition: WinCondition{Type: FileModified, Target: "a"}},
+		{ID: 4, WinCondition: WinCondition{Type: Custom, Command: "grep -q x a"}},
+		{ID: 5, SetupCommandsRoot: []string{"useradd -m glitch"}, SetupCommands: []string{"mkdir -p camp", "./seed.sh"},
+			WinCondition: WinCondition{Type: UserOutputContains, Expected: "x"}},
+	}
+	var got []string
+	for _, tool := range RequiredTools(quests) {
+		got = append(got, tool.Name)
+		if tool.Name == "grep" && !slices.Equal(tool.Quests, []int{2, 4}) {
+			t.Errorf("Expected grep needed by quests 2 and 4, got %v", tool.Quests)
+		}
+	}
+	want := []string{"date", "grep", "mkdir", "stat", "useradd"}
+	if !slices.Equal(got, want) {
+		t.Errorf("RequiredTools() = %q, want %q", got, want)
+	}
+	if bins := RequiredBinaries(quests); !slices.Equal(bins, want) {
+		t.Errorf("RequiredBinaries() = %q, want %q", bins, want)
+	}
+}
@@ -0,0 +1,51 @@
+package game
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// transcriptName allows plain file names only, so a transcript can't escape its directory
+var transcriptName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// TranscriptDir is where save-transcript and --transcript write their files
+func TranscriptDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "goblin-terminal", "transcripts"), nil
+}
+
+// SaveTranscript writes the session output as plain text to name in
+// TranscriptDir, adding a .txt extension if there is none, and returns the path
+func SaveTranscript(name string, lines []string) (string, error) {
+	if !transcriptName.MatchString(name) {
+		return "", fmt.Errorf("invalid transcript name %q: use letters, digits, '.', '-' and '_'", name)
+	}
+	if filepath.Ext(name) == "" {
+		name += ".txt"
+	}
+
+	dir, err := TranscriptDir()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(ansi.Strip(line))
+		b.WriteByte('\n')
+	}
+
+	path := filepath.Join(dir, name)
+	if err := writeFileAtomic(path, []byte(b.String())); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,44 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveTranscript(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	// Colors, window titles and hyperlinks all stay out of the file
+	path, err := SaveTranscript("notes", []string{"\x1b[1;38;2;0;255;0m$ ls\x1b[0m", "\x1b]0;Goblin Terminal\x07hut", "\x1b]8;;https://x\x1b\\link\x1b[0m"})
+	if err != nil {
+		t.Fatalf("SaveTranscript failed: %v", err)
+	}
+	if filepath.Base(path) != "notes.txt" {
+		t.Errorf("Expected .txt to be added, got %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "$ ls\nhut\nlink\n" {
+		t.Errorf("Unexpected transcript contents: %q", data)
+	}
+
+	// Overwriting keeps a single, complete file
+	if _, err := SaveTranscript("notes.txt", []string{"again"}); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected no temp files left behind, got %d entries", len(entries))
+	}
+
+	for _, bad := range []string{"", "../escape", "a/b", ".hidden", ".."} {
+		if _, err := SaveTranscript(bad, nil); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
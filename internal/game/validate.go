@@ -0,0 +1,274 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Validator is the container access needed to evaluate win conditions
+type Validator interface {
+	ExecuteValidation(command string) (string, error)
+	ExecuteValidationStrict(command string) (string, error)
+	RunAsRoot(command string) (string, error)
+	UserExists(name string) bool
+	GroupExists(name string) bool
+	UserInGroup(user, group string) (bool, error)
+	// HomeDir is the player's home, which relative targets are resolved from
+	HomeDir() string
+}
+
+// Reasons EvaluateWinCondition gives for a failed check, so hints can be targeted
+const (
+	ReasonScriptMissing       = "script_missing"
+	ReasonScriptNotExecutable = "script_not_executable"
+	ReasonScriptWrongOutput   = "script_wrong_output"
+)
+
+// CheckWinCondition evaluates wc against the container. lastOutput is the output
+// of the player's most recent successful command and currentDir their working directory.
+func CheckWinCondition(wc WinCondition, v Validator, lastOutput, currentDir string) bool {
+	passed, _ := EvaluateWinCondition(wc, v, lastOutput, currentDir)
+	return passed
+}
+
+// EvaluateWinCondition is CheckWinCondition plus, for conditions that can tell,
+// one of the Reason constants explaining why the check did not pass
+func EvaluateWinCondition(wc WinCondition, v Validator, lastOutput, currentDir string) (bool, string) {
+	t := TraceWinCondition(wc, v, lastOutput, currentDir)
+	return t.Passed, t.Reason
+}
+
+// QuestCheck is the result of checking each of a quest's conditions
+type QuestCheck struct {
+	Conditions []WinCondition
+	Traces     []CheckTrace // One per condition, in the same order
+}
+
+// CheckQuest evaluates every condition, even after one fails, so the player
+// can see how far along a checklist they are
+func CheckQuest(conditions []WinCondition, v Validator, lastOutput, currentDir string) QuestCheck {
+	c := QuestCheck{Conditions: conditions}
+	for _, wc := range conditions {
+		c.Traces = append(c.Traces, TraceWinCondition(wc, v, lastOutput, currentDir))
+	}
+	return c
+}
+
+// Passed reports whether every condition passed
+func (c QuestCheck) Passed() bool {
+	return len(c.Traces) > 0 && c.Done() == len(c.Traces)
+}
+
+// Done is how many conditions passed
+func (c QuestCheck) Done() int {
+	done := 0
+	for _, t := range c.Traces {
+		if t.Passed {
+			done++
+		}
+	}
+	return done
+}
+
+// Total is how many conditions were checked
+func (c QuestCheck) Total() int {
+	return len(c.Traces)
+}
+
+// Percent is the share of conditions that passed, 0 to 100
+func (c QuestCheck) Percent() int {
+	if len(c.Traces) == 0 {
+		return 0
+	}
+	return c.Done() * 100 / len(c.Traces)
+}
+
+// Focus is the index of the condition worth reporting on: the first that
+// failed, or the last one when they all passed
+func (c QuestCheck) Focus() int {
+	for i, t := range c.Traces {
+		if !t.Passed {
+			return i
+		}
+	}
+	return len(c.Traces) - 1
+}
+
+// CheckTrace records what a win condition evaluation wanted and what it
+// actually saw, so quest authors can tell why a check passed or failed
+type CheckTrace struct {
+	Passed   bool
+	Reason   string // One of the Reason constants, when the condition can tell
+	Expected string
+	Observed string
+}
+
+// Observed values for checks that only learn whether something is there
+const (
+	observedPresent = "present"
+	observedMissing = "missing"
+)
+
+// presence turns a found flag into the observed value for existence checks
+func presence(found bool) string {
+	if found {
+		return observedPresent
+	}
+	return observedMissing
+}
+
+// TraceWinCondition evaluates wc like EvaluateWinCondition and keeps the
+// expected and observed values for every condition type
+func TraceWinCondition(wc WinCondition, v Validator, lastOutput, currentDir string) CheckTrace {
+	var t CheckTrace
+
+	// Root-only checks (e.g. /etc/shadow) go through RunAsRoot instead
+	run := v.ExecuteValidation
+	if wc.RootCheck {
+		run = v.RunAsRoot
+	}
+
+	switch wc.Type {
+	case CommandOut:
+		// "CommandOut" runs a command to validate game state.
+		// e.g. "stat -c %a hut" should return "700"
+		// We MUST use the validation exec so it runs in a predictable context (the player's home)
+		// independently of where the user has cd'd to.
+		out, _ := run(wc.Command)
+		t.Expected, t.Observed = wc.Expected, strings.TrimSpace(out)
+		t.Passed = t.Observed == wc.Expected
+	case DirExists:
+		// check if dir exists using test -d, from ROOT context
+		cmd := fmt.Sprintf("test -d %s && echo yes", wc.Target)
+		out, _ := run(cmd)
+		t.Passed = strings.TrimSpace(out) == "yes"
+		t.Expected, t.Observed = observedPresent, presence(t.Passed)
+	case FileExists:
+		cmd := fmt.Sprintf("test -f %s && echo yes", wc.Target)
+		out, _ := run(cmd)
+		t.Passed = strings.TrimSpace(out) == "yes"
+		t.Expected, t.Observed = observedPresent, presence(t.Passed)
+	case DirNotExists:
+		// Passes once the directory is gone; a file of the same name doesn't count
+		out, _ := run(fmt.Sprintf("test ! -d %s && echo yes", wc.Target))
+		t.Passed = strings.TrimSpace(out) == "yes"
+		t.Expected, t.Observed = observedMissing, presence(!t.Passed)
+	case FileNotExists:
+		// Nothing at all may be left at the path, so moving a file away passes
+		// but replacing it with a directory doesn't
+		out, _ := run(fmt.Sprintf("test ! -e %s && echo yes", wc.Target))
+		t.Passed = strings.TrimSpace(out) == "yes"
+		t.Expected, t.Observed = observedMissing, presence(!t.Passed)
+	case FileContains:
+		// check if file content contains string
+		// We use grep in the container to check
+		// safe because it's a validation command running in a controlled container
+		// Escape single quotes for safety if needed, though basic check here:
+		cmd := fmt.Sprintf("grep -q \"%s\" %s && echo yes", wc.Content, wc.Target)
+		out, _ := run(cmd)
+		t.Passed = strings.TrimSpace(out) == "yes"
+		t.Expected, t.Observed = wc.Content, presence(t.Passed)
+	case FileEquals:
+		// Compare the whole file body, not just a substring
+		t.Expected, t.Observed = wc.Content, observedMissing
+		exists, _ := run(fmt.Sprintf("test -f %s && echo yes", wc.Target))
+		if strings.TrimSpace(exists) == "yes" {
+			out, _ := run(fmt.Sprintf("cat %s", wc.Target))
+			t.Observed = out
+			t.Passed = ContentEquals(out, wc.Content, wc.StrictNewlines)
+		}
+	case FileModified:
+		// Expected optionally holds the max age in seconds
+		t = checkRecency(wc, run)
+	case UserExists:
+		// Target holds the username
+		t.Passed = v.UserExists(wc.Target)
+		t.Expected, t.Observed = observedPresent, presence(t.Passed)
+	case GroupExists:
+		// Target holds the group name
+		t.Passed = v.GroupExists(wc.Target)
+		t.Expected, t.Observed = observedPresent, presence(t.Passed)
+	case UserInGroup:
+		// Target holds the username, Content the group.
+		// A missing user is simply not passed yet.
+		inGroup, err := v.UserInGroup(wc.Target, wc.Content)
+		t.Passed = err == nil && inGroup
+		t.Expected, t.Observed = "member of "+wc.Content, "not a member"
+		if err != nil {
+			t.Observed = err.Error()
+		} else if inGroup {
+			t.Observed = t.Expected
+		}
+	case Custom:
+		// The author's command decides: exit 0 passes
+		strict := v.ExecuteValidationStrict
+		if wc.RootCheck {
+			strict = v.RunAsRoot
+		}
+		_, err := strict(wc.Command)
+		t.Passed = err == nil
+		t.Expected, t.Observed = "exit 0", "exit 0"
+		if err != nil {
+			t.Observed = err.Error()
+		}
+	case ScriptRuns:
+		// Running the script directly also checks the execute bit and shebang
+		t = checkScript(wc, run)
+	case UserOutputMatch:
+		// Check if the *last* command output by the user matches the expectation
+		// This is useful for "cat file" or "grep" where we want to see if they saw the right thing
+		t.Expected, t.Observed = strings.TrimSpace(wc.Expected), strings.TrimSpace(lastOutput)
+		t.Passed = t.Observed == t.Expected
+	case UserOutputContains:
+		// Check if the *last* command output contains the expected string
+		t.Expected, t.Observed = wc.Expected, lastOutput
+		t.Passed = strings.Contains(lastOutput, wc.Expected)
+	case CurrentDirMatch:
+		// Check if the current directory matches the target
+		// The manager tracks CurrentDir
+		// We need to handle relative vs absolute paths potentially?
+		// For simplicity early game, target likely "hut" which implies "<home>/hut"
+		// But let's support both explicit absolute or relative to home.
+
+		targetDir := wc.Target
+		// Normalize target
+		if !strings.HasPrefix(targetDir, "/") {
+			targetDir = strings.TrimSuffix(v.HomeDir(), "/") + "/" + targetDir
+		}
+		targetDir = strings.TrimSuffix(targetDir, "/")
+
+		currentDir = strings.TrimSuffix(currentDir, "/")
+
+		t.Expected, t.Observed = targetDir, currentDir
+		t.Passed = currentDir == targetDir
+	}
+
+	return t
+}
+
+// checkScript runs the script at wc.Target and compares its stdout to wc.Expected
+func checkScript(wc WinCondition, run func(string) (string, error)) CheckTrace {
+	t := CheckTrace{Expected: strings.TrimSpace(wc.Expected)}
+	if out, _ := run(fmt.Sprintf("test -f %s && echo yes", wc.Target)); strings.TrimSpace(out) != "yes" {
+		t.Reason, t.Observed = ReasonScriptMissing, observedMissing
+		return t
+	}
+	if out, _ := run(fmt.Sprintf("test -x %s && echo yes", wc.Target)); strings.TrimSpace(out) != "yes" {
+		t.Reason, t.Observed = ReasonScriptNotExecutable, "not executable"
+		return t
+	}
+
+	// A bare name would be looked up in PATH instead of the home directory
+	script := wc.Target
+	if !strings.Contains(script, "/") {
+		script = "./" + script
+	}
+	out, _ := run(script)
+	t.Observed = strings.TrimSpace(out)
+	if t.Observed != t.Expected {
+		t.Reason = ReasonScriptWrongOutput
+		return t
+	}
+	t.Passed = true
+	return t
+}
@@ -0,0 +1,262 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeValidator answers validation commands from a canned table
+type fakeValidator struct {
+	outputs map[string]string
+	root    map[string]string   // commands that only answer when run as root
+	passing map[string]bool     // commands that exit 0 under the strict exec
+	users   map[string][]string // user -> groups
+	groups  map[string]bool
+	home    string // Player's home; empty means /home/player
+}
+
+func (f fakeValidator) ExecuteValidation(command string) (string, error) {
+	return f.outputs[command], nil
+}
+
+func (f fakeValidator) ExecuteValidationStrict(command string) (string, error) {
+	if !f.passing[command] {
+		return "", errors.New("exit status 1")
+	}
+	return f.outputs[command], nil
+}
+
+func (f fakeValidator) RunAsRoot(command string) (string, error) {
+	if out, ok := f.root[command]; ok {
+		return out, nil
+	}
+	return f.outputs[command], nil
+}
+
+func (f fakeValidator) UserExists(name string) bool {
+	_, ok := f.users[name]
+	return ok
+}
+
+func (f fakeValidator) GroupExists(name string) bool {
+	return f.groups[name]
+}
+
+func (f fakeValidator) UserInGroup(user, group string) (bool, error) {
+	groups, ok := f.users[user]
+	if !ok {
+		return false, errNoUser
+	}
+	for _, g := range groups {
+		if g == group {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f fakeValidator) HomeDir() string {
+	if f.home == "" {
+		return "/home/player"
+	}
+	return f.home
+}
+
+var errNoUser = errors.New("no such user")
+
+func TestCheckWinCondition(t *testing.T) {
+	v := fakeValidator{
+		outputs: map[string]string{
+			"test -d /tmp/safe_house && echo yes": "yes\n",
+			"stat -c %a hut":                      "700\n",
+		},
+		root: map[string]string{
+			"grep -q \"glitch\" /etc/shadow && echo yes": "yes\n",
+			"stat -c %U /root/vault":                     "root\n",
+		},
+		users:  map[string][]string{"glitch": {"glitch", "sudo"}, "player": {"player"}},
+		groups: map[string]bool{"sudo": true},
+	}
+
+	cases := []struct {
+		name       string
+		wc         WinCondition
+		lastOutput string
+		currentDir string
+		want       bool
+	}{
+		{"dir exists", WinCondition{Type: DirExists, Target: "/tmp/safe_house"}, "", "", true},
+		{"dir missing", WinCondition{Type: DirExists, Target: "/tmp/other"}, "", "", false},
+		{"command output", WinCondition{Type: CommandOut, Command: "stat -c %a hut", Expected: "700"}, "", "", true},
+		{"user output match", WinCondition{Type: UserOutputMatch, Expected: "/home/player"}, "/home/player\n", "", true},
+		{"user output contains", WinCondition{Type: UserOutputContains, Expected: "artifact"}, "a glitch_artifact.dat", "", true},
+		{"cwd absolute", WinCondition{Type: CurrentDirMatch, Target: "/tmp"}, "", "/tmp", true},
+		{"cwd relative to home", WinCondition{Type: CurrentDirMatch, Target: "hut"}, "", "/home/player/hut/", true},
+		{"user exists", WinCondition{Type: UserExists, Target: "glitch"}, "", "", true},
+		{"user missing", WinCondition{Type: UserExists, Target: "ghost"}, "", "", false},
+		{"group exists", WinCondition{Type: GroupExists, Target: "sudo"}, "", "", true},
+		{"user in group", WinCondition{Type: UserInGroup, Target: "glitch", Content: "sudo"}, "", "", true},
+		{"user not in group", WinCondition{Type: UserInGroup, Target: "player", Content: "sudo"}, "", "", false},
+		{"root file contains", WinCondition{Type: FileContains, Target: "/etc/shadow", Content: "glitch", RootCheck: true}, "", "", true},
+		{"root file as player", WinCondition{Type: FileContains, Target: "/etc/shadow", Content: "glitch"}, "", "", false},
+		{"root command output", WinCondition{Type: CommandOut, Command: "stat -c %U /root/vault", Expected: "root", RootCheck: true}, "", "", true},
+		{"in group but no user", WinCondition{Type: UserInGroup, Target: "ghost", Content: "sudo"}, "", "", false},
+	}
+
+	for _, tc := range cases {
+		if got := CheckWinCondition(tc.wc, v, tc.lastOutput, tc.currentDir); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+
+	// An image with another home resolves relative directories from there
+	v.home = "/home/grub"
+	cwd := WinCondition{Type: CurrentDirMatch, Target: "hut"}
+	if !CheckWinCondition(cwd, v, "", "/home/grub/hut") || CheckWinCondition(cwd, v, "", "/home/player/hut") {
+		t.Error("Expected a relative directory to resolve from the detected home")
+	}
+}
+
+func TestEvaluateScriptRuns(t *testing.T) {
+	v := fakeValidator{outputs: map[string]string{
+		"test -f hello.sh && echo yes":  "yes\n",
+		"test -x hello.sh && echo yes":  "yes\n",
+		"./hello.sh":                    "Hello, Goblin\n",
+		"test -f noexec.sh && echo yes": "yes\n",
+	}}
+
+	cases := []struct {
+		name   string
+		wc     WinCondition
+		passed bool
+		reason string
+	}{
+		{"runs", WinCondition{Type: ScriptRuns, Target: "hello.sh", Expected: "Hello, Goblin"}, true, ""},
+		{"wrong output", WinCondition{Type: ScriptRuns, Target: "hello.sh", Expected: "Bye"}, false, ReasonScriptWrongOutput},
+		{"not executable", WinCondition{Type: ScriptRuns, Target: "noexec.sh", Expected: "Hi"}, false, ReasonScriptNotExecutable},
+		{"missing", WinCondition{Type: ScriptRuns, Target: "none.sh", Expected: "Hi"}, false, ReasonScriptMissing},
+	}
+	for _, tc := range cases {
+		passed, reason := EvaluateWinCondition(tc.wc, v, "", "")
+		if passed != tc.passed || reason != tc.reason {
+			t.Errorf("%s: expected (%v, %q), got (%v, %q)", tc.name, tc.passed, tc.reason, passed, reason)
+		}
+	}
+}
+
+func TestCustomCheck(t *testing.T) {
+	data := []byte(`
+- id: 99
+  title: "Custom"
+  objective: "Make the service answer"
+  win_condition:
+    type: "custom_check"
+    command: "curl -sf http://localhost:8080/health"
+`)
+	quests, err := ParseQuests(data)
+	if err != nil {
+		t.Fatalf("Failed to parse custom quest: %v", err)
+	}
+	wc := quests[0].WinCondition
+
+	pass := fakeValidator{passing: map[string]bool{wc.Command: true}}
+	if !CheckWinCondition(wc, pass, "", "") {
+		t.Error("Expected exit 0 to pass")
+	}
+	if CheckWinCondition(wc, fakeValidator{}, "", "") {
+		t.Error("Expected a non-zero exit to fail")
+	}
+}
+
+func TestTraceWinCondition(t *testing.T) {
+	v := fakeValidator{
+		outputs: map[string]string{
+			"stat -c %a hut":             "755\n",
+			"test -f notes && echo yes":  "yes\n",
+			"cat notes":                  "hello\n",
+			"test -f run.sh && echo yes": "yes\n",
+			"test -x run.sh && echo yes": "",
+			"test -d /tmp/x && echo yes": "",
+		},
+		users: map[string][]string{"player": {"player"}},
+	}
+
+	cases := []struct {
+		name               string
+		wc                 WinCondition
+		expected, observed string
+	}{
+		{"command output", WinCondition{Type: CommandOut, Command: "stat -c %a hut", Expected: "700"}, "700", "755"},
+		{"dir missing", WinCondition{Type: DirExists, Target: "/tmp/x"}, "present", "missing"},
+		{"file body", WinCondition{Type: FileEquals, Target: "notes", Content: "bye"}, "bye", "hello\n"},
+		{"not executable", WinCondition{Type: ScriptRuns, Target: "run.sh", Expected: "ok"}, "ok", "not executable"},
+		{"not in group", WinCondition{Type: UserInGroup, Target: "player", Content: "sudo"}, "member of sudo", "not a member"},
+		{"no user", WinCondition{Type: UserInGroup, Target: "ghost", Content: "sudo"}, "member of sudo", "no such user"},
+		{"custom", WinCondition{Type: Custom, Command: "false"}, "exit 0", "exit status 1"},
+		{"cwd", WinCondition{Type: CurrentDirMatch, Target: "hut"}, "/home/player/hut", "/home/player"},
+	}
+
+	for _, tc := range cases {
+		got := TraceWinCondition(tc.wc, v, "", "/home/player")
+		if got.Passed {
+			t.Errorf("%s: expected a failure", tc.name)
+		}
+		if got.Expected != tc.expected || got.Observed != tc.observed {
+			t.Errorf("%s: expected %q/%q, got %q/%q", tc.name, tc.expected, tc.observed, got.Expected, got.Observed)
+		}
+	}
+}
+
+func TestNotExistsConditions(t *testing.T) {
+	fileGone := WinCondition{Type: FileNotExists, Target: "hut/trash.txt"}
+	dirGone := WinCondition{Type: DirNotExists, Target: "/tmp/old_camp"}
+
+	// Both still present: test ! prints nothing
+	v := fakeValidator{outputs: map[string]string{}}
+	if CheckWinCondition(fileGone, v, "", "") || CheckWinCondition(dirGone, v, "", "") {
+		t.Error("Expected present targets to fail")
+	}
+
+	// After rm and rmdir
+	v.outputs["test ! -e hut/trash.txt && echo yes"] = "yes\n"
+	v.outputs["test ! -d /tmp/old_camp && echo yes"] = "yes\n"
+	if !CheckWinCondition(fileGone, v, "", "") || !CheckWinCondition(dirGone, v, "", "") {
+		t.Error("Expected removed targets to pass")
+	}
+
+	if err := ValidateQuests([]Quest{{ID: 1, Title: "Clean Up", WinCondition: fileGone}}); err != nil {
+		t.Errorf("Expected file_not_exists to be a known type, got %v", err)
+	}
+}
+
+func TestCheckQuest(t *testing.T) {
+	q := Quest{
+		WinCondition: WinCondition{Type: DirExists, Target: "camp"},
+		Checklist: []WinCondition{
+			{Type: FileExists, Target: "camp/tent"},
+			{Type: UserExists, Target: "glitch"},
+		},
+	}
+	v := fakeValidator{outputs: map[string]string{"test -d camp && echo yes": "yes\n"}, users: map[string][]string{"glitch": nil}}
+
+	check := CheckQuest(q.ActiveWinConditions(true), v, "", "")
+	if check.Passed() {
+		t.Error("Expected the quest to fail with a checklist item missing")
+	}
+	if check.Done() != 2 || check.Total() != 3 || check.Percent() != 66 {
+		t.Errorf("Expected 2/3 (66%%), got %d/%d (%d%%)", check.Done(), check.Total(), check.Percent())
+	}
+	if check.Conditions[check.Focus()].Target != "camp/tent" {
+		t.Errorf("Expected the missing tent to be the focus, got %+v", check.Conditions[check.Focus()])
+	}
+
+	v.outputs["test -f camp/tent && echo yes"] = "yes\n"
+	check = CheckQuest(q.ActiveWinConditions(true), v, "", "")
+	if !check.Passed() || check.Percent() != 100 {
+		t.Errorf("Expected every condition to pass, got %d/%d", check.Done(), check.Total())
+	}
+
+	if err := ValidateQuests([]Quest{{ID: 1, Title: "Camp", WinCondition: q.WinCondition, Checklist: []WinCondition{{Type: "bogus"}}}}); err == nil {
+		t.Error("Expected an unknown checklist type to be rejected")
+	}
+}
@@ -0,0 +1,62 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+)
+
+// HintCost is the XP price of revealing a quest hint, unless the
+// difficulty makes it cheaper
+const HintCost = 10
+
+// Balance returns the XP the player can still spend
+func (s *GameState) Balance() int {
+	return s.TotalXP - s.SpentXP
+}
+
+// AwardXP adds a quest reward to the player's lifetime XP
+func (s *GameState) AwardXP(amount int) {
+	s.TotalXP += amount
+}
+
+// AwardBonus adds the XP for optional objective bonus of questID and records
+// it as earned. A bonus already earned, say on a replay, pays nothing and
+// AwardBonus returns false.
+func (s *GameState) AwardBonus(questID, bonus, amount int) bool {
+	if s.BonusEarned(questID, bonus) {
+		return false
+	}
+	if s.BonusesEarned == nil {
+		s.BonusesEarned = make(map[int][]int)
+	}
+	s.BonusesEarned[questID] = append(s.BonusesEarned[questID], bonus)
+	s.TotalXP += amount
+	return true
+}
+
+// BonusEarned reports whether optional objective bonus of questID was rewarded
+func (s *GameState) BonusEarned(questID, bonus int) bool {
+	return slices.Contains(s.BonusesEarned[questID], bonus)
+}
+
+// HintUnlocked reports whether the hint for questID has already been bought
+func (s *GameState) HintUnlocked(questID int) bool {
+	return s.HintsBought[questID]
+}
+
+// BuyHint spends cost XP to unlock the hint for questID.
+// Buying an already unlocked hint is free.
+func (s *GameState) BuyHint(questID, cost int) error {
+	if s.HintUnlocked(questID) {
+		return nil
+	}
+	if s.Balance() < cost {
+		return fmt.Errorf("not enough XP: hint costs %d, you have %d", cost, s.Balance())
+	}
+	if s.HintsBought == nil {
+		s.HintsBought = make(map[int]bool)
+	}
+	s.SpentXP += cost
+	s.HintsBought[questID] = true
+	return nil
+}
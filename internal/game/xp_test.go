@@ -0,0 +1,66 @@
+package game
+
+import "testing"
+
+func TestBuyHint(t *testing.T) {
+	var state GameState
+
+	if err := state.BuyHint(1, HintCost); err == nil {
+		t.Error("Expected hint to be blocked with no XP")
+	}
+
+	state.AwardXP(HintCost + 5)
+	if err := state.BuyHint(1, HintCost); err != nil {
+		t.Fatalf("Expected hint purchase to succeed: %v", err)
+	}
+	if state.Balance() != 5 {
+		t.Errorf("Expected balance 5, got %d", state.Balance())
+	}
+
+	// Re-reading an unlocked hint costs nothing
+	if err := state.BuyHint(1, HintCost); err != nil {
+		t.Errorf("Expected unlocked hint to be free: %v", err)
+	}
+	if state.SpentXP != HintCost {
+		t.Errorf("Expected %d XP spent, got %d", HintCost, state.SpentXP)
+	}
+
+	if err := state.BuyHint(2, HintCost); err == nil {
+		t.Error("Expected second hint to be blocked when balance is too low")
+	}
+}
+
+func TestAwardBonus(t *testing.T) {
+	quests, err := ParseQuests([]byte(`
+quests:
+  - id: 7
+    title: "Camp"
+    win_condition:
+      type: "directory_exists"
+      target: "camp"
+    optional_objectives:
+      - description: "Locked it down to 700"
+        xp_bonus: 15
+        type: "command_output_matches"
+        command: "stat -c %a camp"
+        expected_output: "700"
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse quests: %v", err)
+	}
+	bonus := quests[0].OptionalObjectives[0]
+	if bonus.XPBonus != 15 || bonus.Type != CommandOut || bonus.Expected != "700" {
+		t.Fatalf("Expected the bonus condition to be read inline, got %+v", bonus)
+	}
+
+	var state GameState
+	if !state.AwardBonus(7, 0, bonus.XPBonus) || state.TotalXP != 15 {
+		t.Errorf("Expected the first award to pay 15 XP, got %d", state.TotalXP)
+	}
+	if state.AwardBonus(7, 0, bonus.XPBonus) || state.TotalXP != 15 {
+		t.Errorf("Expected a replayed bonus to pay nothing, got %d", state.TotalXP)
+	}
+	if !state.BonusEarned(7, 0) || state.BonusEarned(7, 1) {
+		t.Errorf("Expected only bonus 0 to be recorded, got %v", state.BonusesEarned)
+	}
+}
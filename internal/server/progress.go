@@ -0,0 +1,64 @@
+package server
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+
+	"goblin-terminal/internal/game"
+)
+
+// ProgressServer exposes the live game progress as read-only JSON
+type ProgressServer struct {
+	mu       sync.RWMutex
+	progress game.Progress
+}
+
+// Publish replaces the progress served to clients. Safe to call from the UI goroutine.
+func (s *ProgressServer) Publish(p game.Progress) {
+	s.mu.Lock()
+	s.progress = p
+	s.mu.Unlock()
+}
+
+// Handler serves GET /progress
+func (s *ProgressServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/progress", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.mu.RLock()
+		p := s.progress
+		s.mu.RUnlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(p)
+	})
+	return mux
+}
+
+// LocalAddr binds a bare ":port" to localhost so the endpoint isn't exposed
+// to the network unless a host is given explicitly
+func LocalAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+	return net.JoinHostPort("127.0.0.1", port)
+}
+
+// Listen binds addr so errors surface before the UI starts, then serves in the background
+func (s *ProgressServer) Listen(addr string) error {
+	ln, err := net.Listen("tcp", LocalAddr(addr))
+	if err != nil {
+		return err
+	}
+	go func() {
+		_ = http.Serve(ln, s.Handler())
+	}()
+	return nil
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"goblin-terminal/internal/game"
+)
+
+func TestProgressHandler(t *testing.T) {
+	s := &ProgressServer{}
+	s.Publish(game.Progress{QuestID: 4, Completed: 3, Total: 30, TotalXP: 45})
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/progress", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	var got game.Progress
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Expected JSON body, got %q: %v", rec.Body.String(), err)
+	}
+	if got.QuestID != 4 || got.Completed != 3 || got.TotalXP != 45 {
+		t.Errorf("Expected published progress, got %+v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/progress", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected writes to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestLocalAddr(t *testing.T) {
+	cases := map[string]string{
+		":8080":          "127.0.0.1:8080",
+		"0.0.0.0:8080":   "0.0.0.0:8080",
+		"localhost:9000": "localhost:9000",
+	}
+	for in, want := range cases {
+		if got := LocalAddr(in); got != want {
+			t.Errorf("LocalAddr(%q): expected %q, got %q", in, want, got)
+		}
+	}
+}
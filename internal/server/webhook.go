@@ -0,0 +1,57 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// WebhookTimeout bounds each delivery so a dead collector costs little
+const WebhookTimeout = 5 * time.Second
+
+// Completion is the JSON body POSTed to a webhook when a quest is completed
+type Completion struct {
+	Profile   string    `json:"profile"`
+	QuestID   int       `json:"quest_id"`
+	XP        int       `json:"xp"` // Total XP after the quest's reward
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Webhook reports quest completions to a URL, e.g. an LMS or a classroom
+// collector. Delivery is best effort: one attempt, no retries.
+type Webhook struct {
+	URL     string
+	Profile string // Who is playing, as the collector should know them
+	Client  *http.Client
+}
+
+// NewWebhook returns a webhook for rawURL, which must be http or https.
+// Plain http is fine here: collectors usually sit on localhost or the LAN.
+func NewWebhook(rawURL, profile string) (*Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q: give an http:// or https:// address", rawURL)
+	}
+	return &Webhook{URL: rawURL, Profile: profile, Client: &http.Client{Timeout: WebhookTimeout}}, nil
+}
+
+// Send POSTs a completion of questID with the player's total xp. Any status
+// outside 2xx counts as a failure.
+func (w *Webhook) Send(questID, xp int) error {
+	body, err := json.Marshal(Completion{Profile: w.Profile, QuestID: questID, XP: xp, Timestamp: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	resp, err := w.Client.Post(w.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookSend(t *testing.T) {
+	var got Completion
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON POST, got %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	hook, err := NewWebhook(srv.URL+"/done", "grub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hook.Send(4, 120); err != nil {
+		t.Fatalf("Expected delivery to succeed, got %v", err)
+	}
+	if got.Profile != "grub" || got.QuestID != 4 || got.XP != 120 || got.Timestamp.IsZero() {
+		t.Errorf("Unexpected payload %+v", got)
+	}
+
+	status = http.StatusInternalServerError
+	if err := hook.Send(5, 130); err == nil {
+		t.Error("Expected a server error to be reported")
+	}
+}
+
+func TestNewWebhookRejectsBadURLs(t *testing.T) {
+	for _, bad := range []string{"", "localhost:9000", "ftp://example.com/x", "http://"} {
+		if _, err := NewWebhook(bad, "grub"); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+	if _, err := NewWebhook("http://localhost:9000/hook", "grub"); err != nil {
+		t.Errorf("Expected plain http on localhost to be allowed, got %v", err)
+	}
+}
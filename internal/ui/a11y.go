@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"strings"
+
+	"goblin-terminal/internal/game"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// accessibleView is View for screen readers: labeled plain-text sections in
+// reading order, with no borders, colors, blinking cursor or ticking clock
+func (m Model) accessibleView() string {
+	width := max(m.width, 1)
+	wrapLines := func(text string) []string {
+		return strings.Split(ansi.Wrap(text, width, ""), "\n")
+	}
+
+	header := wrapLines(T("objective.label", m.objectiveText()+m.checklistHeader()))
+	if m.gameStarted {
+		status := T("a11y.status", int(game.WeightedProgress(m.quests, m.currentQuestIdx)*100), m.state.Balance())
+		if left, ok := m.timeLeft(); ok {
+			status += " " + T("a11y.challenge", game.FormatDuration(left), m.attempt, m.attemptLimit())
+		}
+		header = append(header, wrapLines(status)...)
+	}
+
+	glitch := []string{T("a11y.glitch")}
+	for _, line := range strings.Split(strings.TrimRight(m.glitchText, "\n"), "\n") {
+		glitch = append(glitch, wrapLines(ansi.Strip(line))...)
+	}
+	if m.running != nil {
+		// No spinner: a changing line would be read out over and over
+		glitch = append(glitch, wrapLines(T("running.a11y", m.running.shown()))...)
+	}
+
+	input := m.maskIndicator() + m.promptString() + inputWindow(m.shown(m.input), width-lipgloss.Width(m.maskIndicator()+m.promptString()))
+	if m.search != nil {
+		input = inputWindow(m.searchPrompt(), width)
+	}
+	if m.sudo != nil {
+		input = sudoPromptText()
+	}
+	if m.stdin != nil {
+		input = m.maskIndicator() + inputWindow(m.shown(m.input), width-lipgloss.Width(m.maskIndicator()))
+	}
+
+	termHeight := max(m.height-len(header)-len(glitch)-1, 0)
+	var visible []string
+	switch {
+	case m.menuOpen:
+		visible = wrapLines(ansi.Strip(m.renderMenu()))
+	case m.dialog != nil:
+		visible = wrapLines(ansi.Strip(m.renderConfirm()))
+	case m.onboarding:
+		visible = wrapLines(ansi.Strip(m.renderOnboarding()))
+	default:
+		for i := len(m.output) - 1; i >= 0 && len(visible) < termHeight; i-- {
+			line := ansi.Strip(m.output[i])
+			if i == m.searchLine() {
+				line = "> " + line
+			}
+			visible = append(wrapLines(line), visible...)
+		}
+	}
+	if len(visible) > termHeight {
+		visible = visible[len(visible)-termHeight:]
+	}
+	for len(visible) < termHeight {
+		visible = append(visible, "")
+	}
+
+	rows := append(header, visible...)
+	rows = append(rows, glitch...)
+	rows = append(rows, input)
+	if len(rows) > m.height {
+		// A long intro on a short screen: keep the prompt, lose the top
+		rows = rows[len(rows)-m.height:]
+	}
+	return strings.Join(rows, "\n")
+}
+
+// boxStyle frames overlays and panels with a rounded border in color,
+// or leaves them unframed in accessibility mode
+func (m Model) boxStyle(color string) lipgloss.Style {
+	if m.a11y {
+		return lipgloss.NewStyle()
+	}
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(color))
+}
+
+// announceObjective states the new objective in the scrollback, since a
+// screen reader follows the output rather than the header
+func (m *Model) announceObjective() {
+	if m.a11y {
+		m.output = append(m.output, T("a11y.objective", m.objectiveText()))
+	}
+}
+
+// completionLine is the scrollback notice for a finished quest
+func (m Model) completionLine(q game.Quest) string {
+	if m.a11y {
+		return T("a11y.complete", q.ID, q.Title, m.difficulty.XP(q.XPReward))
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Bold(true).Render(T("quest.complete", m.difficulty.XP(q.XPReward)))
+}
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"strings"
+	"unicode"
+)
+
+// glitchAnswer is one of Glitch's canned replies to `ask`, given when a word
+// of the question starts with one of its keywords
+type glitchAnswer struct {
+	keywords []string
+	reply    string // Catalog key of the reply
+}
+
+// glitchAnswers are tried in order, so narrower topics go before broad ones.
+// Adding a topic is a line here and its reply in the catalog.
+var glitchAnswers = []glitchAnswer{
+	{[]string{"permission", "denied", "chmod", "rwx", "executable"}, "ask.permission"},
+	{[]string{"sudo", "root", "admin"}, "ask.sudo"},
+	{[]string{"owner", "chown", "chgrp"}, "ask.owner"},
+	{[]string{"user", "group", "useradd", "passwd"}, "ask.users"},
+	{[]string{"ssh", "scp", "remote", "gateway"}, "ask.ssh"},
+	{[]string{"network", "ping", "ip", "port"}, "ask.network"},
+	{[]string{"process", "kill", "ps", "running"}, "ask.process"},
+	{[]string{"pipe", "grep", "find", "search", "look"}, "ask.search"},
+	{[]string{"path", "absolute", "relative"}, "ask.path"},
+	{[]string{"cd", "directory", "directories", "folder", "where", "pwd"}, "ask.directory"},
+	{[]string{"file", "touch", "mkdir", "create", "make"}, "ask.create"},
+	{[]string{"stuck", "hint", "help", "lost"}, "ask.stuck"},
+}
+
+// askGlitch answers a question typed after `ask` from glitchAnswers,
+// shrugging when nothing in it is a topic Glitch knows
+func askGlitch(question string) string {
+	words := strings.FieldsFunc(strings.ToLower(question), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, answer := range glitchAnswers {
+		for _, keyword := range answer.keywords {
+			for _, word := range words {
+				if strings.HasPrefix(word, keyword) {
+					return T(answer.reply)
+				}
+			}
+		}
+	}
+	return T("ask.unknown")
+}
+
+// ask has Glitch answer the player's question in its box. Hard Mode players
+// get no help from the goblin.
+func (m *Model) ask(question string) {
+	switch {
+	case m.hardMode:
+		m.output = append(m.output, T("ask.hard_mode"))
+	case question == "":
+		m.output = append(m.output, T("ask.usage"))
+	default:
+		// Small talk, not story, so it stays out of the lore log
+		m.glitchText = askGlitch(question)
+	}
+}
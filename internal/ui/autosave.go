@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"encoding/json"
+	"time"
+
+	"goblin-terminal/internal/game"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DefaultAutosaveInterval is how often progress is written while playing
+const DefaultAutosaveInterval = 30 * time.Second
+
+type autosaveMsg struct{}
+
+// scheduleAutosave arms the next periodic save, if autosave is on
+func (m Model) scheduleAutosave() tea.Cmd {
+	if m.autosaveEvery <= 0 || m.demo || m.scripted {
+		return nil
+	}
+	return tea.Tick(m.autosaveEvery, func(time.Time) tea.Msg {
+		return autosaveMsg{}
+	})
+}
+
+// saveState writes the save file along with the player's working directory.
+// Nothing is written when the state hasn't changed since the last save, in the
+// demo, or when replaying a script.
+func (m *Model) saveState() {
+	if m.demo || m.scripted {
+		return
+	}
+	if m.manager != nil && m.ready {
+		m.state.CurrentDir = m.manager.CurrentDir
+	}
+	m.state.QuestCount = len(m.quests)
+	m.state.RememberHistory(m.history)
+
+	encoded, err := json.Marshal(m.state)
+	if err != nil || string(encoded) == m.lastSaved {
+		return
+	}
+	if game.SaveState(m.state) == nil {
+		m.lastSaved = string(encoded)
+	}
+}
@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"strconv"
+	"time"
+
+	"goblin-terminal/internal/game"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// timeLimitMsg fires when the time limit of the quest started in generation gen runs out
+type timeLimitMsg struct{ gen int }
+
+// scheduleTimeLimit arms the expiry timer for a challenge quest
+func (m Model) scheduleTimeLimit(q game.Quest) tea.Cmd {
+	// The demo and scripts don't play against the clock
+	if q.TimeLimitSeconds <= 0 || m.demo || m.scripted {
+		return nil
+	}
+	gen := m.pollGen
+	return tea.Tick(q.TimeLimit(), func(time.Time) tea.Msg {
+		return timeLimitMsg{gen: gen}
+	})
+}
+
+// timeLeft is what remains of the current challenge's limit, and whether one is running
+func (m Model) timeLeft() (time.Duration, bool) {
+	if m.currentQuestIdx >= len(m.quests) || m.outOfAttempts {
+		return 0, false
+	}
+	q := m.quests[m.currentQuestIdx]
+	if q.TimeLimitSeconds <= 0 || m.demo || m.scripted {
+		return 0, false
+	}
+	return max(0, q.TimeLimit()-time.Since(m.questStart)), true
+}
+
+// expireChallenge handles a quest running out of time: retry it, ask first,
+// or stop timing once the attempts are used up
+func (m Model) expireChallenge() (Model, tea.Cmd) {
+	q := m.quests[m.currentQuestIdx]
+	m.output = append(m.output, T("challenge.expired", q.Title))
+
+	if q.MaxAttempts > 0 && m.attempt >= q.MaxAttempts {
+		// Let the player finish without the clock rather than locking them out
+		m.outOfAttempts = true
+		m.output = append(m.output, T("challenge.out_of_attempts", q.MaxAttempts))
+		return m, nil
+	}
+
+	if q.ConfirmRetry {
+		m = m.confirm(T("confirm.retry"), func(m Model) (Model, tea.Cmd) {
+			return m.retryChallenge()
+		}, func(m Model) (Model, tea.Cmd) {
+			m.outOfAttempts = true
+			m.output = append(m.output, T("challenge.declined"))
+			return m, nil
+		})
+		return m, nil
+	}
+	return m.retryChallenge()
+}
+
+// retryChallenge resets the quest's environment, reruns its setup and restarts the clock
+func (m Model) retryChallenge() (Model, tea.Cmd) {
+	attempt := m.attempt + 1
+	m.output = append(m.output, T("env.restarting"))
+	restart := m.restartContainer()
+	setup := m.startQuest(m.currentQuestIdx)
+	m.attempt = attempt
+	m.output = append(m.output, T("challenge.attempt", attempt, m.attemptLimit()))
+	return m, tea.Sequence(restart, setup)
+}
+
+// attemptLimit formats the current quest's attempt limit, "∞" when unlimited
+func (m Model) attemptLimit() string {
+	if limit := m.quests[m.currentQuestIdx].MaxAttempts; limit > 0 {
+		return strconv.Itoa(limit)
+	}
+	return "∞"
+}
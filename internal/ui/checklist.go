@@ -0,0 +1,28 @@
+package ui
+
+// checklistProgress is how many of the current quest's conditions passed at
+// the last check, out of how many it has
+func (m Model) checklistProgress() (done, total int) {
+	if m.currentQuestIdx < 0 || m.currentQuestIdx >= len(m.quests) {
+		return 0, 0
+	}
+	return m.checksDone, len(m.quests[m.currentQuestIdx].Checklist) + 1
+}
+
+// checklistPercent is done out of total as a whole percentage
+func checklistPercent(done, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return done * 100 / total
+}
+
+// checklistHeader is the " (3/5)" after the objective on checklist quests.
+// Single-condition quests are either done or not, so they show nothing.
+func (m Model) checklistHeader() string {
+	done, total := m.checklistProgress()
+	if total < 2 {
+		return ""
+	}
+	return " " + T("checklist.header", done, total)
+}
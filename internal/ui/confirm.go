@@ -0,0 +1,51 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// confirmAction runs when the player answers a confirm dialog
+type confirmAction func(Model) (Model, tea.Cmd)
+
+// confirmDialog is a yes/no question shown as an overlay
+type confirmDialog struct {
+	message string
+	onYes   confirmAction
+	onNo    confirmAction // May be nil, meaning just close
+}
+
+// confirm opens a yes/no dialog. Keys are intercepted until it is answered.
+func (m Model) confirm(message string, onYes, onNo confirmAction) Model {
+	m.dialog = &confirmDialog{message: message, onYes: onYes, onNo: onNo}
+	return m
+}
+
+// updateConfirm handles y/n/Esc while a dialog is open
+func (m Model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	dialog := m.dialog
+
+	var action confirmAction
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		return m.shutdown()
+	case msg.Type == tea.KeyRunes && (string(msg.Runes) == "y" || string(msg.Runes) == "Y"):
+		action = dialog.onYes
+	case msg.Type == tea.KeyRunes && (string(msg.Runes) == "n" || string(msg.Runes) == "N"), msg.Type == tea.KeyEsc:
+		action = dialog.onNo
+	default:
+		return m, nil
+	}
+
+	m.dialog = nil
+	if action == nil {
+		return m, nil
+	}
+	return action(m)
+}
+
+// renderConfirm draws the open dialog
+func (m Model) renderConfirm() string {
+	return m.boxStyle(theme.HardMode).
+		Padding(0, 2).
+		Render(m.dialog.message + "\n\n" + T("confirm.keys"))
+}
@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+
+	"goblin-terminal/internal/game"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// fileDiff compares a failed file win condition against the real file, for --debug.
+// Returns nil for other condition types.
+func fileDiff(wc game.WinCondition, v game.Validator) []game.DiffLine {
+	if wc.Type != game.FileEquals && wc.Type != game.FileContains {
+		return nil
+	}
+	run := v.ExecuteValidation
+	if wc.RootCheck {
+		run = v.RunAsRoot
+	}
+	actual, _ := run("cat " + wc.Target)
+	return game.DiffLines(wc.Content, actual)
+}
+
+// traceValueMax keeps a long file body from swamping a check log line
+const traceValueMax = 60
+
+// checkTraceLine summarizes one win condition evaluation for --debug
+func checkTraceLine(wc game.WinCondition, t game.CheckTrace) string {
+	subject := wc.Target
+	if wc.Type == game.CommandOut || wc.Type == game.Custom {
+		subject = wc.Command
+	}
+	label := strings.TrimSpace(string(wc.Type) + " " + subject)
+
+	verdict := "FAIL"
+	if t.Passed {
+		verdict = "PASS"
+	}
+	return T("debug.check", label, traceValue(t.Expected), traceValue(t.Observed), verdict)
+}
+
+// traceValue shortens a value and quotes it when it has spaces or newlines
+func traceValue(s string) string {
+	if runes := []rune(s); len(runes) > traceValueMax {
+		s = string(runes[:traceValueMax-3]) + "..."
+	}
+	if s == "" || strings.ContainsAny(s, " \t\n\"") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// renderDiff colors a diff, showing trailing whitespace so it can't hide
+func renderDiff(target string, diff []game.DiffLine) []string {
+	removed := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.HardMode))
+	added := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Glitch))
+	same := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+
+	lines := []string{T("debug.diff_header", target)}
+	for _, d := range diff {
+		text := string(d.Op) + " " + showTrailingSpace(d.Text)
+		switch d.Op {
+		case game.DiffRemove:
+			lines = append(lines, removed.Render(text))
+		case game.DiffAdd:
+			lines = append(lines, added.Render(text))
+		default:
+			lines = append(lines, same.Render(text))
+		}
+	}
+	return lines
+}
+
+// showTrailingSpace replaces trailing spaces and tabs with visible markers
+func showTrailingSpace(s string) string {
+	trimmed := strings.TrimRight(s, " \t")
+	tail := s[len(trimmed):]
+	tail = strings.NewReplacer(" ", "·", "\t", "→").Replace(tail)
+	return trimmed + tail
+}
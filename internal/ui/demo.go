@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"time"
+	"unicode/utf8"
+
+	"goblin-terminal/internal/game"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Demo (attract) mode types each quest's solution at a readable pace
+type demoTickMsg struct{}
+type demoRestartMsg struct{ err error }
+
+const (
+	demoTypeDelay    = 60 * time.Millisecond   // Between typed characters
+	demoCommandDelay = 1500 * time.Millisecond // After pressing Enter
+	demoIdleDelay    = 500 * time.Millisecond  // While waiting for a quest to pass
+	demoStallTicks   = 20                      // Idle ticks before skipping a quest that didn't pass
+	demoLoopDelay    = 3 * time.Second         // Pause on the final screen before looping
+)
+
+func demoTick(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(time.Time) tea.Msg {
+		return demoTickMsg{}
+	})
+}
+
+// loadDemo queues the solution of q for typing
+func (m *Model) loadDemo(q game.Quest) {
+	if !m.demo {
+		return
+	}
+	m.demoQueue = append([]string{}, q.Solution...)
+	m.demoTyping = ""
+	m.demoIdle = 0
+}
+
+// updateDemo advances the auto-player by one step
+func (m Model) updateDemo() (tea.Model, tea.Cmd) {
+	if m.currentQuestIdx >= len(m.quests) {
+		// The loop restart is already scheduled
+		return m, nil
+	}
+
+	switch {
+	case m.demoWaiting:
+		// Let the previous command finish before typing the next one
+		return m, demoTick(demoIdleDelay)
+
+	case m.demoTyping != "":
+		r, size := utf8.DecodeRuneInString(m.demoTyping)
+		m.input += string(r)
+		m.demoTyping = m.demoTyping[size:]
+		return m, demoTick(demoTypeDelay)
+
+	case m.input != "":
+		// Fully typed: press Enter
+		updated, cmd := m.submitInput()
+		m = updated.(Model)
+		m.demoWaiting = cmd != nil
+		return m, tea.Batch(cmd, demoTick(demoCommandDelay))
+
+	case len(m.demoQueue) > 0:
+		m.demoTyping = m.demoQueue[0]
+		m.demoQueue = m.demoQueue[1:]
+		m.demoIdle = 0
+		return m, demoTick(demoTypeDelay)
+	}
+
+	// Solution exhausted but the quest hasn't passed: wait a little, then move on
+	m.demoIdle++
+	if m.demoIdle <= demoStallTicks {
+		return m, demoTick(demoIdleDelay)
+	}
+
+	m.output = append(m.output, T("demo.skip"))
+	nextIdx := m.currentQuestIdx + 1
+	if nextIdx >= len(m.quests) {
+		m.currentQuestIdx = nextIdx
+		m.say(T("quest.all_done"))
+		return m, m.restartDemo()
+	}
+	return m, tea.Batch(m.startQuest(nextIdx), demoTick(demoCommandDelay))
+}
+
+// restartDemo wipes the environment after a pause so the demo can loop from the start
+func (m Model) restartDemo() tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(demoLoopDelay)
+		if err := m.manager.ResetStorage(); err != nil {
+			return demoRestartMsg{err: err}
+		}
+		return demoRestartMsg{err: m.manager.StartContainer()}
+	}
+}
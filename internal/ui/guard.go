@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"path"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// wipesHome reports whether command, run in dir, is a recursive rm of the
+// home directory or the whole filesystem: the slips that throw away every
+// quest's work at once. Anything more targeted runs as typed.
+func wipesHome(command, home, dir string) bool {
+	fields := strings.Fields(command)
+	if len(fields) > 0 && fields[0] == "sudo" {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 || fields[0] != "rm" {
+		return false
+	}
+
+	recursive, wide := false, false
+	for _, f := range fields[1:] {
+		switch {
+		case f == "--recursive" || strings.HasPrefix(f, "-") && !strings.HasPrefix(f, "--") && strings.ContainsAny(f, "rR"):
+			recursive = true
+		case strings.HasPrefix(f, "-"):
+		default:
+			target := strings.TrimSuffix(f, "*")
+			if target != "/" {
+				target = strings.TrimSuffix(target, "/")
+			}
+			switch target {
+			case "~", "/", "$HOME", path.Clean(home):
+				wide = true
+			case "", ".":
+				// Everything here, which is everything when here is home
+				wide = wide || path.Clean(dir) == path.Clean(home)
+			}
+		}
+	}
+	return recursive && wide
+}
+
+// guardCommand asks before running a command that would wipe the home
+// directory, and runs it only if the player says yes. A secret command is
+// run the same way it was typed: without showing it.
+func (m Model) guardCommand(command string, secret bool) Model {
+	shown := command
+	if secret {
+		shown = mask(command)
+	}
+	return m.confirm(T("guard.confirm", shown), func(m Model) (Model, tea.Cmd) {
+		run := m.runCommand(command, secret)
+		return m, run
+	}, func(m Model) (Model, tea.Cmd) {
+		m.output = append(m.output, T("guard.cancelled"))
+		return m, nil
+	})
+}
@@ -0,0 +1,39 @@
+package ui
+
+// Screen layouts for Glitch's box
+const (
+	LayoutBottom = "bottom"
+	LayoutSide   = "side"
+)
+
+// Layouts lists the valid --layout values
+var Layouts = []string{LayoutBottom, LayoutSide}
+
+// minSideLayoutWidth is the narrowest terminal that gets the side layout;
+// below it the terminal column would be too cramped, so Glitch goes back to the bottom
+const minSideLayoutWidth = 100
+
+// sideLayout reports whether Glitch is drawn as a right-hand column
+func (m Model) sideLayout() bool {
+	return m.layout == LayoutSide && m.width >= minSideLayoutWidth
+}
+
+// sideColumnWidth is the width of Glitch's column: a third of the screen, within limits
+func sideColumnWidth(width int) int {
+	w := width / 3
+	if w < 32 {
+		w = 32
+	}
+	if w > 60 {
+		w = 60
+	}
+	return w
+}
+
+// nextLayout cycles to the other layout, for the pause menu
+func nextLayout(current string) string {
+	if current == LayoutSide {
+		return LayoutBottom
+	}
+	return LayoutSide
+}
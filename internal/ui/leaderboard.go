@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"time"
+
+	"goblin-terminal/internal/game"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// leaderboardShown is how many runs the leaderboard lists
+const leaderboardShown = 10
+
+// recordRun puts a finished full run on the leaderboard and shows where it
+// placed. Scripted runs aren't real play, so they stay off it.
+func (m *Model) recordRun(total time.Duration) {
+	if m.scripted {
+		return
+	}
+	rank, err := game.AddLeaderboardEntry(game.LeaderboardEntry{
+		Profile: m.profile,
+		Time:    total,
+		XP:      m.state.TotalXP,
+		Hints:   len(m.state.HintsBought),
+		Date:    time.Now(),
+	})
+	if err != nil {
+		m.output = append(m.output, T("leaderboard.save_error", err))
+		return
+	}
+	entries, err := game.LoadLeaderboard()
+	if err != nil {
+		return
+	}
+	if rank == 0 {
+		m.output = append(m.output, T("leaderboard.missed", game.MaxLeaderboardEntries))
+		return
+	}
+	m.output = append(m.output, lipgloss.NewStyle().Bold(true).Render(T("leaderboard.rank", rank)))
+	m.showLeaderboard(entries, rank)
+}
+
+// showLeaderboard prints the top runs, marking highlight (a 1-based rank)
+// when it is one of them
+func (m *Model) showLeaderboard(entries []game.LeaderboardEntry, highlight int) {
+	if len(entries) == 0 {
+		m.output = append(m.output, T("leaderboard.empty"))
+		return
+	}
+	m.output = append(m.output, T("leaderboard.header"))
+	for i, e := range entries[:min(len(entries), leaderboardShown)] {
+		marker := "  "
+		if i+1 == highlight {
+			marker = "> "
+		}
+		line := T("leaderboard.entry", marker, i+1, e.Profile, game.FormatDuration(e.Time), e.XP, e.Hints, e.Date.Format("2006-01-02"))
+		if i+1 == highlight {
+			line = lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Highlight)).Render(line)
+		}
+		m.output = append(m.output, line)
+	}
+}
@@ -0,0 +1,34 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// containerStopped tells the player, once, that the environment is gone and
+// offers to bring it back. Declining leaves the 'restart' command to do it later.
+func (m Model) containerStopped() Model {
+	if m.containerDown {
+		return m
+	}
+	m.containerDown = true
+	m.output = append(m.output, T("env.container_gone"))
+	if m.scripted {
+		// Nobody is there to answer a dialog
+		m.output = append(m.output, T("env.restart_later"))
+		return m
+	}
+	return m.confirm(T("confirm.restart_env"), func(m Model) (Model, tea.Cmd) {
+		return m.recoverContainer()
+	}, func(m Model) (Model, tea.Cmd) {
+		m.output = append(m.output, T("env.restart_later"))
+		return m, nil
+	})
+}
+
+// recoverContainer starts a fresh environment and reruns the current quest's setup
+func (m Model) recoverContainer() (Model, tea.Cmd) {
+	m.containerDown = false
+	m.output = append(m.output, T("env.restarting"))
+	restart := m.restartContainer()
+	return m, tea.Sequence(restart, m.startQuest(m.currentQuestIdx))
+}
@@ -0,0 +1,26 @@
+package ui
+
+import "strings"
+
+// say puts text in Glitch's box and records it in the story log
+func (m *Model) say(text string) {
+	m.glitchText = text
+	m.state.AddLore(text)
+}
+
+// showLore prints the story log into the scrollback, where it can be scrolled
+// and searched like any other output
+func (m *Model) showLore() {
+	if len(m.state.MsgLog) == 0 {
+		m.output = append(m.output, T("lore.empty"))
+		return
+	}
+	m.output = append(m.output, T("lore.header", len(m.state.MsgLog)))
+	for _, beat := range m.state.MsgLog {
+		m.output = append(m.output, "")
+		for _, line := range strings.Split(beat, "\n") {
+			m.output = append(m.output, styleStoryLine(line))
+		}
+	}
+	m.output = append(m.output, "")
+}
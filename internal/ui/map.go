@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// mapMaxEntries caps the 'map' listing so a runaway directory can't flood the screen
+const mapMaxEntries = 200
+
+type homeTreeMsg struct {
+	output string
+	err    error
+}
+
+// formatTree renders find's "<type> <relative path>" lines as an indented
+// tree rooted at ~, with directories highlighted
+func formatTree(findOutput string) []string {
+	dirStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Highlight)).Bold(true)
+
+	isDir := make(map[string]bool)
+	children := make(map[string][]string)
+	total, dirs := 0, 0
+	for _, line := range strings.Split(strings.TrimSpace(findOutput), "\n") {
+		kind, rel, ok := strings.Cut(line, " ")
+		if !ok || rel == "" {
+			continue
+		}
+		isDir[rel] = kind == "d"
+		parent := path.Dir(rel)
+		if parent == "." {
+			parent = ""
+		}
+		children[parent] = append(children[parent], rel)
+		total++
+		if kind == "d" {
+			dirs++
+		}
+	}
+	for _, kids := range children {
+		sort.Strings(kids)
+	}
+
+	lines := []string{dirStyle.Render("~")}
+	shown := 0
+	var walk func(dir, prefix string)
+	walk = func(dir, prefix string) {
+		kids := children[dir]
+		for i, rel := range kids {
+			if shown == mapMaxEntries {
+				return
+			}
+			shown++
+
+			branch, indent := "├── ", "│   "
+			if i == len(kids)-1 {
+				branch, indent = "└── ", "    "
+			}
+			name := path.Base(rel)
+			if isDir[rel] {
+				name = dirStyle.Render(name + "/")
+			}
+			lines = append(lines, prefix+branch+name)
+			if isDir[rel] {
+				walk(rel, prefix+indent)
+			}
+		}
+	}
+	walk("", "")
+
+	if total == 0 {
+		return append(lines, T("map.empty"))
+	}
+	if shown < total {
+		lines = append(lines, T("map.truncated", total-shown))
+	}
+	return append(lines, "", T("map.summary", dirs, total-dirs))
+}
@@ -0,0 +1,167 @@
+package ui
+
+import (
+	"strings"
+
+	"goblin-terminal/internal/game"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Pause menu entries, in display order
+const (
+	menuResume = iota
+	menuHardMode
+	menuTheme
+	menuColor
+	menuLayout
+	menuStats
+	menuTutorial
+	menuResetQuest
+	menuQuit
+)
+
+var menuKeys = []string{
+	"menu.resume",
+	"menu.hard_mode",
+	"menu.theme",
+	"menu.color",
+	"menu.layout",
+	"menu.stats",
+	"menu.tutorial",
+	"menu.reset_quest",
+	"menu.quit",
+}
+
+// updateMenu handles keys while the pause menu is open
+func (m Model) updateMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlP:
+		m.menuOpen = false
+	case tea.KeyCtrlC:
+		return m.shutdown()
+	case tea.KeyUp:
+		m.menuIdx = (m.menuIdx + len(menuKeys) - 1) % len(menuKeys)
+	case tea.KeyDown:
+		m.menuIdx = (m.menuIdx + 1) % len(menuKeys)
+	case tea.KeyEnter:
+		return m.selectMenuItem()
+	}
+	return m, nil
+}
+
+// selectMenuItem applies the highlighted option
+func (m Model) selectMenuItem() (tea.Model, tea.Cmd) {
+	switch m.menuIdx {
+	case menuResume:
+		m.menuOpen = false
+	case menuHardMode:
+		m.toggleHardMode()
+	case menuTheme:
+		NextTheme()
+	case menuColor:
+		SetColor(!colorEnabled)
+	case menuLayout:
+		m.layout = nextLayout(m.layout)
+	case menuStats:
+		m.menuOpen = false
+		m.output = append(m.output, m.statsLines()...)
+	case menuTutorial:
+		m.menuOpen = false
+		m.onboarding = true
+	case menuResetQuest:
+		m.menuOpen = false
+		if m.currentQuestIdx < len(m.quests) {
+			m = m.confirm(T("confirm.reset_quest"), func(m Model) (Model, tea.Cmd) {
+				m.output = append(m.output, T("menu.resetting"))
+				return m, m.startQuest(m.currentQuestIdx)
+			}, nil)
+		}
+	case menuQuit:
+		m = m.confirm(T("confirm.quit"), func(m Model) (Model, tea.Cmd) {
+			return m.shutdown()
+		}, nil)
+	}
+	return m, nil
+}
+
+// statsLines summarises progress for the stats menu entry
+func (m Model) statsLines() []string {
+	completed := m.currentQuestIdx
+	if completed > len(m.quests) {
+		completed = len(m.quests)
+	}
+	lines := []string{
+		T("stats.header"),
+		T("stats.quests", completed, len(m.quests)),
+		T("stats.xp", m.state.TotalXP, m.state.Balance()),
+	}
+	if m.state.BestTotalTime > 0 {
+		lines = append(lines, T("stats.best_run", game.FormatDuration(m.state.BestTotalTime)))
+	}
+	return lines
+}
+
+// menuValue shows the current setting next to toggle entries
+func (m Model) menuValue(item int) string {
+	onOff := func(on bool) string {
+		if on {
+			return T("menu.on")
+		}
+		return T("menu.off")
+	}
+	switch item {
+	case menuHardMode:
+		return onOff(m.hardMode)
+	case menuTheme:
+		return theme.Name
+	case menuColor:
+		return onOff(colorEnabled)
+	case menuLayout:
+		if m.layout == LayoutSide {
+			return T("menu.layout_side")
+		}
+		return T("menu.layout_bottom")
+	}
+	return ""
+}
+
+// renderMenu draws the pause menu box
+func (m Model) renderMenu() string {
+	selected := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Highlight)).Bold(true)
+
+	var rows []string
+	rows = append(rows, T("menu.title"), "")
+	for i, key := range menuKeys {
+		label := T(key)
+		if value := m.menuValue(i); value != "" {
+			label += ": " + value
+		}
+		if i == m.menuIdx {
+			rows = append(rows, selected.Render("> "+label))
+		} else {
+			rows = append(rows, "  "+label)
+		}
+	}
+	rows = append(rows, "", T("menu.help"))
+
+	return m.boxStyle(theme.Glitch).
+		Padding(0, 2).
+		Render(strings.Join(rows, "\n"))
+}
+
+// overlayCenter replaces the middle rows of background with box, centered horizontally
+func overlayCenter(background []string, box string, width int) []string {
+	boxLines := strings.Split(box, "\n")
+	result := append([]string{}, background...)
+	for len(result) < len(boxLines) {
+		result = append(result, "")
+	}
+
+	top := (len(result) - len(boxLines)) / 2
+	for i, line := range boxLines {
+		result[top+i] = lipgloss.PlaceHorizontal(width, lipgloss.Center, line)
+	}
+	return result
+}
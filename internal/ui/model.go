@@ -1,7 +1,12 @@
 package ui
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -10,14 +15,36 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // Define custom messages
 type containerReadyMsg struct{ err error }
-type commandResultMsg struct {
+type tickMsg time.Time
+type pollMsg struct{ gen int } // Timed win condition re-check for the quest started in generation gen
+type diskSpaceMsg struct{ err error }
+type inventoryMsg struct {
+	output string
+	err    error
+}
+type containerRestartMsg struct{ err error }
+
+// setupMsg reports a quest's setup: the scenario swap's error, if any, and each setup command
+type setupMsg struct {
+	scenarioErr error
+	results     []game.SetupResult
+}
+type processListMsg struct {
 	output string
 	err    error
 }
+type commandResultMsg struct {
+	command  string // What ran, as typed
+	output   string
+	stderr   string // Shown marked when the command succeeded; a failure's is in err
+	combined string // Both streams in the order written, for display
+	err      error
+}
 
 type Model struct {
 	// dependencies
@@ -25,52 +52,249 @@ type Model struct {
 	manager *docker.Manager
 
 	// Game state
+	state           game.GameState // Persisted progress, XP and records
 	currentQuestIdx int
 	gameStarted     bool
 	ready           bool
-	output          []string // Output buffer for the virtual terminal
-	lastOutput      string   // Last command output for validation
-	input           string   // Current input
-	history         []string // Command history
-	historyIdx      int      // Current position in history
-	glitchText      string   // What the goblin is currently saying
+	output          []string       // Output buffer for the virtual terminal
+	lastOutput      string         // Last command output for validation
+	lastReason      string         // Last failed check reason shown, to avoid repeating it
+	secrets         map[int]string // Random token per quest ID, rolled when the quest starts
+	lastDiff        string         // Last --debug file diff shown, to avoid repeating it
+	autosaveEvery   time.Duration  // Periodic save interval; zero disables
+	autoHintAfter   int            // Commands without progress before Glitch offers the hint; zero disables
+	stuckCommands   int            // Commands run since the current quest last progressed
+	autoHinted      bool           // The automatic hint was already shown for this quest
+	checksDone      int            // Current quest's conditions that passed at the last check
+	containerDown   bool           // A check found the player container stopped and the player was told
+	lastSaved       string         // Encoded state last written, to skip redundant saves
+	shuttingDown    bool           // Containers are being removed before quitting
+	shutdownErr     error          // Why removing the containers failed, reported after exit
+	input           string         // Current input
+	history         []string       // Command history
+	historyIdx      int            // Current position in history
+	glitchText      string         // What the goblin is currently saying
+
+	// Environment startup
+	buildFailed  bool // Waiting for the player to retry or quit after a failed build
+	buildRetries int  // Number of retries already attempted
+
+	// Speedrun timer
+	gameStart  time.Time // When the environment became ready
+	questStart time.Time // When the current quest began
+	fullRun    bool      // Session started from the first quest, so the total time counts
+
+	// Win condition polling
+	pollGen    int                   // Bumped on every quest start so older poll timers stop
+	checkCache *game.ValidationCache // Reused path check results; nil unless --fast-validate
 
 	// View state
 	width, height int
-	viewportReady bool // To avoid rendering before size is known
-	hardMode      bool // Hard Mode: hide commands
+	viewportReady bool            // To avoid rendering before size is known
+	hardMode      bool            // Hard Mode: hide commands
+	difficulty    game.Difficulty // Level being played: hint cost, XP, suggestions, guard
+	lineNumbers   bool            // Prefix command output with line numbers
+	menuOpen      bool            // Pause menu overlay is showing
+	menuIdx       int             // Highlighted pause menu entry
+	dialog        *confirmDialog  // Open yes/no dialog, if any
+	sudo          *sudoPrompt     // sudo command waiting for a password, if any
+	stdin         *stdinCapture   // Command waiting for its typed input, if any
+	masked        bool            // Typed input shows as • (see secret.go)
+	running       *runningCommand // Command still executing, if any (see running.go)
+	runGen        int             // Bumped per command so stale spinner ticks stop
+	onboarding    bool            // First-run tutorial overlay is showing
+	search        *scrollSearch   // Open scrollback search, if any
+	allowShell    bool            // !shell may suspend the UI for a raw container shell
+	debug         bool            // Quest-author diagnostics, e.g. file diffs
+	verboseSetup  bool            // Show every setup command with its output, not just failures
+	layout        string          // Where Glitch's box goes: LayoutBottom or LayoutSide
+	a11y          bool            // Screen-reader friendly: plain labeled text, nothing animated
+	prompt        string          // Prompt template, e.g. "%u@%h:%w$ "
+	maxOutput     int             // Oldest output lines are dropped beyond this
+	maxHistory    int             // Oldest history entries are dropped beyond this
+	windowTitle   bool            // Keep the terminal window title in sync
+	lastTitle     string          // Title most recently sent to the terminal
+	transcript    bool            // Save the scrollback to a file on exit
+	splitOutput   bool            // Show stdout, then stderr, instead of interleaved
+	recap         bool            // Replay recent commands and the objective on resume
+	profile       string          // Player name on the leaderboard
+	onProgress    func(game.Progress)
+	onComplete    func(questID, xp int) error // Reports completions to --webhook
+	webhookWarned bool                        // A failed delivery was already mentioned
+
+	// Demo mode
+	demo        bool     // Auto-play quest solutions
+	demoQueue   []string // Solution commands not yet typed
+	demoTyping  string   // Remainder of the command being typed
+	demoIdle    int      // Ticks spent waiting with nothing left to type
+	demoWaiting bool     // A typed command is still running
+	bell        bool     // Ring the terminal bell on quest completion and errors
+	ringing     bool     // A BEL leads the frames rendered until bellDoneMsg
+	scripted    bool     // Driven by RunScript: no timers and no saving
+	newQuests   int      // Quests added by an update since the player finished them all
+
+	// Challenge quests (time limited)
+	attempt       int  // Try number at the current quest, from 1
+	outOfAttempts bool // The clock stopped: attempts used up or a retry declined
 }
 
-func NewModel(quests []game.Quest, manager *docker.Manager, startQuestID int, hardMode bool) Model {
-	initialText := "Initializing Goblin Terminal..."
+// Default caps on the scrollback and command history, so long sessions
+// don't grow memory and the View loop without bound
+const (
+	DefaultMaxOutputLines = 5000
+	DefaultMaxHistory     = 1000
+)
+
+func orDefault(n, def int) int {
+	if n <= 0 {
+		return def
+	}
+	return n
+}
+
+// trimBuffers drops the oldest output and history past their caps. The
+// output cap never goes below the screen height, so the view stays full.
+func (m *Model) trimBuffers() {
+	limit := m.maxOutput
+	if limit < m.height {
+		limit = m.height
+	}
+	if drop := len(m.output) - limit; limit > 0 && drop > 0 {
+		m.output = m.output[drop:]
+		m.shiftSearch(drop)
+	}
+
+	if drop := len(m.history) - m.maxHistory; m.maxHistory > 0 && drop > 0 {
+		m.history = m.history[drop:]
+		m.historyIdx -= drop
+		if m.historyIdx < 0 {
+			m.historyIdx = 0
+		}
+	}
+}
+
+// maxBuildRetries bounds how many times the player can retry a failed build
+const maxBuildRetries = 3
+
+// Options holds the player-selectable settings passed in from the command line
+type Options struct {
+	HardMode bool
+	Bell     bool
+	Demo     bool // Attract mode: auto-play solutions and loop, without saving
+	Numbers  bool // Prefix command output with line numbers
+	// WindowTitle sets the terminal title to the quest and directory.
+	// Off for terminals that print the escape sequence literally.
+	WindowTitle bool
+	SkipIntro   bool   // Don't show the first-run tutorial
+	AllowShell  bool   // Enable the !shell escape hatch
+	Debug       bool   // Show expected vs actual content when file checks fail
+	Layout      string // LayoutBottom (default) or LayoutSide
+	// AutosaveInterval saves progress periodically while playing; zero disables
+	AutosaveInterval time.Duration
+	// AutoHintAfter shows the quest hint for free after this many commands
+	// without progress. Zero disables; Hard Mode never gets it.
+	AutoHintAfter int
+	// Scrollback and history caps; zero means the defaults
+	MaxOutputLines int
+	MaxHistory     int
+	// A11y renders for screen readers: plain labeled sections instead of
+	// boxes and colors, no blinking cursor, and spoken-style announcements
+	A11y bool
+	// Prompt is the input prompt template (see expandPrompt); empty means DefaultPrompt
+	Prompt string
+	// OnProgress receives a summary after every update, for the progress endpoint
+	OnProgress func(game.Progress)
+	// OnQuestComplete reports each completed quest with the player's total XP,
+	// for the webhook. It runs off the UI goroutine; errors are shown once.
+	OnQuestComplete func(questID, xp int) error
+	// Transcript saves the scrollback as a plain text file on exit
+	Transcript bool
+	// Recap replays the last few saved commands and the objective on resume
+	Recap bool
+	// SplitOutput shows a command's stderr after its stdout, marked, instead
+	// of interleaved in the order written
+	SplitOutput bool
+	// FastValidate reuses path check results while the paths' mtimes are unchanged
+	FastValidate bool
+	// Profile names the player on the leaderboard; empty means "player"
+	Profile string
+	// VerboseSetup shows each setup command and its output, marked [setup];
+	// otherwise only failed setup steps are reported
+	VerboseSetup bool
+	// Difficulty tunes hints, suggestions, XP and the command guard; the zero
+	// value means normal. The HardMode option plays the hard level instead.
+	Difficulty game.Difficulty
+}
+
+func NewModel(quests []game.Quest, manager *docker.Manager, state game.GameState, startQuestID int, opts Options) Model {
+	initialText := T("init.title")
 	if len(quests) > 0 {
-		initialText = "Loading content..."
+		initialText = T("init.loading")
 	}
 
-	// Ensure startQuestID is valid
+	// Ensure startQuestID is valid. len(quests) is a finished game.
 	if startQuestID < 0 {
 		startQuestID = 0
 	}
-	if startQuestID >= len(quests) {
+	if startQuestID > len(quests) {
 		startQuestID = len(quests) - 1
 	}
 
-	return Model{
+	// Hard Mode is the hard level, so the header names what is actually played
+	difficulty := cmp.Or(opts.Difficulty, game.Difficulties[game.DifficultyNormal])
+	if opts.HardMode {
+		difficulty = game.Difficulties[game.DifficultyHard]
+	}
+
+	m := Model{
 		quests:          quests,
 		manager:         manager,
+		state:           state,
 		output:          []string{initialText},
 		glitchText:      "<'.'> ...",
 		currentQuestIdx: startQuestID,
-		history:         []string{},
-		historyIdx:      0,
-		hardMode:        hardMode,
+		history:         slices.Clone(state.History),
+		historyIdx:      len(state.History),
+		hardMode:        difficulty.HardMode,
+		difficulty:      difficulty,
+		bell:            opts.Bell,
+		demo:            opts.Demo,
+		lineNumbers:     opts.Numbers,
+		windowTitle:     opts.WindowTitle,
+		onProgress:      opts.OnProgress,
+		onComplete:      opts.OnQuestComplete,
+		transcript:      opts.Transcript,
+		splitOutput:     opts.SplitOutput,
+		recap:           opts.Recap,
+		profile:         cmp.Or(opts.Profile, "player"),
+		allowShell:      opts.AllowShell,
+		debug:           opts.Debug,
+		verboseSetup:    opts.VerboseSetup,
+		layout:          opts.Layout,
+		a11y:            opts.A11y,
+		prompt:          cmp.Or(opts.Prompt, DefaultPrompt),
+		autosaveEvery:   opts.AutosaveInterval,
+		autoHintAfter:   opts.AutoHintAfter,
+		maxOutput:       orDefault(opts.MaxOutputLines, DefaultMaxOutputLines),
+		maxHistory:      orDefault(opts.MaxHistory, DefaultMaxHistory),
+		onboarding:      !state.SeenOnboarding && !opts.SkipIntro && !opts.Demo,
+		newQuests:       state.NewQuestCount(len(quests)),
 	}
+	if opts.FastValidate {
+		m.checkCache = game.NewValidationCache()
+	}
+	return m
 }
 
 func (m Model) Init() tea.Cmd {
+	// Warn about low disk space before the build has a chance to fail halfway
+	checkDisk := func() tea.Msg {
+		return diskSpaceMsg{err: m.manager.CheckDiskSpace()}
+	}
+
 	// Start by building/starting the container async
-	return func() tea.Msg {
-		m.output = append(m.output, "Building simulation environment... (this may take a moment)")
+	return tea.Sequence(checkDisk, func() tea.Msg {
+		m.output = append(m.output, T("env.building"))
 		if err := m.manager.BuildImage(); err != nil {
 			return containerReadyMsg{err: err}
 		}
@@ -78,55 +302,255 @@ func (m Model) Init() tea.Cmd {
 			return containerReadyMsg{err: err}
 		}
 		return containerReadyMsg{err: nil}
-	}
+	})
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.update(msg)
+	next, ok := updated.(Model)
+	if !ok {
+		return updated, cmd
+	}
+
+	next.trimBuffers()
+
+	// Feed the --serve dashboard endpoint
+	if next.onProgress != nil {
+		next.onProgress(game.SummarizeProgress(next.quests, next.state, next.currentQuestIdx))
+	}
+
+	if !next.windowTitle || next.manager == nil {
+		return next, cmd
+	}
+
+	// Sync the window title after quest transitions and directory changes
+	title := next.titleText()
+	if title == next.lastTitle {
+		return next, cmd
+	}
+	next.lastTitle = title
+	return next, tea.Batch(cmd, tea.SetWindowTitle(title))
+}
+
+// titleText is the terminal window title for the current quest and directory
+func (m Model) titleText() string {
+	dir := m.displayDir()
+	if m.currentQuestIdx >= len(m.quests) {
+		return T("title.done", dir)
+	}
+	q := m.quests[m.currentQuestIdx]
+	return T("title.quest", q.ID, q.Title, dir)
+}
+
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
+		// A resize during a running command lands here as usual. While !shell
+		// has the terminal the size is re-read once it returns, so the redraw
+		// afterwards uses the new dimensions either way.
 		m.width = msg.Width
 		m.height = msg.Height
 		m.viewportReady = true
 		return m, nil
 
+	case diskSpaceMsg:
+		if msg.err != nil {
+			m.output = append(m.output, T("env.disk_warning", msg.err))
+		}
+		return m, nil
+
 	case containerReadyMsg:
 		if msg.err != nil {
-			m.output = append(m.output, fmt.Sprintf("Error starting environment: %v", msg.err))
-			return m, tea.Quit
+			m.output = append(m.output, T("env.error", msg.err))
+			if m.buildRetries >= maxBuildRetries {
+				m.output = append(m.output, T("env.retry_exhausted"))
+				return m, tea.Quit
+			}
+			m.buildFailed = true
+			m.output = append(m.output, T("env.retry_prompt"))
+			return m, nil
 		}
 		m.ready = true
 		m.gameStarted = true
-		m.output = append(m.output, "Environment ready.")
+		m.gameStart = time.Now()
+		m.fullRun = m.currentQuestIdx == 0
+		m.output = append(m.output, T("env.ready"))
+		if m.manager.NetRawUnavailable {
+			m.output = append(m.output, T("env.no_ping"))
+		}
+		if m.manager.DynamicIPs {
+			m.output = append(m.output, T("env.dynamic_ips"))
+		}
+		if m.manager.ShellFallback != "" {
+			m.output = append(m.output, T("env.shell_fallback", m.manager.ShellFallback))
+		}
+		for _, restriction := range m.manager.HardeningRestrictions() {
+			m.output = append(m.output, T("env.harden_warning", m.hardeningWarning(restriction)))
+		}
+		if m.manager.NoRoot {
+			m.output = append(m.output, T("env.no_root", rootQuestIDs(m.quests)))
+		}
+		if m.manager.HomeRepaired {
+			m.output = append(m.output, T("env.home_repaired"))
+		} else if m.manager.HomeRepairErr != nil {
+			m.output = append(m.output, T("env.home_repair_failed", m.manager.HomeRepairErr))
+		}
 
 		// Restore environment state (users, permissions) if needed
 		if err := m.manager.RestoreEnvironment(m.currentQuestIdx); err != nil {
-			m.output = append(m.output, fmt.Sprintf("Warning: State restoration issue: %v", err))
+			m.output = append(m.output, T("env.restore_warning", err))
 		}
 
 		// Display loaded game message if we are not at 0
-		if m.currentQuestIdx > 0 {
-			m.output = append(m.output, fmt.Sprintf("Resuming from Quest %d...", m.quests[m.currentQuestIdx].ID))
+		if m.newQuests > 0 {
+			m.output = append(m.output, T("quest.new_available", m.newQuests))
+		}
+		// A finished save resumes past the last quest, which startQuest reports
+		if m.currentQuestIdx > 0 && m.currentQuestIdx < len(m.quests) {
+			m.output = append(m.output, T("quest.resuming", m.quests[m.currentQuestIdx].ID))
+
+			// Pick up in the directory the player was last saved in, if it still exists
+			if m.state.CurrentDir != "" && m.state.CurrentQuestID == m.currentQuestIdx {
+				m.manager.CurrentDir = m.state.CurrentDir
+				m.manager.RefreshCurrentDir()
+			}
+			m.showRecap()
 		}
 		m.output = append(m.output, "")
 
 		// Load quest intro
 		if len(m.quests) > 0 {
 			// Start with the current quest index (which might be loaded or flagged)
-			return m, m.startQuest(m.currentQuestIdx)
+			cmds := []tea.Cmd{m.startQuest(m.currentQuestIdx), m.tick(), m.scheduleAutosave()}
+			if m.demo {
+				cmds = append(cmds, demoTick(demoCommandDelay))
+			}
+			return m, tea.Batch(cmds...)
+		}
+		return m, tea.Batch(m.tick(), m.scheduleAutosave())
+
+	case shutdownMsg:
+		return m.finishShutdown(msg)
+
+	case autosaveMsg:
+		m.saveState()
+		return m, m.scheduleAutosave()
+
+	case inventoryMsg:
+		if msg.err != nil {
+			m.output = append(m.output, T("cmd.error", msg.err))
+			return m, nil
+		}
+		m.output = append(m.output, formatInventory(msg.output, m.manager.HomeDir())...)
+		return m, nil
+
+	case homeTreeMsg:
+		if msg.err != nil {
+			m.output = append(m.output, T("cmd.error", msg.err))
+			return m, nil
+		}
+		m.output = append(m.output, formatTree(msg.output)...)
+		return m, nil
+
+	case usageMsg:
+		if msg.err != nil {
+			m.output = append(m.output, T("cmd.error", msg.err))
+			return m, nil
+		}
+		m.output = append(m.output, formatUsage(msg.stats)...)
+		return m, nil
+
+	case processListMsg:
+		m.demoWaiting = false
+		if msg.err != nil {
+			m.output = append(m.output, T("cmd.error", msg.err))
+			return m, nil
+		}
+		var relevant []string
+		if m.currentQuestIdx < len(m.quests) {
+			relevant = m.quests[m.currentQuestIdx].RelevantProcesses
+		}
+		m.output = append(m.output, highlightProcesses(msg.output, relevant)...)
+		return m, nil
+
+	case containerRestartMsg:
+		if msg.err != nil {
+			m.output = append(m.output, T("env.restart_error", msg.err))
+		}
+		return m, nil
+
+	case setupMsg:
+		if msg.scenarioErr != nil {
+			m.output = append(m.output, T("env.scenario_error", msg.scenarioErr))
+		}
+		m.reportSetup(msg.results)
+		return m, nil
+
+	case demoTickMsg:
+		return m.updateDemo()
+
+	case demoRestartMsg:
+		if msg.err != nil {
+			m.output = append(m.output, T("env.restart_error", msg.err))
+			return m, nil
+		}
+		m.output = []string{T("demo.restart")}
+		m.state = game.GameState{}
+		m.history = []string{}
+		m.historyIdx = 0
+		m.gameStart = time.Now()
+		return m, tea.Batch(m.startQuest(0), demoTick(demoCommandDelay))
+
+	case tickMsg:
+		// Re-render once a second so the timer stays current
+		return m, m.tick()
+
+	case timeLimitMsg:
+		// Stale once the quest was finished, retried or restarted
+		if msg.gen != m.pollGen || m.currentQuestIdx >= len(m.quests) || m.outOfAttempts {
+			return m, nil
 		}
+		return m.expireChallenge()
+
+	case pollMsg:
+		// A newer quest start (or completion) ends this poll chain
+		if msg.gen != m.pollGen || m.currentQuestIdx >= len(m.quests) {
+			return m, nil
+		}
+		return m, tea.Batch(m.checkWinCondition(), m.schedulePoll(m.quests[m.currentQuestIdx]))
+
+	case spinnerMsg:
+		return m.updateSpinner(msg)
+
+	case bellDoneMsg:
+		m.ringing = false
 		return m, nil
 
 	case commandResultMsg:
+		m.running = nil
+		if errors.Is(msg.err, context.Canceled) {
+			m.demoWaiting = false
+			m.output = append(m.output, "^C", T("command.cancelled"))
+			if msg.err != context.Canceled {
+				// The runtime gave up, but the command couldn't be stopped
+				m.output = append(m.output, T("command.cancel_failed", msg.err))
+			}
+			return m, nil
+		}
+		if m.wantsSudoPassword(msg) {
+			m.sudo = &sudoPrompt{command: msg.command}
+			return m, nil
+		}
+		m.demoWaiting = false
+		m.stuckCommands++
 		// Display output
+		m.output = append(m.output, m.commandLines(msg)...)
 		if msg.err != nil {
-			m.output = append(m.output, fmt.Sprintf("Error: %v", msg.err))
-		} else {
-			lines := strings.Split(msg.output, "\n")
-			// Filter out empty last line often caused by split
-			if len(lines) > 0 && lines[len(lines)-1] == "" {
-				lines = lines[:len(lines)-1]
+			if m.bell {
+				bell := m.ringBell()
+				return m, tea.Batch(bell, m.checkWinCondition())
 			}
-			m.output = append(m.output, lines...)
+		} else {
 			m.lastOutput = msg.output
 		}
 
@@ -134,78 +558,83 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, m.checkWinCondition()
 
 	case tea.KeyMsg:
-		if !m.ready {
-			if msg.Type == tea.KeyCtrlC || msg.Type == tea.KeyEsc {
-				return m, tea.Quit
+		if m.shuttingDown {
+			// Only a second Ctrl+C gets through, to skip waiting on cleanup
+			if msg.Type == tea.KeyCtrlC {
+				return m.shutdown()
 			}
 			return m, nil
 		}
 
-		switch msg.Type {
-		case tea.KeyCtrlC, tea.KeyEsc:
-			// Cleanup on exit
-			// Ideally we would do this in a defer or cleanup hook, but bubbletea doesn't have a global cleanup easily accessible here
-			// For now, we rely on the container being --rm or stopped
-			m.manager.StopContainer()
-			return m, tea.Quit
-		case tea.KeyCtrlH:
-			// Toggle Hard Mode
-			m.hardMode = !m.hardMode
-			return m, nil
-		case tea.KeyEnter:
-			cmdText := strings.TrimSpace(m.input)
+		if m.onboarding {
+			return m.updateOnboarding(msg)
+		}
 
-			// Calculate display path for history
-			displayPath := m.manager.CurrentDir
-			if strings.HasPrefix(displayPath, "/home/player") {
-				displayPath = strings.Replace(displayPath, "/home/player", "~", 1)
+		if !m.ready {
+			if msg.Type == tea.KeyCtrlC || msg.Type == tea.KeyEsc {
+				return m, tea.Quit
+			}
+			if m.buildFailed {
+				// Any key other than R gives up
+				if msg.Type == tea.KeyRunes && strings.EqualFold(string(msg.Runes), "r") {
+					m.buildFailed = false
+					m.buildRetries++
+					m.output = append(m.output, T("env.retrying", m.buildRetries, maxBuildRetries))
+					return m, m.Init()
+				}
+				return m, tea.Quit
 			}
+			return m, nil
+		}
 
-			m.output = append(m.output, fmt.Sprintf("player@goblin:%s$ %s", displayPath, cmdText))
-			m.input = ""
+		if m.dialog != nil {
+			return m.updateConfirm(msg)
+		}
 
-			// Add to history if not empty
-			if cmdText != "" {
-				m.history = append(m.history, cmdText)
-				m.historyIdx = len(m.history) // Reset index to end
-			}
+		if m.sudo != nil {
+			return m.updateSudo(msg)
+		}
 
-			if cmdText == "exit" {
-				m.output = append(m.output, "Shutting down simulation...")
-				return m, tea.Sequence(
-					tea.Tick(500*time.Millisecond, func(t time.Time) tea.Msg {
-						return tea.Quit()
-					}),
-					func() tea.Msg {
-						m.manager.StopContainer()
-						return nil
-					},
-				)
-			}
+		if m.stdin != nil {
+			return m.updateStdin(msg)
+		}
 
-			if cmdText == "" {
-				return m, nil
+		if m.running != nil {
+			if updated, cmd, handled := m.updateRunning(msg); handled {
+				return updated, cmd
 			}
+		}
 
-			// Execute command async
-			cmd := cmdText // capture for closure
+		if m.menuOpen {
+			return m.updateMenu(msg)
+		}
 
-			if cmd == "help" {
-				m.output = append(m.output, "To quit the game, type 'exit'.")
-				return m, nil
-			}
+		// The demo drives the prompt itself; only quitting is allowed
+		if m.demo && msg.Type != tea.KeyCtrlC && msg.Type != tea.KeyEsc {
+			return m, nil
+		}
 
-			if cmd == "history" {
-				for i, h := range m.history {
-					m.output = append(m.output, fmt.Sprintf("%5d  %s", i+1, h))
-				}
-				return m, nil
-			}
+		if m.search != nil {
+			return m.updateSearch(msg)
+		}
 
-			return m, func() tea.Msg {
-				out, err := m.manager.ExecuteCommand(cmd)
-				return commandResultMsg{output: out, err: err}
-			}
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m.shutdown()
+		case tea.KeyCtrlF:
+			// Search the scrollback; also '/' on an empty prompt (below)
+			m.search = &scrollSearch{typing: true}
+			return m, nil
+		case tea.KeyCtrlP:
+			// Open the pause menu
+			m.menuOpen = true
+			m.menuIdx = menuResume
+			return m, nil
+		case tea.KeyCtrlH:
+			m.toggleHardMode()
+			return m, nil
+		case tea.KeyEnter:
+			return m.submitInput()
 
 		case tea.KeyUp:
 			if m.historyIdx > 0 {
@@ -228,13 +657,74 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.input = m.input[:len(m.input)-1]
 			}
 		case tea.KeyRunes:
-			m.input += string(msg.Runes)
+			// '/' starts a search only on an empty prompt, so absolute paths can still be typed
+			if m.input == "" && string(msg.Runes) == "/" {
+				m.search = &scrollSearch{typing: true}
+				return m, nil
+			}
+			// A multi-line paste still has to fit on the single prompt line
+			m.input += strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(string(msg.Runes))
 		case tea.KeySpace:
 			m.input += " "
 		}
 
+	case shellExitMsg:
+		if msg.err != nil {
+			m.output = append(m.output, T("shell.error", msg.err))
+		}
+		m.output = append(m.output, T("shell.returned"))
+		// The shell may have removed or left the tracked directory, or finished the quest
+		manager := m.manager
+		return m, tea.Sequence(func() tea.Msg {
+			manager.RefreshCurrentDir()
+			return nil
+		}, m.checkWinCondition())
+
 	case questCheckMsg:
-		if msg.passed {
+		if msg.trace != "" {
+			m.output = append(m.output, lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render(msg.trace))
+		}
+		if msg.containerGone && msg.idx == m.currentQuestIdx {
+			return m.containerStopped(), nil
+		}
+
+		// Nudge the player when a check fails for a new, specific reason
+		if !msg.passed && msg.idx == m.currentQuestIdx && msg.reason != m.lastReason {
+			m.lastReason = msg.reason
+			// Not having written the script yet isn't worth a nudge
+			if msg.reason != "" && msg.reason != game.ReasonScriptMissing {
+				m.output = append(m.output, T("check."+msg.reason))
+			}
+		}
+
+		// So close: Glitch says what's off, without giving the answer away
+		if msg.nearMiss != "" && msg.idx == m.currentQuestIdx {
+			m.glitchText = T("nearmiss." + msg.nearMiss)
+		}
+
+		if !msg.passed && msg.retried && msg.idx == m.currentQuestIdx {
+			m.output = append(m.output, T("retry.not_yet"))
+		}
+
+		if msg.idx == m.currentQuestIdx {
+			m.checksDone = msg.done
+		}
+		if !msg.passed && msg.idx == m.currentQuestIdx {
+			m.maybeAutoHint()
+		}
+
+		if !msg.passed && msg.idx == m.currentQuestIdx && msg.diff != nil {
+			if rendered := renderDiff(msg.target, msg.diff); strings.Join(rendered, "\n") != m.lastDiff {
+				m.lastDiff = strings.Join(rendered, "\n")
+				m.output = append(m.output, rendered...)
+			}
+		}
+
+		// A poll and a command check can both pass; only the first one counts
+		if msg.passed && msg.idx == m.currentQuestIdx {
+			m.lastReason = ""
+			m.stuckCommands = 0
+			m.autoHinted = false
 			// Quest Complete Logic
 
 			// Advance quest
@@ -243,63 +733,581 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			// Quest complete notification remains in history
 			headerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Bold(true)
-			m.output = append(m.output, headerStyle.Render(fmt.Sprintf(">>> QUEST COMPLETE! +%d XP <<<", completedQuest.XPReward)))
+			m.output = append(m.output, m.completionLine(completedQuest))
+			for _, i := range msg.bonuses {
+				b := completedQuest.OptionalObjectives[i]
+				if bonus := m.difficulty.XP(b.XPBonus); m.state.AwardBonus(completedQuest.ID, i, bonus) {
+					m.output = append(m.output, headerStyle.Render(T("quest.bonus", bonus, b.Description)))
+				}
+			}
 
 			// Success text in history
-			successLines := strings.Split(completedQuest.SuccessText, "\n")
-			m.output = append(m.output, successLines...)
+			m.state.AddLore(completedQuest.SuccessText)
+			for _, line := range strings.Split(completedQuest.SuccessText, "\n") {
+				m.output = append(m.output, styleStoryLine(line))
+			}
 			m.output = append(m.output, "")
 
-			nextIdx := msg.idx + 1
+			nextIdx := m.skipRootQuests(msg.idx + 1)
 
 			// Save Progress
-			_ = game.SaveState(game.GameState{CurrentQuestID: nextIdx})
+			m.state.CurrentQuestID = nextIdx
+			m.state.AwardXP(m.difficulty.XP(completedQuest.XPReward))
+			if !m.demo {
+				// The demo never touches the player's save or records
+				questTime := time.Since(m.questStart)
+				if m.state.RecordQuestTime(completedQuest.ID, questTime) {
+					m.output = append(m.output, headerStyle.Render(T("timer.quest_best", game.FormatDuration(questTime))))
+				}
+				if nextIdx >= len(m.quests) && m.fullRun {
+					totalTime := time.Since(m.gameStart)
+					if m.state.RecordTotalTime(totalTime) {
+						m.output = append(m.output, headerStyle.Render(T("timer.total_best", game.FormatDuration(totalTime))))
+					}
+					m.recordRun(totalTime)
+				}
+				m.saveState()
+			}
+			report := m.reportCompletion(completedQuest.ID)
 
 			if nextIdx < len(m.quests) {
-				q := m.quests[nextIdx]
+				q := m.quests[nextIdx].WithSecret(m.newSecret(m.quests[nextIdx].ID))
 
 				// Show next quest info in Glitch box
-				m.glitchText = fmt.Sprintf("(Next: %s)\n%s", q.Title, q.IntroText)
+				m.say(T("quest.next", q.Title, q.IntroText))
 				m.currentQuestIdx = nextIdx
-				m.output = append(m.output, fmt.Sprintf("--- QUEST %d: %s ---", q.ID, q.Title))
+				m.questStart = time.Now()
+				m.attempt = 1
+				m.outOfAttempts = false
+				m.checksDone = 0
+				m.output = append(m.output, m.bannerLines(q.Banner)...)
+				m.output = append(m.output, T("quest.header", q.ID, q.Title))
+				m.announceObjective()
+				m.loadDemo(q)
+				m.suggestCommand(q)
+				m.pollGen++
 
 				// Run setup commands for the new quest
-				return m, m.performQuestSetup(q)
+				setup := tea.Batch(m.performQuestSetup(q), m.schedulePoll(q), m.scheduleTimeLimit(q))
+				if q.ResetsHome() {
+					m.output = append(m.output, T("env.resetting_home"))
+					setup = tea.Sequence(m.resetHome(), setup)
+				}
+				if q.RestartsContainer() {
+					m.output = append(m.output, T("env.restarting"))
+					setup = tea.Sequence(m.restartContainer(), setup)
+				} else if q.RestartPlayer {
+					m.output = append(m.output, T("env.restarting"))
+					setup = tea.Sequence(m.restartPlayer(), setup)
+				}
+				if m.bell {
+					bell := m.ringBell()
+					return m, tea.Batch(bell, setup, report)
+				}
+				return m, tea.Batch(setup, report)
 
 			} else {
-				m.glitchText = "You did it! All systems normal. <^.^>"
+				m.say(T("quest.all_done"))
 				m.currentQuestIdx = nextIdx
+				m.pollGen++
+				if m.demo {
+					return m, m.restartDemo()
+				}
+				if m.bell {
+					bell := m.ringBell()
+					return m, tea.Batch(bell, report)
+				}
 			}
+			return m, report
+		}
+		return m, nil
+
+	case sudoResultMsg:
+		return m.sudoResult(msg)
+
+	case webhookMsg:
+		if msg.err != nil {
+			return m.webhookFailed(msg.err), nil
 		}
 		return m, nil
 	}
 	return m, nil
 }
 
+// toggleHardMode switches between the hard level and the saved one (normal
+// if that was hard too), so XP, hints, the guard, the timeout and the header
+// all follow Hard Mode. The saved choice is left alone.
+func (m *Model) toggleHardMode() {
+	d := game.Difficulties[game.DifficultyHard]
+	if m.hardMode {
+		saved, err := game.LookupDifficulty(m.state.Difficulty)
+		if err != nil || saved.HardMode {
+			saved = game.Difficulties[game.DifficultyNormal]
+		}
+		d = saved
+	}
+	m.difficulty = d
+	m.hardMode = d.HardMode
+	if m.manager != nil {
+		m.manager.CommandTimeout = d.CommandTimeout
+	}
+}
+
+// submitInput runs whatever is on the prompt line, either a built-in or a container command
+func (m Model) submitInput() (tea.Model, tea.Cmd) {
+	cmdText := strings.TrimSpace(m.input)
+
+	m.output = append(m.output, m.promptString()+m.shown(cmdText))
+	m.input = ""
+	// A secret line stays out of history, and a ! in it is just a character
+	secret := m.masked
+	m.masked = false
+
+	// Expand !! and !n from history, echoing what will actually run
+	expanded, ok, err := expandHistory(cmdText, m.history)
+	if secret {
+		ok, err = false, nil
+	}
+	if err != nil {
+		m.output = append(m.output, err.Error())
+		return m, nil
+	}
+	if ok {
+		cmdText = expanded
+		m.output = append(m.output, cmdText)
+	}
+
+	// Add to history if not empty
+	if cmdText != "" && !secret {
+		m.history = append(m.history, cmdText)
+		m.historyIdx = len(m.history) // Reset index to end
+	}
+
+	if cmdText == "exit" {
+		return m.shutdown()
+	}
+
+	if cmdText == "" {
+		return m, nil
+	}
+
+	// Execute command async
+	cmd := cmdText // capture for closure
+
+	if cmd == "help" {
+		m.output = append(m.output, T("help.exit"))
+		m.output = append(m.output, T("help.search"))
+		m.output = append(m.output, T("help.map"))
+		m.output = append(m.output, T("help.usage"))
+		m.output = append(m.output, T("help.transcript"))
+		m.output = append(m.output, T("help.checklist"))
+		m.output = append(m.output, T("help.restart"))
+		m.output = append(m.output, T("help.lore"))
+		m.output = append(m.output, T("help.leaderboard"))
+		m.output = append(m.output, T("help.retry"))
+		m.output = append(m.output, T("help.ask"))
+		m.output = append(m.output, T("help.secret"))
+		return m, nil
+	}
+
+	if cmd == "ask" || strings.HasPrefix(cmd, "ask ") {
+		m.ask(strings.TrimSpace(strings.TrimPrefix(cmd, "ask")))
+		return m, nil
+	}
+
+	if cmd == "secret" {
+		m.masked = true
+		m.output = append(m.output, T("secret.on"))
+		return m, nil
+	}
+
+	if cmd == "retry" {
+		return m, m.retryCheck()
+	}
+
+	if cmd == "lore" {
+		m.showLore()
+		return m, nil
+	}
+
+	if cmd == "leaderboard" {
+		entries, err := game.LoadLeaderboard()
+		if err != nil {
+			m.output = append(m.output, T("leaderboard.error", err))
+			return m, nil
+		}
+		m.showLeaderboard(entries, 0)
+		return m, nil
+	}
+
+	if cmd == "!shell" {
+		if !m.allowShell {
+			m.output = append(m.output, T("shell.disabled"))
+			return m, nil
+		}
+		m.output = append(m.output, T("shell.entering"))
+		return m, tea.Exec(resizingExec{m.manager.ShellCommand()}, func(err error) tea.Msg {
+			return shellExitMsg{err: err}
+		})
+	}
+
+	if cmd == "redo-setup" {
+		if m.currentQuestIdx >= len(m.quests) {
+			return m, nil
+		}
+		// Same secret as the running quest, and no new inventory mark,
+		// so the player's own changes are left alone
+		q := m.quests[m.currentQuestIdx]
+		q = q.WithSecret(m.secrets[q.ID])
+		if len(q.SetupCommands) == 0 && len(q.SetupCommandsRoot) == 0 {
+			m.output = append(m.output, T("setup.none"))
+			return m, nil
+		}
+		m.output = append(m.output, T("setup.redo"))
+		for _, c := range q.SetupCommandsRoot {
+			m.output = append(m.output, T("setup.root_command", c))
+		}
+		for _, c := range q.SetupCommands {
+			m.output = append(m.output, "  "+c)
+		}
+		return m, func() tea.Msg {
+			return setupMsg{results: m.runSetupCommands(q)}
+		}
+	}
+
+	if cmd == "whereami" {
+		m.output = append(m.output, T("whereami.full", m.manager.CurrentDir))
+		m.output = append(m.output, T("whereami.prompt", m.displayDir()))
+		return m, nil
+	}
+
+	if cmd == "hint" {
+		if m.currentQuestIdx >= len(m.quests) {
+			return m, nil
+		}
+		q := m.quests[m.currentQuestIdx]
+		alreadyBought := m.state.HintUnlocked(q.ID)
+		if err := m.state.BuyHint(q.ID, m.difficulty.HintCost); err != nil {
+			m.output = append(m.output, T("hint.denied", m.difficulty.HintCost, m.state.Balance()))
+			return m, nil
+		}
+		if !alreadyBought && m.difficulty.HintCost > 0 {
+			// Persist right away so quitting doesn't refund the hint
+			m.saveState()
+			m.output = append(m.output, T("hint.bought", m.difficulty.HintCost, m.state.Balance()))
+		}
+		hint := q.Hint
+		if hint == "" {
+			hint = q.Objective
+		}
+		m.output = append(m.output, T("hint.text", hint))
+		return m, nil
+	}
+
+	if cmd == "checklist" {
+		if m.currentQuestIdx >= len(m.quests) {
+			return m, nil
+		}
+		done, total := m.checklistProgress()
+		m.output = append(m.output, T("checklist.progress", done, total, checklistPercent(done, total)))
+		return m, nil
+	}
+
+	if cmd == "restart" {
+		return m.recoverContainer()
+	}
+
+	if cmd == "progress" {
+		m.output = append(m.output, T("progress.header"))
+		for _, c := range game.ProgressByCategory(m.quests, m.currentQuestIdx) {
+			m.output = append(m.output, T("progress.category", c.Name, c.Done, c.Total))
+		}
+		return m, nil
+	}
+
+	if cmd == "inventory" {
+		return m, func() tea.Msg {
+			out, err := m.manager.Inventory()
+			return inventoryMsg{output: out, err: err}
+		}
+	}
+
+	if cmd == "map" {
+		return m, func() tea.Msg {
+			out, err := m.manager.HomeTree()
+			return homeTreeMsg{output: out, err: err}
+		}
+	}
+
+	if cmd == "usage" {
+		return m, func() tea.Msg {
+			stats, err := m.manager.Stats()
+			return usageMsg{stats: stats, err: err}
+		}
+	}
+
+	if cmd == "numbers" {
+		m.lineNumbers = !m.lineNumbers
+		if m.lineNumbers {
+			m.output = append(m.output, T("numbers.on"))
+		} else {
+			m.output = append(m.output, T("numbers.off"))
+		}
+		return m, nil
+	}
+
+	if cmd == "processes" {
+		// Highlighting happens when the result arrives
+		return m, func() tea.Msg {
+			out, err := m.manager.ExecuteCommand("ps aux")
+			return processListMsg{output: out, err: err}
+		}
+	}
+
+	if name, ok := strings.CutPrefix(cmd, "save-transcript"); ok && (name == "" || name[0] == ' ') {
+		m.saveTranscript(strings.TrimSpace(name))
+		return m, nil
+	}
+
+	if cmd == "history" {
+		for i, h := range m.history {
+			m.output = append(m.output, fmt.Sprintf("%5d  %s", i+1, h))
+		}
+		return m, nil
+	}
+
+	// Only a player at the keyboard can answer the guard's question
+	if m.difficulty.Guard && !m.demo && !m.scripted && wipesHome(cmd, m.manager.HomeDir(), m.manager.CurrentDir) {
+		return m.guardCommand(cmd, secret), nil
+	}
+
+	// Nobody types into the demo or a script, so those get EOF as before
+	if readsStdin(cmd) && !m.demo && !m.scripted {
+		m.stdin = &stdinCapture{command: cmd}
+		m.masked = readsSecret(cmd)
+		m.output = append(m.output, lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render(T("stdin.hint")))
+		return m, nil
+	}
+
+	run := m.runCommand(cmd, secret)
+	return m, run
+}
+
 func (m *Model) startQuest(idx int) tea.Cmd {
+	idx = m.skipRootQuests(idx)
 	if idx >= len(m.quests) {
-		m.glitchText = "You did it! All systems normal. <^.^>"
+		m.say(T("quest.all_done"))
 		return nil
 	}
 	m.currentQuestIdx = idx
-	q := m.quests[idx]
-	m.glitchText = q.IntroText
-	m.output = append(m.output, fmt.Sprintf("--- QUEST %d: %s ---", q.ID, q.Title))
+	m.questStart = time.Now()
+	m.attempt = 1
+	m.outOfAttempts = false
+	m.checksDone = 0
+	// A reset re-rolls the secret, so setup writes a fresh token
+	q := m.quests[idx].WithSecret(m.newSecret(m.quests[idx].ID))
+	m.say(q.IntroText)
+	m.output = append(m.output, m.bannerLines(q.Banner)...)
+	m.output = append(m.output, T("quest.header", q.ID, q.Title))
+	m.announceObjective()
+	m.loadDemo(q)
+	m.suggestCommand(q)
+	m.pollGen++
+	m.lastReason = ""
+	m.stuckCommands = 0
+	m.autoHinted = false
+
+	return tea.Batch(m.performQuestSetup(q), m.schedulePoll(q), m.scheduleTimeLimit(q))
+}
+
+// The header's progress bar is progressBarWidth cells, shown only on
+// terminals at least progressBarMinWidth wide so the objective keeps its room
+const (
+	progressBarWidth    = 10
+	progressBarMinWidth = 100
+)
 
-	return m.performQuestSetup(q)
+// progressMeter shows how far through the content the player is, by quest weight
+func (m Model) progressMeter() string {
+	percent := game.WeightedProgress(m.quests, m.currentQuestIdx) * 100
+	if m.width < progressBarMinWidth {
+		return fmt.Sprintf("%d%%", int(percent))
+	}
+	return fmt.Sprintf("%s %d%%", meterBar(percent, progressBarWidth), int(percent))
 }
 
-func (m Model) performQuestSetup(q game.Quest) tea.Cmd {
-	if len(q.SetupCommands) == 0 {
+// skipRootQuests moves past quests that need root when safe mode can't run them,
+// announcing each, and returns the index of the next playable quest
+func (m *Model) skipRootQuests(idx int) int {
+	for idx < len(m.quests) && m.quests[idx].NeedsRoot() && m.manager != nil && m.manager.NoRoot {
+		m.output = append(m.output, T("quest.skipped_no_root", m.quests[idx].ID, m.quests[idx].Title))
+		idx++
+	}
+	return idx
+}
+
+// rootQuestIDs lists the IDs of quests safe mode skips, e.g. "10, 11, 18"
+func rootQuestIDs(quests []game.Quest) string {
+	return questIDs(quests, game.Quest.NeedsRoot)
+}
+
+// questIDs lists the IDs of the quests matching pred, or "none"
+func questIDs(quests []game.Quest, pred func(game.Quest) bool) string {
+	var ids []string
+	for _, q := range quests {
+		if pred(q) {
+			ids = append(ids, strconv.Itoa(q.ID))
+		}
+	}
+	if len(ids) == 0 {
+		return "none"
+	}
+	return strings.Join(ids, ", ")
+}
+
+// hardeningWarning says what a hardening restriction breaks, naming the
+// affected quests from the loaded pack
+func (m Model) hardeningWarning(restriction string) string {
+	switch restriction {
+	case docker.RestrictSudo:
+		return T("harden.sudo", rootQuestIDs(m.quests))
+	case docker.RestrictReadOnlyRoot:
+		return T("harden.read_only_root", m.manager.HomeDir())
+	case docker.RestrictPing:
+		// Quests with a fallback for missing NET_RAW are the ones that ping
+		return T("harden.ping", questIDs(m.quests, func(q game.Quest) bool { return q.NoPingWinCondition != nil }))
+	}
+	return restriction
+}
+
+// maybeAutoHint has Glitch offer the quest hint once the player seems stuck
+func (m *Model) maybeAutoHint() {
+	if m.autoHintAfter <= 0 || m.autoHinted || m.hardMode || m.demo {
+		return
+	}
+	if m.stuckCommands < m.autoHintAfter || m.currentQuestIdx >= len(m.quests) {
+		return
+	}
+	q := m.quests[m.currentQuestIdx]
+	hint := q.Hint
+	if hint == "" {
+		hint = q.Objective
+	}
+	m.autoHinted = true
+	m.say(T("hint.auto", hint))
+}
+
+// newSecret rolls the random token for a quest run and remembers it for checks
+func (m *Model) newSecret(questID int) string {
+	if m.secrets == nil {
+		m.secrets = make(map[int]string)
+	}
+	secret := game.NewSecret()
+	m.secrets[questID] = secret
+	return secret
+}
+
+// schedulePoll arms the next timed win condition check, if the quest wants one
+func (m Model) schedulePoll(q game.Quest) tea.Cmd {
+	// A script checks after each command and must not wait on timers
+	if q.PollIntervalSeconds <= 0 || m.scripted {
 		return nil
 	}
+	gen := m.pollGen
+	return tea.Tick(time.Duration(q.PollIntervalSeconds)*time.Second, func(time.Time) tea.Msg {
+		return pollMsg{gen: gen}
+	})
+}
+
+func (m Model) performQuestSetup(q game.Quest) tea.Cmd {
 	return func() tea.Msg {
-		for _, cmd := range q.SetupCommands {
-			// Run setup commands silently
-			// We use ExecuteValidation to run from root/home context as needed
-			_, _ = m.manager.ExecuteValidation(cmd)
+		// Swap in the quest's hosts, or back to the gateway, before setup touches them
+		var err error
+		if specs := m.scenarioFor(q); !slices.Equal(specs, m.manager.Scenario) {
+			err = m.manager.StartScenario(specs)
+		}
+		// Then put the player on the quest's host, or back on their own
+		err = errors.Join(err, m.manager.SetHost(q.Host))
+		results := m.runSetupCommands(q)
+		// Mark after setup so the inventory only shows what the player changed
+		_ = m.manager.MarkQuestStart()
+		return setupMsg{scenarioErr: err, results: results}
+	}
+}
+
+// scenarioFor maps a quest's hosts to containers; nil means the default gateway
+func (m Model) scenarioFor(q game.Quest) []docker.ContainerSpec {
+	var specs []docker.ContainerSpec
+	for _, host := range q.Containers {
+		specs = append(specs, docker.ContainerSpec{
+			Name:     m.manager.AuxContainerName(host.Name),
+			Hostname: host.Name,
+			Image:    host.Image,
+			IP:       host.IP,
+			Command:  host.Command,
+		})
+	}
+	return specs
+}
+
+// runSetupCommands runs a quest's setup commands, for reportSetup to show
+func (m Model) runSetupCommands(q game.Quest) []game.SetupResult {
+	return q.RunSetup(m.manager)
+}
+
+// reportSetup prints every setup command with its output when setup is
+// verbose, and otherwise only warns about the steps that failed, since a
+// failed step can leave the quest unwinnable
+func (m *Model) reportSetup(results []game.SetupResult) {
+	if !m.verboseSetup {
+		for _, r := range game.SetupFailures(results) {
+			m.output = append(m.output, T("setup.failed", r.Command, r.Err))
+		}
+		return
+	}
+	for _, r := range results {
+		command := T("setup.verbose_command", r.Command)
+		if r.Root {
+			command = T("setup.verbose_root_command", r.Command)
+		}
+		m.output = append(m.output, command)
+		for _, line := range strings.Split(strings.TrimRight(r.Output, "\n"), "\n") {
+			if line != "" {
+				m.output = append(m.output, T("setup.verbose_output", line))
+			}
+		}
+		if r.Err != nil {
+			m.output = append(m.output, T("setup.verbose_error", r.Err))
 		}
-		return nil
+	}
+}
+
+// restartContainer recreates the containers and restores progress-dependent state
+// so a quest starts from a pristine base
+func (m Model) restartContainer() tea.Cmd {
+	idx := m.currentQuestIdx
+	return func() tea.Msg {
+		if err := m.manager.StartContainer(); err != nil {
+			return containerRestartMsg{err: err}
+		}
+		return containerRestartMsg{err: m.manager.RestoreEnvironment(idx)}
+	}
+}
+
+// resetHome restores the player's home to the image's copy, for quests
+// whose reset policy asks for it
+func (m Model) resetHome() tea.Cmd {
+	return func() tea.Msg {
+		return containerRestartMsg{err: m.manager.ResetHome()}
+	}
+}
+
+// restartPlayer is restartContainer for the player container alone,
+// keeping the gateway up for quests that rely on a stable target host
+func (m Model) restartPlayer() tea.Cmd {
+	idx := m.currentQuestIdx
+	return func() tea.Msg {
+		return containerRestartMsg{err: m.manager.RestartPlayerOnly(idx)}
 	}
 }
 
@@ -309,6 +1317,7 @@ func (m *Model) checkWinCondition() tea.Cmd {
 	}
 
 	q := m.quests[m.currentQuestIdx]
+	q = q.WithSecret(m.secrets[q.ID])
 
 	return func() tea.Msg {
 		// Validating state often requires running another command
@@ -317,80 +1326,60 @@ func (m *Model) checkWinCondition() tea.Cmd {
 		// OR we dispatch a special validation msg.
 
 		// BLOCKING CALL for validation (simple for prototype)
-		checkPassed := false
-
-		switch q.WinCondition.Type {
-		case game.CommandOut:
-			// "CommandOut" runs a command to validate game state.
-			// e.g. "stat -c %a hut" should return "700"
-			// We MUST use ExecuteValidation so it runs in a predictable context (/home/player)
-			// independently of where the user has cd'd to.
-			out, _ := m.manager.ExecuteValidation(q.WinCondition.Command)
-			if strings.TrimSpace(out) == q.WinCondition.Expected {
-				checkPassed = true
-			}
-		case game.DirExists:
-			// check if dir exists using test -d, from ROOT context
-			cmd := fmt.Sprintf("test -d %s && echo yes", q.WinCondition.Target)
-			out, _ := m.manager.ExecuteValidation(cmd)
-			if strings.TrimSpace(out) == "yes" {
-				checkPassed = true
-			}
-		case game.FileExists:
-			cmd := fmt.Sprintf("test -f %s && echo yes", q.WinCondition.Target)
-			out, _ := m.manager.ExecuteValidation(cmd)
-			if strings.TrimSpace(out) == "yes" {
-				checkPassed = true
+		check := m.checkCache.CheckQuest(q.ActiveWinConditions(!m.manager.NetRawUnavailable), m.manager, m.lastOutput, m.manager.CurrentDir)
+		// Reasons, traces and diffs describe the first condition still failing
+		wc, trace := check.Conditions[check.Focus()], check.Traces[check.Focus()]
+
+		msg := questCheckMsg{idx: m.currentQuestIdx, passed: check.Passed(), reason: trace.Reason, done: check.Done(), total: check.Total()}
+		if !msg.passed {
+			// A stopped container fails every check; say so rather than failing forever
+			if running, err := m.manager.IsRunning(); err == nil && !running {
+				msg.containerGone = true
 			}
-		case game.FileContains:
-			// check if file content contains string
-			// We use grep in the container to check
-			// safe because it's a validation command running in a controlled container
-			// Escape single quotes for safety if needed, though basic check here:
-			cmd := fmt.Sprintf("grep -q \"%s\" %s && echo yes", q.WinCondition.Content, q.WinCondition.Target)
-			out, _ := m.manager.ExecuteValidation(cmd)
-			if strings.TrimSpace(out) == "yes" {
-				checkPassed = true
-			}
-		case game.UserOutputMatch:
-			// Check if the *last* command output by the user matches the expectation
-			// This is useful for "cat file" or "grep" where we want to see if they saw the right thing
-			if strings.TrimSpace(m.lastOutput) == strings.TrimSpace(q.WinCondition.Expected) {
-				checkPassed = true
-			}
-		case game.UserOutputContains:
-			// Check if the *last* command output contains the expected string
-			if strings.Contains(m.lastOutput, q.WinCondition.Expected) {
-				checkPassed = true
-			}
-		case game.CurrentDirMatch:
-			// Check if the current directory matches the target
-			// The manager tracks CurrentDir
-			// We need to handle relative vs absolute paths potentially?
-			// For simplicity early game, target likely "hut" which implies "/home/player/hut"
-			// But let's support both explicit absolute or relative to home.
-
-			targetDir := q.WinCondition.Target
-			// Normalize target
-			if !strings.HasPrefix(targetDir, "/") {
-				targetDir = "/home/player/" + targetDir
-			}
-			targetDir = strings.TrimSuffix(targetDir, "/")
-
-			currentDir := strings.TrimSuffix(m.manager.CurrentDir, "/")
-
-			if currentDir == targetDir {
-				checkPassed = true
+		}
+		if msg.passed {
+			// Bonuses count only if they're met at the moment the quest completes
+			for i, b := range q.OptionalObjectives {
+				if game.CheckWinCondition(b.WinCondition, m.manager, m.lastOutput, m.manager.CurrentDir) {
+					msg.bonuses = append(msg.bonuses, i)
+				}
 			}
 		}
-
-		return questCheckMsg{idx: m.currentQuestIdx, passed: checkPassed}
+		if !msg.passed && !m.hardMode {
+			msg.nearMiss = game.NearMiss(wc, trace)
+		}
+		if m.debug {
+			msg.trace = checkTraceLine(wc, trace)
+		}
+		if !trace.Passed && m.debug {
+			msg.diff = fileDiff(wc, m.manager)
+			msg.target = wc.Target
+		}
+		return msg
 	}
 }
 
+type shellExitMsg struct{ err error }
+
 type questCheckMsg struct {
 	idx    int
 	passed bool
+	reason string // Why the check failed, when the condition can tell
+	done   int    // Conditions that passed, out of total
+	total  int
+	// Optional objectives met when the quest passed, as indexes into OptionalObjectives
+	bonuses []int
+	// The check failed because the player container isn't running
+	containerGone bool
+	// Asked for with 'retry', so a failure is worth saying out loud
+	retried bool
+	// How a failed output check came close (see game.NearMiss), if it did
+	nearMiss string
+
+	// --debug only: expected vs actual file content for a failed file check
+	diff   []game.DiffLine
+	target string
+	trace  string // --debug only: one-line log of what the check saw
 }
 
 // Need to handle the new msg type
@@ -399,9 +1388,21 @@ type questCheckMsg struct {
 // Wait, I am writing the whole file. I need to insert the handler for questCompleteMsg in the Update function.
 // I will rewrite the Update function below properly.
 
+// View renders the screen, led by a BEL while the bell rings. The bell goes
+// out with a frame, so it can't land in the middle of one.
 func (m Model) View() string {
+	if m.ringing {
+		return "\a" + m.view()
+	}
+	return m.view()
+}
+
+func (m Model) view() string {
 	if !m.viewportReady {
-		return "Initializing..."
+		return T("init.viewport")
+	}
+	if m.a11y {
+		return m.accessibleView()
 	}
 
 	// Styles
@@ -413,32 +1414,36 @@ func (m Model) View() string {
 	// Layout components
 
 	// 1. Header (Objective)
-	objectiveText := "Load Quests..." // Default
-	headerColor := "#AAAAAA"          // Default Gray
+	objectiveText := m.objectiveText() + m.checklistHeader()
+	headerColor := theme.Header // Default Gray
+	if m.hardMode && m.currentQuestIdx < len(m.quests) {
+		headerColor = theme.HardMode // Red for Hard Mode
+	}
 
-	if m.currentQuestIdx < len(m.quests) {
-		q := m.quests[m.currentQuestIdx]
-		if m.hardMode {
-			headerColor = "#FF5555" // Red for Hard Mode
-			if q.HardObjective != "" {
-				objectiveText = fmt.Sprintf("[HARD MODE] %s", q.HardObjective)
-			} else {
-				objectiveText = fmt.Sprintf("[HARD MODE] %s", q.Objective)
-			}
-		} else {
-			objectiveText = q.Objective
+	// Progress, XP balance and speedrun timer in the top-right corner,
+	// after the countdown when the quest is a challenge
+	timerText := ""
+	if m.gameStarted {
+		timerText = fmt.Sprintf(" %s  %s  %s  %s ", m.progressMeter(), T("xp.balance", m.state.Balance()), T("difficulty."+m.difficulty.Name), game.FormatDuration(time.Since(m.gameStart)))
+		if left, ok := m.timeLeft(); ok {
+			timerText = " " + T("challenge.header", game.FormatDuration(left), m.attempt, m.attemptLimit()) + " " + timerText
 		}
-	} else {
-		objectiveText = "All Objectives Complete!"
 	}
+	timerWidth := lipgloss.Width(timerText)
 
-	header := lipgloss.NewStyle().
-		Width(m.width).
+	headerStyle := lipgloss.NewStyle().
 		Height(1).
 		Foreground(lipgloss.Color("#000000")).
-		Background(lipgloss.Color(headerColor)).
-		PaddingLeft(1).
-		Render(fmt.Sprintf("OBJECTIVE: %s", objectiveText))
+		Background(lipgloss.Color(headerColor))
+
+	header := lipgloss.JoinHorizontal(lipgloss.Top,
+		headerStyle.
+			Width(m.width-timerWidth).
+			MaxWidth(m.width-timerWidth).
+			PaddingLeft(1).
+			Render(T("objective.label", objectiveText)),
+		headerStyle.Bold(true).Render(timerText),
+	)
 
 	// 3. Glitch's Box (Bottom)
 	// We render this FIRST to calculate remaining height for terminal
@@ -448,29 +1453,49 @@ func (m Model) View() string {
 	lines := strings.Split(fmt.Sprintf("%s\n\n<'.'>", m.glitchText), "\n")
 	var styledLines []string
 	for _, line := range lines {
-		styledLines = append(styledLines, styleLine(line))
+		styledLines = append(styledLines, styleStoryLine(line))
+	}
+	if m.running != nil {
+		styledLines[len(styledLines)-1] += " " + m.runningLine()
 	}
 	styledGlitchText := strings.Join(styledLines, "\n")
 
-	glitchBox := lipgloss.NewStyle().
+	glitchStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("#00FF00")). // Green border for Glitch identity
+		BorderForeground(lipgloss.Color(theme.Glitch)). // Green border for Glitch identity
 		Padding(1).
-		Width(m.width - 4). // Full width minus margins
-		Render(styledGlitchText)
-
-	// 4. Input Line
-	// Pretty path: /home/player -> ~
-	displayPath := m.manager.CurrentDir
-	if strings.HasPrefix(displayPath, "/home/player") {
-		displayPath = strings.Replace(displayPath, "/home/player", "~", 1)
+		Width(m.width - 4) // Full width minus margins
+
+	// Side layout: Glitch becomes a full-height right-hand column
+	side := m.sideLayout()
+	termWidth := m.width
+	if side {
+		glitchWidth := sideColumnWidth(m.width)
+		termWidth = m.width - glitchWidth
+		glitchStyle = glitchStyle.
+			Width(glitchWidth - 2). // Border is outside Width
+			Height(m.height - 4).   // Header, input and border
+			MaxHeight(m.height - 2) // Clip long intros rather than push the input off screen
 	}
+	glitchBox := glitchStyle.Render(styledGlitchText)
 
-	inputLine := fmt.Sprintf("player@goblin:%s$ %s", displayPath, m.input)
+	// 4. Input Line
+	// Long input scrolls horizontally so the layout never wraps. -1 leaves room for the cursor.
+	prompt := m.promptString()
+	inputLine := m.maskIndicator() + prompt + inputWindow(m.shown(m.input), m.width-lipgloss.Width(m.maskIndicator()+prompt)-1)
 
 	// Exit hint only for first quest
 	if m.input == "" && m.currentQuestIdx == 0 {
-		inputLine += lipgloss.NewStyle().Foreground(lipgloss.Color("#555555")).Render(" (type 'exit' to quit)")
+		inputLine += lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render(T("hint.exit"))
+	}
+	if m.search != nil {
+		inputLine = lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Highlight)).Render(inputWindow(m.searchPrompt(), m.width-1))
+	}
+	if m.sudo != nil {
+		inputLine = sudoPromptText()
+	}
+	if m.stdin != nil {
+		inputLine = m.maskIndicator() + inputWindow(m.shown(m.input), m.width-lipgloss.Width(m.maskIndicator())-1)
 	}
 	// Add blinking cursor
 	if time.Now().UnixMilli()/500%2 == 0 {
@@ -484,6 +1509,9 @@ func (m Model) View() string {
 	// Total Fixed = 1 + h(glitchBox) + 1
 
 	totalFixedHeight := 1 + lipgloss.Height(glitchBox) + 1
+	if side {
+		totalFixedHeight = 2
+	}
 
 	termHeight := m.height - totalFixedHeight
 	if termHeight < 0 {
@@ -492,7 +1520,7 @@ func (m Model) View() string {
 
 	// 2. Main Terminal Output
 	// We need to account for wrapping to ensure we don't overflow the height
-	contentWidth := m.width - 2 // -2 for horizontal padding
+	contentWidth := termWidth - 2 // -2 for horizontal padding
 	if contentWidth < 1 {
 		contentWidth = 1
 	}
@@ -500,12 +1528,28 @@ func (m Model) View() string {
 	wrapStyle := lipgloss.NewStyle().Width(contentWidth)
 	var visibleLines []string
 
+	// A search scrolls back so the current match sits mid-screen
+	last := len(m.output) - 1
+	if line := m.searchLine(); line >= 0 && line+termHeight/2 < last {
+		last = line + termHeight/2
+	}
+	matchStyle := lipgloss.NewStyle().Reverse(true)
+	currentStyle := matchStyle.Foreground(lipgloss.Color(theme.Highlight)).Bold(true)
+
 	// Iterate backwards through history to collect the most recent lines
 	// accounting for line wrapping
-	for i := len(m.output) - 1; i >= 0; i-- {
+	for i := last; i >= 0; i-- {
 		// Style the line BEFORE wrapping to preserve ansi codes naturally?
 		// No, styleLine adds ansi codes. wrapStyle handles them.
 		lineContent := styleLine(m.output[i])
+		if m.menuOpen || m.dialog != nil {
+			// Dim the terminal behind the overlay
+			lineContent = lipgloss.NewStyle().Faint(true).Render(m.output[i])
+		} else if i == m.searchLine() {
+			lineContent = currentStyle.Render(ansi.Strip(m.output[i]))
+		} else if m.isSearchMatch(i) {
+			lineContent = matchStyle.Render(ansi.Strip(m.output[i]))
+		}
 
 		// Render with wrapping
 		rendered := wrapStyle.Render(lineContent)
@@ -525,12 +1569,33 @@ func (m Model) View() string {
 		visibleLines = visibleLines[len(visibleLines)-termHeight:]
 	}
 
+	if m.menuOpen {
+		visibleLines = overlayCenter(visibleLines, m.renderMenu(), contentWidth)
+	}
+	if m.dialog != nil {
+		visibleLines = overlayCenter(visibleLines, m.renderConfirm(), contentWidth)
+	}
+	if m.onboarding {
+		visibleLines = overlayCenter(visibleLines, m.renderOnboarding(), contentWidth)
+	}
+
 	mainTerm := lipgloss.NewStyle().
-		Width(m.width).
+		Width(termWidth).
 		Height(termHeight).
 		Padding(0, 1). // Horizontal padding
 		Render(strings.Join(visibleLines, "\n"))
 
+	if side {
+		return screenStyle.Render(
+			lipgloss.JoinVertical(
+				lipgloss.Left,
+				header,
+				lipgloss.JoinHorizontal(lipgloss.Top, mainTerm, glitchBox),
+				inputLine,
+			),
+		)
+	}
+
 	return screenStyle.Render(
 		lipgloss.JoinVertical(
 			lipgloss.Left,
@@ -542,17 +1607,309 @@ func (m Model) View() string {
 	)
 }
 
+// suggestCommand prefills the prompt with the quest's suggested command.
+// It waits at the end of history like anything typed, and is left out if the
+// player already started typing or the demo is at the keyboard.
+func (m *Model) suggestCommand(q game.Quest) {
+	if q.SuggestedCommand == "" || !m.difficulty.SuggestCommands || m.hardMode || m.demo || m.scripted || m.input != "" {
+		return
+	}
+	m.input = q.SuggestedCommand
+	m.historyIdx = len(m.history)
+}
+
+// objectiveText is the current quest's objective with its category,
+// the Hard Mode wording when that is on
+func (m Model) objectiveText() string {
+	if len(m.quests) == 0 {
+		return T("objective.default")
+	}
+	if m.currentQuestIdx >= len(m.quests) {
+		return T("objective.complete")
+	}
+
+	q := m.quests[m.currentQuestIdx]
+	text := q.Objective
+	if m.hardMode {
+		if q.HardObjective != "" {
+			text = T("objective.hard", q.HardObjective)
+		} else {
+			text = T("objective.hard", q.Objective)
+		}
+	}
+	return fmt.Sprintf("[%s] %s", q.QuestCategory(), text)
+}
+
+// inputWindow returns the end of input that fits in width cells, marking
+// clipped text with an ellipsis. The cursor is always at the end, so that's
+// the part worth showing.
+func inputWindow(input string, width int) string {
+	if width < 1 || lipgloss.Width(input) <= width {
+		return input
+	}
+
+	runes := []rune(input)
+	used := 1 // The ellipsis
+	start := len(runes)
+	for start > 0 {
+		w := lipgloss.Width(string(runes[start-1]))
+		if used+w > width {
+			break
+		}
+		used += w
+		start--
+	}
+	return "…" + string(runes[start:])
+}
+
+// highlightProcesses marks the lines of ps output that mention one of the
+// relevant process names so beginners can spot them
+func highlightProcesses(psOutput string, relevant []string) []string {
+	highlight := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Highlight)).Bold(true)
+
+	lines := strings.Split(strings.TrimRight(psOutput, "\n"), "\n")
+	result := make([]string, 0, len(lines))
+	for i, line := range lines {
+		// Keep the header row aligned with the marker column
+		if i == 0 {
+			result = append(result, "  "+line)
+			continue
+		}
+		matched := false
+		for _, name := range relevant {
+			if name != "" && strings.Contains(line, name) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			result = append(result, highlight.Render("> "+line))
+		} else {
+			result = append(result, "  "+line)
+		}
+	}
+	return result
+}
+
+// tick schedules the next timer refresh. A script has no screen to refresh.
+func (m Model) tick() tea.Cmd {
+	// The accessible view has no clock, and redraws make screen readers repeat
+	if m.scripted || m.a11y {
+		return nil
+	}
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// bannerLines renders a quest's ASCII art centered in the terminal area.
+// Nothing is shown if the art is wider than the terminal, since wrapping would garble it,
+// or in accessibility mode, where it reads as noise.
+func (m Model) bannerLines(banner string) []string {
+	banner = strings.TrimRight(banner, "\n")
+	if strings.TrimSpace(banner) == "" || m.a11y {
+		return nil
+	}
+
+	art := strings.Split(banner, "\n")
+	artWidth := 0
+	for _, line := range art {
+		if w := lipgloss.Width(line); w > artWidth {
+			artWidth = w
+		}
+	}
+
+	contentWidth := m.width - 2 // Matches the terminal's horizontal padding
+	if m.width > 0 && artWidth > contentWidth {
+		return nil
+	}
+
+	pad := ""
+	if m.width > 0 {
+		pad = strings.Repeat(" ", (contentWidth-artWidth)/2)
+	}
+
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Glitch)).Bold(true)
+	lines := make([]string, 0, len(art)+1)
+	for _, line := range art {
+		lines = append(lines, pad+style.Render(line))
+	}
+	return append(lines, "")
+}
+
+// bellFrames is how long the BEL leads the view: two frames at bubbletea's
+// 60 fps, so one flush carries it and later ones see an unchanged line
+const bellFrames = time.Second / 30
+
+// bellDoneMsg ends the frames the bell is rendered in
+type bellDoneMsg struct{}
+
+// ringBell makes the terminal beep or flash. The BEL is written by the
+// renderer, as part of the view, never straight to stdout.
+func (m *Model) ringBell() tea.Cmd {
+	m.ringing = true
+	return tea.Tick(bellFrames, func(time.Time) tea.Msg { return bellDoneMsg{} })
+}
+
+// formatInventory turns find's "<type> <path>" lines into a labelled listing,
+// with paths under home shortened to "~"
+func formatInventory(findOutput, home string) []string {
+	lines := []string{T("inventory.header")}
+	count := 0
+	for _, line := range strings.Split(strings.TrimSpace(findOutput), "\n") {
+		kind, path, ok := strings.Cut(line, " ")
+		if !ok || path == "" {
+			continue
+		}
+		label := T("inventory.file")
+		switch kind {
+		case "d":
+			label = T("inventory.dir")
+		case "l":
+			label = T("inventory.link")
+		}
+		lines = append(lines, fmt.Sprintf("  %-6s %s", label, promptPath(path, home)))
+		count++
+	}
+	if count == 0 {
+		lines = append(lines, T("inventory.empty"))
+	}
+	return lines
+}
+
+// outputLines splits command output into lines, without the empty one a
+// trailing newline leaves
+func outputLines(out string) []string {
+	if out == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+}
+
+// commandLines is what a finished command shows. By default that's both
+// streams interleaved as written, like a real terminal, where a failure's
+// stderr speaks for itself. With --split-output, stdout comes first and then
+// stderr, marked on success or as the error on failure.
+func (m Model) commandLines(msg commandResultMsg) []string {
+	if !m.splitOutput && msg.combined != "" {
+		lines := m.displayLines(outputLines(msg.combined))
+		if msg.err != nil && msg.stderr == "" {
+			lines = append(lines, T("cmd.error", msg.err))
+		}
+		return lines
+	}
+
+	// Whatever reached stdout before a failure still belongs on screen
+	lines := outputLines(msg.output)
+	if msg.err != nil {
+		return append(m.displayLines(lines), T("cmd.error", msg.err))
+	}
+	for _, line := range outputLines(msg.stderr) {
+		lines = append(lines, T("cmd.stderr", line))
+	}
+	return m.displayLines(lines)
+}
+
+// displayLines applies the display-only options to command output lines;
+// lastOutput keeps the raw text for win checks
+func (m Model) displayLines(lines []string) []string {
+	if m.lineNumbers {
+		return numberLines(lines)
+	}
+	return lines
+}
+
+// numberLines prefixes each output line with its 1-based line number
+func numberLines(lines []string) []string {
+	numbered := make([]string, len(lines))
+	for i, line := range lines {
+		numbered[i] = fmt.Sprintf("%4d  %s", i+1, line)
+	}
+	return numbered
+}
+
+// expandHistory resolves shell-style "!!" (previous command) and "!n"
+// (history entry n, numbered as the history command shows it).
+// Returns the expanded command and whether an expansion happened.
+func expandHistory(cmd string, history []string) (string, bool, error) {
+	if !strings.HasPrefix(cmd, "!") || cmd == "!" {
+		return cmd, false, nil
+	}
+
+	if cmd == "!!" {
+		if len(history) == 0 {
+			return "", false, fmt.Errorf("!!: event not found")
+		}
+		return history[len(history)-1], true, nil
+	}
+
+	n, err := strconv.Atoi(cmd[1:])
+	if err != nil {
+		// Not a history reference (e.g. "!foo"), run as typed
+		return cmd, false, nil
+	}
+	if n < 1 || n > len(history) {
+		return "", false, fmt.Errorf("%s: event not found", cmd)
+	}
+	return history[n-1], true, nil
+}
+
+// promptPath shortens the player's home directory to "~" for display.
+// Only the exact home path or paths below it are replaced, so siblings
+// like /home/player2 are left untouched.
+func promptPath(dir, home string) string {
+	if dir == home {
+		return "~"
+	}
+	if strings.HasPrefix(dir, home+"/") {
+		return "~" + strings.TrimPrefix(dir, home)
+	}
+	return dir
+}
+
 func styleLine(text string) string {
+	style, ok := lineStyle(text)
+	if !ok {
+		// Default: return as is (white/terminal default)
+		return text
+	}
+	return style.Render(text)
+}
+
+// lineStyle picks the color for a line of story text, if it gets one
+func lineStyle(text string) (lipgloss.Style, bool) {
 	// If the line already has ansi codes (e.g. from SuccessText), we might want to skip or be careful.
 	// Simple check: if it starts with [SYSTEM MESSAGE], color it Orange.
 	if strings.Contains(text, "[SYSTEM MESSAGE]") {
 		// Orange/Yellow
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500")).Render(text)
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.System)), true
 	}
 	if strings.Contains(text, "<'.'>") {
 		// Green
-		return lipgloss.NewStyle().Foreground(lipgloss.Color("#00FF00")).Render(text)
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Glitch)), true
+	}
+	return lipgloss.NewStyle(), false
+}
+
+// styleStoryLine is styleLine for quest-author text (intros, hints, success
+// text): `backtick` spans are drawn as inline code, without the backticks.
+// Lines with unbalanced backticks render as plain styleLine text.
+func styleStoryLine(text string) string {
+	spans := strings.Split(text, "`")
+	if len(spans) < 3 || len(spans)%2 == 0 {
+		return styleLine(text)
+	}
+
+	base, _ := lineStyle(text)
+	code := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Code)).Bold(true)
+	var b strings.Builder
+	for i, span := range spans {
+		switch {
+		case i%2 == 1:
+			b.WriteString(code.Render(span))
+		case span != "":
+			b.WriteString(base.Render(span))
+		}
 	}
-	// Default: return as is (white/terminal default)
-	return text
+	return b.String()
 }
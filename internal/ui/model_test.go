@@ -0,0 +1,1818 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"goblin-terminal/internal/game"
+	"goblin-terminal/pkg/docker"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+func TestPromptPath(t *testing.T) {
+	cases := map[string]string{
+		"/home/player":         "~",
+		"/home/player/":        "~/",
+		"/home/player/hut":     "~/hut",
+		"/home/player/hut/bed": "~/hut/bed",
+		"/home/playerX":        "/home/playerX",
+		"/home/player2/hut":    "/home/player2/hut",
+		"/tmp":                 "/tmp",
+		"/":                    "/",
+	}
+
+	for in, want := range cases {
+		if got := promptPath(in, docker.DefaultHome); got != want {
+			t.Errorf("promptPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExpandPrompt(t *testing.T) {
+	cases := []struct {
+		template, host, dir, want string
+	}{
+		{DefaultPrompt, "", "~", "player@goblin:~$ "},
+		{DefaultPrompt, "", "~/hut", "player@goblin:~/hut$ "},
+		{DefaultPrompt, "gateway", "~", "player@gateway:~$ "},
+		{"[%h %w] ", "", "/tmp", "[goblin /tmp] "},
+		{"%u > ", "", "/", "player > "},
+		{"100%% %w%", "", "~", "100% ~%"},
+		{"%x%w", "", "/etc", "%x/etc"},
+	}
+	for _, tc := range cases {
+		if got := expandPrompt(tc.template, tc.host, tc.dir); got != tc.want {
+			t.Errorf("expandPrompt(%q, %q, %q) = %q, want %q", tc.template, tc.host, tc.dir, got, tc.want)
+		}
+	}
+
+	if err := ValidatePrompt("line\n"); err == nil {
+		t.Error("Expected a newline in the prompt to be rejected")
+	}
+}
+
+func TestEchoedPromptMatchesLivePrompt(t *testing.T) {
+	mgr := &docker.Manager{Runtime: "false", ContainerName: "goblin-test", CurrentDir: "/home/player/hut"}
+	for _, template := range []string{"", "%w %% ", "[%u@%h %w]$ "} {
+		m := NewModel([]game.Quest{{ID: 1, Objective: "Run pwd"}}, mgr, game.GameState{}, 0, Options{SkipIntro: true, Prompt: template})
+		m.ready = true
+		m.viewportReady = true
+		m.width, m.height = 80, 24
+
+		live := m.promptString()
+		if !strings.Contains(m.View(), live) {
+			t.Errorf("Expected the input line to show %q", live)
+		}
+		m.input = "ls"
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = updated.(Model)
+		if echoed := m.output[len(m.output)-1]; echoed != live+"ls" {
+			t.Errorf("Echoed prompt %q doesn't match live prompt %q", echoed, live)
+		}
+	}
+}
+
+func TestCustomHomeInPrompt(t *testing.T) {
+	mgr := &docker.Manager{Runtime: "false", ContainerName: "goblin-test", Home: "/home/grub", CurrentDir: "/home/grub/hut"}
+	m := NewModel([]game.Quest{{ID: 1}}, mgr, game.GameState{}, 0, Options{SkipIntro: true, Prompt: "%w$ "})
+	if got := m.promptString(); got != "~/hut$ " {
+		t.Errorf("Expected the detected home shortened to ~, got %q", got)
+	}
+	mgr.CurrentDir = "/home/player"
+	if got := m.promptString(); got != "/home/player$ " {
+		t.Errorf("Expected the default home shown in full when it isn't home, got %q", got)
+	}
+}
+
+func TestQuestHostInPrompt(t *testing.T) {
+	mgr := &docker.Manager{Runtime: "true", ContainerName: "goblin-test", GatewayName: "goblin-test_gateway", CurrentDir: "/home/player/hut"}
+	quests := []game.Quest{{ID: 1, Host: game.GatewayHost}, {ID: 2}}
+	m := NewModel(quests, mgr, game.GameState{}, 0, Options{SkipIntro: true})
+
+	if msg := m.performQuestSetup(quests[0])().(setupMsg); msg.scenarioErr != nil {
+		t.Fatalf("Unexpected setup error: %v", msg.scenarioErr)
+	}
+	if got := m.promptString(); got != "player@gateway:~$ " {
+		t.Errorf("Expected the prompt on the gateway, got %q", got)
+	}
+
+	// The next quest brings the player home, to the directory they left
+	m.performQuestSetup(quests[1])()
+	if got := m.promptString(); got != "player@goblin:~/hut$ " {
+		t.Errorf("Expected the prompt back on the player's machine, got %q", got)
+	}
+}
+
+func TestBuildRetryPrompt(t *testing.T) {
+	m := NewModel(nil, nil, game.GameState{}, 0, Options{SkipIntro: true})
+
+	updated, cmd := m.Update(containerReadyMsg{err: errors.New("pull failed")})
+	m = updated.(Model)
+	if !m.buildFailed {
+		t.Fatal("Expected model to wait for retry after a failed build")
+	}
+	if cmd != nil {
+		t.Error("Expected no command while waiting for the player")
+	}
+
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m = updated.(Model)
+	if m.buildFailed || m.buildRetries != 1 {
+		t.Errorf("Expected retry to be started, got buildFailed=%v retries=%d", m.buildFailed, m.buildRetries)
+	}
+	if cmd == nil {
+		t.Error("Expected retry to return the build command")
+	}
+
+	m.buildRetries = maxBuildRetries
+	updated, cmd = m.Update(containerReadyMsg{err: errors.New("pull failed")})
+	m = updated.(Model)
+	if m.buildFailed || cmd == nil {
+		t.Error("Expected model to quit once retries are exhausted")
+	}
+}
+
+func TestHighlightProcesses(t *testing.T) {
+	ps := "USER PID COMMAND\nroot 1 tail -f /dev/null\nroot 42 /usr/sbin/cron\n"
+
+	lines := highlightProcesses(ps, []string{"cron"})
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d", len(lines))
+	}
+	if !strings.HasPrefix(lines[1], "  ") {
+		t.Errorf("Expected unrelated process to be unmarked, got %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "> root 42 /usr/sbin/cron") {
+		t.Errorf("Expected cron to be marked, got %q", lines[2])
+	}
+}
+
+func TestPauseMenuToggleHardMode(t *testing.T) {
+	mgr := &docker.Manager{Runtime: "false", ContainerName: "goblin-test", CurrentDir: docker.DefaultHome}
+	easy := game.Difficulties[game.DifficultyEasy]
+	m := NewModel([]game.Quest{{ID: 1, Objective: "Make a hut"}}, mgr, game.GameState{Difficulty: game.DifficultyEasy}, 0, Options{SkipIntro: true, Difficulty: easy})
+	m.ready, m.viewportReady, m.gameStarted = true, true, true
+	m.width, m.height = 120, 30
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	m = updated.(Model)
+	if !m.menuOpen {
+		t.Fatal("Expected Ctrl+P to open the pause menu")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = updated.(Model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if !m.hardMode {
+		t.Error("Expected selecting Hard Mode to toggle it on")
+	}
+	if !m.menuOpen {
+		t.Error("Expected menu to stay open after toggling a setting")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if m.menuOpen {
+		t.Error("Expected Esc to close the pause menu")
+	}
+	// The whole level follows Hard Mode, not just the hidden commands
+	if view := m.View(); m.difficulty.Name != game.DifficultyHard || !strings.Contains(view, T("difficulty.hard")) || mgr.CommandTimeout != game.Difficulties[game.DifficultyHard].CommandTimeout {
+		t.Errorf("Expected the hard level played and shown, got %q", m.difficulty.Name)
+	}
+
+	// Ctrl+H turns it off again, back to the saved level
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlH})
+	m = updated.(Model)
+	if view := m.View(); m.hardMode || m.difficulty.Name != game.DifficultyEasy || !strings.Contains(view, T("difficulty.easy")) || mgr.CommandTimeout != easy.CommandTimeout {
+		t.Errorf("Expected the saved easy level back, got %q (hard mode %v)", m.difficulty.Name, m.hardMode)
+	}
+}
+
+func TestExpandHistory(t *testing.T) {
+	history := []string{"pwd", "ls /tmp", "cd /tmp"}
+
+	cases := []struct {
+		in       string
+		want     string
+		expanded bool
+		wantErr  bool
+	}{
+		{"!!", "cd /tmp", true, false},
+		{"!1", "pwd", true, false},
+		{"!3", "cd /tmp", true, false},
+		{"!4", "", false, true},
+		{"!0", "", false, true},
+		{"ls", "ls", false, false},
+		{"!", "!", false, false},
+		{"!foo", "!foo", false, false},
+	}
+
+	for _, tc := range cases {
+		got, expanded, err := expandHistory(tc.in, history)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("expandHistory(%q): expected error=%v, got %v", tc.in, tc.wantErr, err)
+			continue
+		}
+		if got != tc.want || expanded != tc.expanded {
+			t.Errorf("expandHistory(%q) = %q, %v; want %q, %v", tc.in, got, expanded, tc.want, tc.expanded)
+		}
+	}
+
+	if _, _, err := expandHistory("!!", nil); err == nil {
+		t.Error("Expected !! with empty history to fail")
+	}
+}
+
+func TestDemoTypesSolution(t *testing.T) {
+	quests := []game.Quest{{ID: 1, Solution: []string{"ls"}}}
+	m := NewModel(quests, nil, game.GameState{}, 0, Options{Demo: true})
+	m.loadDemo(quests[0])
+
+	for _, want := range []string{"", "l", "ls"} {
+		updated, cmd := m.Update(demoTickMsg{})
+		m = updated.(Model)
+		if m.input != want {
+			t.Errorf("Expected input %q, got %q", want, m.input)
+		}
+		if cmd == nil {
+			t.Error("Expected demo to schedule another tick")
+		}
+	}
+
+	// Real key presses are ignored while the demo drives the prompt
+	m.ready = true
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if updated.(Model).input != "ls" {
+		t.Error("Expected demo to ignore typed keys")
+	}
+}
+
+func TestLineNumbersKeepRawOutput(t *testing.T) {
+	m := NewModel([]game.Quest{{ID: 1}}, nil, game.GameState{}, 0, Options{Numbers: true})
+
+	updated, _ := m.Update(commandResultMsg{output: "/home/player\n"})
+	m = updated.(Model)
+
+	if got := m.output[len(m.output)-1]; got != "   1  /home/player" {
+		t.Errorf("Expected numbered output line, got %q", got)
+	}
+	if m.lastOutput != "/home/player\n" {
+		t.Errorf("Expected lastOutput to stay unnumbered, got %q", m.lastOutput)
+	}
+}
+
+func TestCommandOutputInterleaved(t *testing.T) {
+	m := NewModel([]game.Quest{{ID: 1}}, nil, game.GameState{}, 0, Options{})
+
+	updated, _ := m.Update(commandResultMsg{output: "result\n", stderr: "fetching\n", combined: "fetching\nresult\n"})
+	m = updated.(Model)
+	if tail := m.output[len(m.output)-2:]; tail[0] != "fetching" || tail[1] != "result" {
+		t.Errorf("Expected output in the order written, got %q", tail)
+	}
+	if m.lastOutput != "result\n" {
+		t.Errorf("Expected lastOutput to hold stdout only, got %q", m.lastOutput)
+	}
+
+	// A failure's stderr is already on screen, so no separate error line
+	before := len(m.output)
+	updated, _ = m.Update(commandResultMsg{stderr: "ls: nope\n", combined: "ls: nope\n", err: errors.New("ls: nope\n")})
+	m = updated.(Model)
+	if got := m.output[before:]; len(got) != 1 || got[0] != "ls: nope" {
+		t.Errorf("Expected the stderr line once, got %q", got)
+	}
+}
+
+func TestCommandStderr(t *testing.T) {
+	m := NewModel([]game.Quest{{ID: 1}}, nil, game.GameState{}, 0, Options{SplitOutput: true})
+
+	// Exit 0 with stderr: shown after stdout, marked, and not a failure
+	updated, _ := m.Update(commandResultMsg{output: "ok\n", stderr: "useradd: warning\n"})
+	m = updated.(Model)
+	tail := m.output[len(m.output)-2:]
+	if tail[0] != "ok" || tail[1] != T("cmd.stderr", "useradd: warning") {
+		t.Errorf("Expected stdout then marked stderr, got %q", tail)
+	}
+	if m.lastOutput != "ok\n" {
+		t.Errorf("Expected lastOutput to hold stdout only, got %q", m.lastOutput)
+	}
+
+	// Nonzero exit: partial stdout then the error carrying stderr
+	updated, _ = m.Update(commandResultMsg{output: "partial\n", err: errors.New("bogus: command not found")})
+	m = updated.(Model)
+	tail = m.output[len(m.output)-2:]
+	if tail[0] != "partial" || tail[1] != T("cmd.error", "bogus: command not found") {
+		t.Errorf("Expected stdout then the error, got %q", tail)
+	}
+	if m.lastOutput != "ok\n" {
+		t.Errorf("Expected a failure to leave lastOutput alone, got %q", m.lastOutput)
+	}
+}
+
+func TestBellRendered(t *testing.T) {
+	mgr := &docker.Manager{Runtime: "false", ContainerName: "goblin-test", CurrentDir: docker.DefaultHome}
+	m := NewModel([]game.Quest{{ID: 1}}, mgr, game.GameState{}, 0, Options{Bell: true})
+	m.ready, m.viewportReady = true, true
+	m.width, m.height = 80, 24
+
+	// The BEL rides on the rendered frame, not a write of its own
+	updated, cmd := m.Update(commandResultMsg{stderr: "ls: nope\n", combined: "ls: nope\n", err: errors.New("ls: nope\n")})
+	m = updated.(Model)
+	if cmd == nil || !strings.HasPrefix(m.View(), "\a") {
+		t.Fatal("Expected a failed command to ring the bell in the view")
+	}
+
+	updated, _ = m.Update(bellDoneMsg{})
+	m = updated.(Model)
+	if strings.Contains(m.View(), "\a") {
+		t.Error("Expected the bell to ring only once")
+	}
+}
+
+func TestLoreLog(t *testing.T) {
+	quests := []game.Quest{
+		{ID: 1, Title: "Hut", IntroText: "Build me a hut.", SuccessText: "A fine hut!"},
+		{ID: 2, Title: "Door", IntroText: "Now a door."},
+	}
+	m := NewModel(quests, &docker.Manager{}, game.GameState{}, 0, Options{SkipIntro: true})
+	m.ready = true
+	m.scripted = true
+
+	m.input = "lore"
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if got := m.output[len(m.output)-1]; got != T("lore.empty") {
+		t.Errorf("Expected an empty log at first, got %q", got)
+	}
+
+	m.startQuest(0)
+	updated, _ = m.Update(questCheckMsg{idx: 0, passed: true, done: 1, total: 1})
+	m = updated.(Model)
+
+	want := []string{"Build me a hut.", "A fine hut!", T("quest.next", "Door", "Now a door.")}
+	if !slices.Equal(m.state.MsgLog, want) {
+		t.Fatalf("MsgLog = %q, want %q", m.state.MsgLog, want)
+	}
+
+	// The log is narrative only: commands and their output stay out of it
+	before := len(m.output)
+	m.input = "lore"
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	shown := m.output[before:]
+	if !slices.Contains(shown, T("lore.header", 3)) || !slices.Contains(shown, styleStoryLine("A fine hut!")) {
+		t.Errorf("Expected the story beats under the lore header, got %q", shown)
+	}
+}
+
+func TestWebhookOnCompletion(t *testing.T) {
+	quests := []game.Quest{{ID: 1, XPReward: 10}, {ID: 2, XPReward: 20}}
+	var sent [][2]int
+	report := func(questID, xp int) error {
+		sent = append(sent, [2]int{questID, xp})
+		return errors.New("connection refused")
+	}
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	m := NewModel(quests, nil, game.GameState{CurrentQuestID: 1, TotalXP: 5}, 1, Options{SkipIntro: true, OnQuestComplete: report})
+
+	// Finishing the last quest reports it, with nothing else left to run
+	updated, cmd := m.Update(questCheckMsg{idx: 1, passed: true, done: 1, total: 1})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("Expected a command to report the completion")
+	}
+	result := cmd()
+	msg, ok := result.(webhookMsg)
+	if !ok {
+		t.Fatalf("Expected the completion to be reported, got %T", result)
+	}
+	if len(sent) != 1 || sent[0] != [2]int{2, 25} {
+		t.Fatalf("Expected quest 2 reported with 25 XP, got %v", sent)
+	}
+
+	// A failure is mentioned once, not after every quest
+	updated, _ = m.Update(msg)
+	updated, _ = updated.(Model).Update(msg)
+	m = updated.(Model)
+	warned := 0
+	for _, line := range m.output {
+		if strings.Contains(line, "connection refused") {
+			warned++
+		}
+	}
+	if warned != 1 {
+		t.Errorf("Expected the failure noted once, got %d times", warned)
+	}
+
+	// Without --webhook nothing is sent
+	m = NewModel(quests, nil, game.GameState{}, 0, Options{SkipIntro: true})
+	if m.reportCompletion(1) != nil {
+		t.Error("Expected no report without a webhook")
+	}
+}
+
+func TestSudoPasswordPrompt(t *testing.T) {
+	mgr := &docker.Manager{Runtime: "false", ContainerName: "goblin-test", CurrentDir: "/home/player"}
+	m := NewModel([]game.Quest{{ID: 1}}, mgr, game.GameState{}, 0, Options{SkipIntro: true})
+	m.ready = true
+	m.viewportReady = true
+	m.width, m.height = 80, 24
+
+	updated, _ := m.Update(commandResultMsg{command: "sudo useradd glitch", err: errors.New("sudo: a password is required")})
+	m = updated.(Model)
+	if m.sudo == nil {
+		t.Fatal("Expected a password prompt")
+	}
+
+	// Typed characters go to the password, never to the screen
+	for _, key := range []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("gobx")}, {Type: tea.KeyBackspace}, {Type: tea.KeyRunes, Runes: []rune("lin")}} {
+		updated, _ = m.Update(key)
+		m = updated.(Model)
+	}
+	if m.sudo.password != "goblin" || m.input != "" || strings.Contains(m.View(), "gob") {
+		t.Errorf("Expected the password kept hidden, got %q", m.sudo.password)
+	}
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.sudo != nil || cmd == nil {
+		t.Fatal("Expected Enter to run the command with the password")
+	}
+
+	// Wrong passwords get two more tries, then sudo gives up
+	wrong := sudoResultMsg{prompt: sudoPrompt{command: "sudo useradd glitch"}, result: commandResultMsg{err: errors.New("sudo: 1 incorrect password attempt")}}
+	for attempt := 1; attempt < sudoAttempts; attempt++ {
+		updated, _ = m.Update(wrong)
+		m = updated.(Model)
+		if m.sudo == nil || m.output[len(m.output)-1] != T("sudo.try_again") {
+			t.Fatalf("attempt %d: expected to be asked again", attempt)
+		}
+		wrong.prompt = *m.sudo
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = updated.(Model)
+	}
+	updated, _ = m.Update(wrong)
+	m = updated.(Model)
+	if m.sudo != nil || m.output[len(m.output)-1] != T("cmd.error", T("sudo.gave_up", sudoAttempts)) {
+		t.Errorf("Expected sudo to give up, got %q", m.output[len(m.output)-1])
+	}
+
+	// Esc cancels without running anything
+	m.sudo = &sudoPrompt{command: "sudo ls"}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m = updated.(Model); m.sudo != nil || m.output[len(m.output)-1] != T("sudo.cancelled") {
+		t.Error("Expected Esc to cancel the prompt")
+	}
+}
+
+func TestReadsStdin(t *testing.T) {
+	cases := map[string]bool{
+		"cat > notes.txt":      true,
+		"cat >> notes.txt":     true,
+		"cat":                  true,
+		"cat - >out":           true,
+		"read name":            true,
+		"sudo chpasswd":        true,
+		"tee log.txt":          true,
+		"sort -r":              true,
+		"cat notes.txt":        false,
+		"cat notes.txt > copy": false,
+		"cat < notes.txt":      false,
+		"ls | sort":            false,
+		"echo hi > notes.txt":  false,
+		"cat > a; ls":          false,
+		"":                     false,
+	}
+	for command, want := range cases {
+		if got := readsStdin(command); got != want {
+			t.Errorf("readsStdin(%q) = %v, want %v", command, got, want)
+		}
+	}
+}
+
+func TestStdinInput(t *testing.T) {
+	dir := t.TempDir()
+	runtime := filepath.Join(dir, "runtime")
+	stdin := filepath.Join(dir, "stdin")
+	if err := os.WriteFile(runtime, []byte("#!/bin/sh\ncat > "+stdin+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := &docker.Manager{Runtime: runtime, ContainerName: "goblin-test", CurrentDir: "/home/player"}
+	m := NewModel([]game.Quest{{ID: 1}}, mgr, game.GameState{}, 0, Options{SkipIntro: true})
+	m.ready = true
+	m.width, m.height = 80, 24
+
+	m.input = "cat > notes.txt"
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.stdin == nil || cmd != nil {
+		t.Fatal("Expected the command to wait for its input")
+	}
+
+	// A typed line, a pasted block, and a last line without Enter
+	for _, key := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("gold")}, {Type: tea.KeyEnter},
+		{Type: tea.KeyRunes, Runes: []rune("silver\ncopper\ngem")},
+	} {
+		updated, _ = m.Update(key)
+		m = updated.(Model)
+	}
+	if got := m.output[len(m.output)-3:]; !slices.Equal(got, []string{"gold", "silver", "copper"}) {
+		t.Errorf("Expected typed lines echoed, got %q", got)
+	}
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	m = updated.(Model)
+	if m.stdin != nil || cmd == nil {
+		t.Fatal("Expected Ctrl+D to run the command")
+	}
+	cmd()
+	if got, _ := os.ReadFile(stdin); string(got) != "gold\nsilver\ncopper\ngem" {
+		t.Errorf("Expected the typed input on stdin, got %q", got)
+	}
+
+	// Ctrl+C drops the command without running it
+	m.input = "read name"
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updated, cmd = updated.(Model).Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if m = updated.(Model); m.stdin != nil || cmd != nil || m.output[len(m.output)-1] != "^C" {
+		t.Error("Expected Ctrl+C to cancel the input")
+	}
+}
+
+func TestFormatInventory(t *testing.T) {
+	lines := formatInventory("d /tmp/safe_house\nf /home/player/hut/bed.txt\n", docker.DefaultHome)
+	if len(lines) != 3 {
+		t.Fatalf("Expected header and 2 entries, got %v", lines)
+	}
+	if !strings.Contains(lines[1], "dir") || !strings.Contains(lines[1], "/tmp/safe_house") {
+		t.Errorf("Unexpected directory entry %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "~/hut/bed.txt") {
+		t.Errorf("Expected home paths to be shortened, got %q", lines[2])
+	}
+
+	if empty := formatInventory("", docker.DefaultHome); len(empty) != 2 {
+		t.Errorf("Expected header and empty note, got %v", empty)
+	}
+}
+
+func TestFormatTree(t *testing.T) {
+	defer SetColor(colorEnabled)
+	SetColor(false)
+
+	lines := formatTree("f notes.txt\nd hut\nf hut/bed.txt\nd hut/attic\nd camp\n")
+	want := []string{
+		"~",
+		"├── camp/",
+		"├── hut/",
+		"│   ├── attic/",
+		"│   └── bed.txt",
+		"└── notes.txt",
+		"",
+		"3 directories, 2 files",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected tree:\n%s", strings.Join(lines, "\n"))
+	}
+
+	if empty := formatTree(""); len(empty) != 2 {
+		t.Errorf("Expected the root and an empty note, got %v", empty)
+	}
+}
+
+func TestCheckTraceLine(t *testing.T) {
+	wc := game.WinCondition{Type: game.CommandOut, Command: "stat -c %a hut", Expected: "700"}
+	line := checkTraceLine(wc, game.CheckTrace{Expected: "700", Observed: "755"})
+	if line != "[check] command_output_matches stat -c %a hut expected=700 got=755 => FAIL" {
+		t.Errorf("Unexpected trace line %q", line)
+	}
+
+	wc = game.WinCondition{Type: game.FileEquals, Target: "notes", Content: "a b"}
+	line = checkTraceLine(wc, game.CheckTrace{Passed: true, Expected: "a b", Observed: "\n" + strings.Repeat("x", 100)})
+	if !strings.Contains(line, `expected="a b"`) || !strings.Contains(line, `x..."`) || !strings.HasSuffix(line, "=> PASS") {
+		t.Errorf("Expected quoted, shortened values, got %q", line)
+	}
+}
+
+func TestHardeningWarnings(t *testing.T) {
+	// A custom pack: the warnings name its quests, not the bundled ones
+	quests := []game.Quest{{ID: 4, RequiresRoot: true}, {ID: 7, NoPingWinCondition: &game.WinCondition{Type: game.FileExists}}, {ID: 9}}
+	mgr := &docker.Manager{Runtime: "true", ContainerName: "goblin-test", CurrentDir: docker.DefaultHome}
+	mgr.Harden()
+	mgr.CapAdd = nil
+	m := NewModel(quests, mgr, game.GameState{SeenOnboarding: true}, 0, Options{SkipIntro: true})
+	updated, _ := m.Update(containerReadyMsg{})
+	m = updated.(Model)
+	for _, want := range []string{T("harden.sudo", "4"), T("harden.ping", "7"), T("harden.read_only_root", docker.DefaultHome)} {
+		if !slices.Contains(m.output, T("env.harden_warning", want)) {
+			t.Errorf("Expected the warning %q, got %q", want, m.output)
+		}
+	}
+}
+
+func TestSafeModeSkipsRootQuests(t *testing.T) {
+	quests := []game.Quest{{ID: 1, Title: "Citizenship", RequiresRoot: true}, {ID: 2, Title: "Backpack"}}
+	mgr := &docker.Manager{Runtime: "true", NoRoot: true}
+	m := NewModel(quests, mgr, game.GameState{}, 0, Options{SkipIntro: true})
+
+	m.startQuest(0)
+	if m.currentQuestIdx != 1 {
+		t.Fatalf("Expected the root quest to be skipped, at index %d", m.currentQuestIdx)
+	}
+	if !strings.Contains(strings.Join(m.output, "\n"), "Skipping Quest 1") {
+		t.Errorf("Expected the skip to be announced, got %v", m.output)
+	}
+	if ids := rootQuestIDs(quests); ids != "1" {
+		t.Errorf("Expected root quest IDs %q, got %q", "1", ids)
+	}
+
+	// Completing a quest also moves past root quests that follow it
+	quests = append([]game.Quest{{ID: 0, Title: "Warm Up"}}, quests...)
+	m = NewModel(quests, mgr, game.GameState{}, 0, Options{SkipIntro: true, Demo: true})
+	updated, _ := m.Update(questCheckMsg{idx: 0, passed: true})
+	if m = updated.(Model); m.currentQuestIdx != 2 {
+		t.Errorf("Expected to advance past the root quest to index 2, got %d", m.currentQuestIdx)
+	}
+}
+
+func TestFormatUsage(t *testing.T) {
+	if bar := usageBar(50); bar != "["+strings.Repeat("#", 10)+strings.Repeat(".", 10)+"]" {
+		t.Errorf("Expected a half-full bar, got %q", bar)
+	}
+	if bar := usageBar(250); strings.Contains(bar, ".") {
+		t.Errorf("Expected multi-core CPU to clamp to full, got %q", bar)
+	}
+
+	panel := strings.Join(formatUsage(docker.ContainerStats{CPUPercent: 3.2, MemUsage: "12MiB", MemLimit: "512MiB", MemPercent: 2.3, PIDs: 4}), "\n")
+	for _, want := range []string{"3.2%", "12MiB / 512MiB", "Processes  4"} {
+		if !strings.Contains(panel, want) {
+			t.Errorf("Expected %q in the usage panel:\n%s", want, panel)
+		}
+	}
+}
+
+func TestParseScript(t *testing.T) {
+	got := ParseScript("# setup\nmkdir hut\n\n  cd hut  \n#cd /\n")
+	if strings.Join(got, "|") != "mkdir hut|cd hut" {
+		t.Errorf("Expected comments and blank lines to be skipped, got %q", got)
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	// "true" stands in for the runtime, so every container call succeeds silently
+	mgr := &docker.Manager{Runtime: "true", ContainerName: "goblin-test", GatewayName: "goblin-test_gateway",
+		NetworkName: "net", Subnet: docker.DefaultSubnet, GatewayIP: docker.DefaultGatewayIP, PlayerIP: docker.DefaultPlayerIP,
+		StoragePath: t.TempDir(), CurrentDir: "/home/player"}
+	quests := []game.Quest{
+		{ID: 1, Title: "Home", WinCondition: game.WinCondition{Type: game.CurrentDirMatch, Target: "/home/player"}},
+		{ID: 2, Title: "Never", WinCondition: game.WinCondition{Type: game.UserOutputContains, Expected: "unreachable"}},
+	}
+
+	var out strings.Builder
+	idx, err := RunScript(NewModel(quests, mgr, game.GameState{}, 0, Options{}), []string{"pwd", "ls"}, &out)
+	if err != nil {
+		t.Fatalf("RunScript failed: %v", err)
+	}
+	if idx != 1 {
+		t.Errorf("Expected to finish on quest index 1, got %d", idx)
+	}
+	if !strings.Contains(out.String(), "QUEST COMPLETE") || !strings.Contains(out.String(), "ls") {
+		t.Errorf("Expected the run to be printed, got:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "\x1b[") {
+		t.Error("Expected plain output without escape codes")
+	}
+}
+
+func TestStyleStoryLineInlineCode(t *testing.T) {
+	defer SetColor(colorEnabled)
+	SetColor(false)
+
+	cases := map[string]string{
+		"<'.'> \"Run `chmod 700 hut` now!\"": "<'.'> \"Run chmod 700 hut now!\"",
+		"Try `ls` then `cd hut`.":            "Try ls then cd hut.",
+		"No code here.":                      "No code here.",
+		"A stray ` backtick":                 "A stray ` backtick",
+	}
+	for in, want := range cases {
+		if got := styleStoryLine(in); got != want {
+			t.Errorf("styleStoryLine(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	// With color on, the code span is styled apart from the prose
+	lipgloss.SetColorProfile(termenv.TrueColor)
+	got := styleStoryLine("Run `ls` now")
+	if !strings.Contains(got, "\x1b[") || strings.Contains(got, "`") {
+		t.Errorf("Expected a styled span without backticks, got %q", got)
+	}
+}
+
+func TestChallengeRetry(t *testing.T) {
+	mgr := &docker.Manager{Runtime: "true", CurrentDir: "/home/player"}
+	quests := []game.Quest{{ID: 1, Title: "Speedrun", TimeLimitSeconds: 30, MaxAttempts: 2}}
+	m := NewModel(quests, mgr, game.GameState{}, 0, Options{SkipIntro: true})
+	m.startQuest(0)
+
+	if left, ok := m.timeLeft(); !ok || left <= 0 || left > 30*time.Second {
+		t.Fatalf("Expected a running countdown, got %v (%v)", left, ok)
+	}
+
+	gen := m.pollGen
+	updated, cmd := m.Update(timeLimitMsg{gen: gen})
+	m = updated.(Model)
+	if cmd == nil || m.attempt != 2 {
+		t.Fatalf("Expected a reset and a second attempt, got attempt %d", m.attempt)
+	}
+	if !strings.Contains(strings.Join(m.output, "\n"), "Attempt 2 of 2") {
+		t.Errorf("Expected the attempt to be announced, got %v", m.output)
+	}
+
+	// The first attempt's timer is stale now
+	if updated, _ := m.Update(timeLimitMsg{gen: gen}); updated.(Model).attempt != 2 {
+		t.Error("Expected a stale expiry to be ignored")
+	}
+
+	updated, _ = m.Update(timeLimitMsg{gen: m.pollGen})
+	m = updated.(Model)
+	if !m.outOfAttempts || m.attempt != 2 {
+		t.Errorf("Expected the clock to stop after the last attempt, got attempt %d", m.attempt)
+	}
+	if _, ok := m.timeLeft(); ok {
+		t.Error("Expected no countdown once out of attempts")
+	}
+
+	// Authors can have the player confirm each retry
+	quests[0].ConfirmRetry = true
+	m = NewModel(quests, mgr, game.GameState{}, 0, Options{SkipIntro: true})
+	m.startQuest(0)
+	updated, _ = m.Update(timeLimitMsg{gen: m.pollGen})
+	if m = updated.(Model); m.dialog == nil || m.attempt != 1 {
+		t.Errorf("Expected a retry prompt before resetting, got dialog %v at attempt %d", m.dialog, m.attempt)
+	}
+}
+
+func TestConfirmDialog(t *testing.T) {
+	m := NewModel([]game.Quest{{ID: 1}}, nil, game.GameState{}, 0, Options{})
+	m.ready = true
+
+	answered := ""
+	yes := func(m Model) (Model, tea.Cmd) { answered = "yes"; return m, nil }
+	no := func(m Model) (Model, tea.Cmd) { answered = "no"; return m, nil }
+
+	m = m.confirm("Sure?", yes, no)
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = updated.(Model)
+	if m.dialog == nil || answered != "" {
+		t.Fatal("Expected unrelated keys to leave the dialog open")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if m.dialog != nil || answered != "no" {
+		t.Errorf("Expected Esc to answer no, got %q", answered)
+	}
+
+	m = m.confirm("Sure?", yes, nil)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	m = updated.(Model)
+	if m.dialog != nil || answered != "yes" {
+		t.Errorf("Expected y to answer yes, got %q", answered)
+	}
+}
+
+func TestBannerLines(t *testing.T) {
+	m := NewModel([]game.Quest{{ID: 1}}, nil, game.GameState{}, 0, Options{})
+	art := "/\\\n\\/\n"
+
+	m.width = 12
+	lines := m.bannerLines(art)
+	if len(lines) != 3 {
+		t.Fatalf("Expected 2 art lines and a spacer, got %v", lines)
+	}
+	if !strings.HasPrefix(lines[0], "    ") {
+		t.Errorf("Expected art to be centered, got %q", lines[0])
+	}
+
+	m.width = 3
+	if lines := m.bannerLines(art); lines != nil {
+		t.Errorf("Expected banner to be skipped on a narrow terminal, got %v", lines)
+	}
+
+	if lines := m.bannerLines(""); lines != nil {
+		t.Errorf("Expected no lines without a banner, got %v", lines)
+	}
+}
+
+func TestOnboardingShownOnce(t *testing.T) {
+	// Dismissing saves the state, so keep it out of the real config dir
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	m := NewModel([]game.Quest{{ID: 1}}, nil, game.GameState{}, 0, Options{})
+	if !m.onboarding {
+		t.Fatal("Expected onboarding on first launch")
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = updated.(Model)
+	if m.onboarding || !m.state.SeenOnboarding {
+		t.Error("Expected a keypress to dismiss onboarding and remember it")
+	}
+	if m.input != "" {
+		t.Errorf("Expected the dismissing key not to be typed, got %q", m.input)
+	}
+
+	if NewModel([]game.Quest{{ID: 1}}, nil, m.state, 0, Options{}).onboarding {
+		t.Error("Expected onboarding to be skipped once seen")
+	}
+	if NewModel([]game.Quest{{ID: 1}}, nil, game.GameState{}, 0, Options{SkipIntro: true}).onboarding {
+		t.Error("Expected --skip-intro to suppress onboarding")
+	}
+}
+
+func TestLeaderboardAfterFullRun(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	m := NewModel([]game.Quest{{ID: 1, XPReward: 50}}, nil, game.GameState{}, 0, Options{SkipIntro: true, Profile: "gob"})
+	m.fullRun = true
+	m.gameStart = time.Now().Add(-2 * time.Minute)
+	updated, _ := m.Update(questCheckMsg{idx: 0, passed: true})
+	m = updated.(Model)
+	if !slices.Contains(m.output, T("leaderboard.rank", 1)) {
+		t.Fatalf("Expected the run to place first, got %q", m.output)
+	}
+
+	entries, err := game.LoadLeaderboard()
+	if err != nil || len(entries) != 1 || entries[0].Profile != "gob" || entries[0].XP != 50 {
+		t.Fatalf("Expected the run saved with its XP, got %+v, %v", entries, err)
+	}
+
+	m.input = "leaderboard"
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if last := ansi.Strip(m.output[len(m.output)-1]); !strings.Contains(last, "gob") || !strings.Contains(last, "02:00") {
+		t.Errorf("Expected the leaderboard command to list the run, got %q", last)
+	}
+}
+
+func TestAskGlitch(t *testing.T) {
+	cases := map[string]string{
+		"why is my permission denied?": "ask.permission",
+		"How do I use SUDO":            "ask.sudo",
+		"what's an absolute path":      "ask.path",
+		"where am i":                   "ask.directory",
+		"what is the meaning of life":  "ask.unknown",
+	}
+	for question, key := range cases {
+		if got := askGlitch(question); got != T(key) {
+			t.Errorf("askGlitch(%q) = %q, want the %s reply", question, got, key)
+		}
+	}
+
+	mgr := &docker.Manager{Runtime: "false", ContainerName: "goblin-test", CurrentDir: docker.DefaultHome}
+	m := NewModel([]game.Quest{{ID: 1}}, mgr, game.GameState{}, 0, Options{SkipIntro: true})
+	m.input = "ask what does chmod do"
+	updated, _ := m.submitInput()
+	m = updated.(Model)
+	if m.glitchText != T("ask.permission") {
+		t.Errorf("Expected Glitch to answer in its box, got %q", m.glitchText)
+	}
+	if len(m.state.MsgLog) != 0 {
+		t.Error("Expected small talk to stay out of the lore log")
+	}
+
+	m.hardMode = true
+	m.input = "ask what does chmod do"
+	updated, _ = m.submitInput()
+	m = updated.(Model)
+	if got := m.output[len(m.output)-1]; got != T("ask.hard_mode") {
+		t.Errorf("Expected no answers in Hard Mode, got %q", got)
+	}
+}
+
+func TestWipesHome(t *testing.T) {
+	home := docker.DefaultHome
+	cases := []struct {
+		command, dir string
+		want         bool
+	}{
+		{"rm -rf ~", "/tmp", true},
+		{"rm -r ~/*", "/tmp", true},
+		{"sudo rm -rf /", "/tmp", true},
+		{"rm --recursive /home/player/", "/tmp", true},
+		{"rm -rf *", home, true},
+		{"rm -rf *", home + "/hut", false},
+		{"rm -rf hut", home, false},
+		{"rm -f ~/notes.txt", home, false},
+		{"rm ~", home, false},
+	}
+	for _, tc := range cases {
+		if got := wipesHome(tc.command, home, tc.dir); got != tc.want {
+			t.Errorf("wipesHome(%q) in %s = %v, want %v", tc.command, tc.dir, got, tc.want)
+		}
+	}
+}
+
+func TestDifficulty(t *testing.T) {
+	mgr := &docker.Manager{Runtime: "false", ContainerName: "goblin-test", CurrentDir: docker.DefaultHome}
+	quests := []game.Quest{{ID: 1, Objective: "Make a hut", Hint: "mkdir hut"}}
+	easy := game.Difficulties[game.DifficultyEasy]
+	m := NewModel(quests, mgr, game.GameState{}, 0, Options{SkipIntro: true, Difficulty: easy})
+	m.ready, m.viewportReady, m.gameStarted = true, true, true
+	m.width, m.height = 120, 30
+
+	if !strings.Contains(m.View(), T("difficulty.easy")) {
+		t.Error("Expected the difficulty in the header")
+	}
+
+	// Easy hints are free
+	m.input = "hint"
+	updated, _ := m.submitInput()
+	m = updated.(Model)
+	if m.state.SpentXP != 0 || m.output[len(m.output)-1] != T("hint.text", "mkdir hut") {
+		t.Errorf("Expected a free hint, spent %d XP and got %q", m.state.SpentXP, m.output[len(m.output)-1])
+	}
+
+	// The guard asks first, and no runs nothing
+	m.input = "rm -rf ~"
+	updated, cmd := m.submitInput()
+	m = updated.(Model)
+	if m.dialog == nil || cmd != nil {
+		t.Fatal("Expected the guard to ask before wiping home")
+	}
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	m = updated.(Model)
+	if cmd != nil || m.output[len(m.output)-1] != T("guard.cancelled") {
+		t.Errorf("Expected the command cancelled, got %q", m.output[len(m.output)-1])
+	}
+
+	// Hard is Hard Mode, without the guard
+	m = NewModel(quests, mgr, game.GameState{}, 0, Options{SkipIntro: true, Difficulty: game.Difficulties[game.DifficultyHard]})
+	if !m.hardMode {
+		t.Error("Expected hard difficulty to turn on Hard Mode")
+	}
+	m.input = "rm -rf ~"
+	updated, cmd = m.submitInput()
+	if updated.(Model).dialog != nil || cmd == nil {
+		t.Error("Expected hard difficulty to run the command without asking")
+	}
+
+	// Hard Mode over a saved easy level plays, and shows, hard
+	m = NewModel(quests, mgr, game.GameState{}, 0, Options{SkipIntro: true, HardMode: true, Difficulty: easy})
+	m.ready, m.viewportReady, m.gameStarted = true, true, true
+	m.width, m.height = 120, 30
+	if view := m.View(); !m.hardMode || !strings.Contains(view, T("difficulty.hard")) || strings.Contains(view, T("difficulty.easy")) {
+		t.Error("Expected --hard to play and show the hard level")
+	}
+}
+
+func TestRunningCommand(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "runtime")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncase \"$*\" in *kill*) exit 0;; esac\nexec sleep 5\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := &docker.Manager{Runtime: script, ContainerName: "goblin-test", CurrentDir: docker.DefaultHome, CommandTimeout: time.Minute}
+	m := NewModel([]game.Quest{{ID: 1}}, mgr, game.GameState{}, 0, Options{SkipIntro: true})
+	m.ready, m.viewportReady = true, true
+	m.width, m.height = 80, 24
+
+	m.input = "sleep 5"
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.running == nil || !strings.Contains(m.View(), "running sleep 5...") {
+		t.Fatal("Expected Glitch's box to show the command running")
+	}
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok || len(batch) != 2 {
+		t.Fatalf("Expected the command and a spinner tick, got %#v", batch)
+	}
+
+	// The spinner moves on, and stale ticks from an older run don't
+	updated, _ = m.Update(spinnerMsg{gen: m.running.gen})
+	m = updated.(Model)
+	if m.running.frame != 1 {
+		t.Errorf("Expected the spinner to advance, got frame %d", m.running.frame)
+	}
+	if _, tick := m.Update(spinnerMsg{gen: m.running.gen - 1}); tick != nil {
+		t.Error("Expected a stale spinner tick to stop")
+	}
+
+	// Typing ahead is fine, but Enter waits for the running command
+	m.input = "ls"
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.input != "ls" {
+		t.Error("Expected Enter to be ignored while a command runs")
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	m = updated.(Model)
+	if m.shuttingDown {
+		t.Fatal("Expected Ctrl+C to cancel the command, not quit")
+	}
+	start := time.Now()
+	msg := batch[0]()
+	if time.Since(start) > 2*time.Second {
+		t.Error("Expected cancelling to stop the command")
+	}
+	updated, _ = m.Update(msg)
+	m = updated.(Model)
+	if m.running != nil || m.output[len(m.output)-1] != T("command.cancelled") {
+		t.Errorf("Expected the cancel reported and the prompt free, got %q", m.output[len(m.output)-1])
+	}
+}
+
+func TestSecretInput(t *testing.T) {
+	mgr := &docker.Manager{Runtime: "false", ContainerName: "goblin-test", CurrentDir: docker.DefaultHome}
+	m := NewModel([]game.Quest{{ID: 1}}, mgr, game.GameState{}, 0, Options{SkipIntro: true})
+	m.ready, m.viewportReady = true, true
+	m.width, m.height = 80, 24
+
+	m.input = "secret"
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("flag{x}")})
+	m = updated.(Model)
+	if m.input != "flag{x}" {
+		t.Errorf("Expected the real text kept for running, got %q", m.input)
+	}
+	view := m.View()
+	if strings.Contains(view, "flag{") || !strings.Contains(view, "•••••••") || !strings.Contains(view, T("secret.indicator")) {
+		t.Error("Expected the input line masked and marked as hidden")
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if m.masked || slices.Contains(m.history, "flag{x}") {
+		t.Error("Expected masking to end after one line, without saving it to history")
+	}
+	for _, line := range m.output {
+		if strings.Contains(line, "flag{") {
+			t.Fatalf("Expected the secret kept out of the scrollback, got %q", line)
+		}
+	}
+
+	if m.running == nil || strings.Contains(m.View(), "flag{") {
+		t.Error("Expected the secret masked in Glitch's box while it runs")
+	}
+	m.a11y = true
+	if strings.Contains(m.View(), "flag{") {
+		t.Error("Expected the secret masked in the accessible view while it runs")
+	}
+	m.a11y = false
+
+	// The command finishes before the next one is typed
+	updated, _ = m.Update(cmd().(tea.BatchMsg)[0]())
+	m = updated.(Model)
+
+	// Password commands mask their input automatically
+	m.input = "sudo chpasswd"
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	for _, key := range []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("glitch:pw")}, {Type: tea.KeyEnter}} {
+		updated, _ = m.Update(key)
+		m = updated.(Model)
+	}
+	if got := m.output[len(m.output)-1]; got != "•••••••••" {
+		t.Errorf("Expected the password line masked, got %q", got)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if updated.(Model).masked {
+		t.Error("Expected masking to end with the command")
+	}
+}
+
+func TestSetupFailureReported(t *testing.T) {
+	dir := t.TempDir()
+	runtime := filepath.Join(dir, "runtime")
+	// The broken step fails like a real one would; everything else prints its command
+	body := "#!/bin/sh\ncase \"$*\" in *broken*) echo 'chgrp: invalid group' >&2; exit 1;; esac\necho ok\n"
+	if err := os.WriteFile(runtime, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := &docker.Manager{Runtime: runtime, ContainerName: "goblin-test", CurrentDir: docker.DefaultHome, NoRoot: true}
+	q := game.Quest{ID: 1, SetupCommands: []string{"mkdir -p camp", "chgrp broken camp"}}
+
+	m := NewModel([]game.Quest{q}, mgr, game.GameState{}, 0, Options{SkipIntro: true})
+	updated, _ := m.Update(m.performQuestSetup(q)())
+	m = updated.(Model)
+	got := m.output[len(m.output)-1]
+	if !strings.Contains(got, "chgrp broken camp") || !strings.Contains(got, "invalid group") {
+		t.Errorf("Expected the failed setup step reported, got %q", got)
+	}
+
+	m = NewModel([]game.Quest{q}, mgr, game.GameState{}, 0, Options{SkipIntro: true, VerboseSetup: true})
+	updated, _ = m.Update(m.performQuestSetup(q)())
+	m = updated.(Model)
+	want := []string{"[setup] $ mkdir -p camp", "[setup]   ok", "[setup] $ chgrp broken camp"}
+	if got := m.output[len(m.output)-4 : len(m.output)-1]; !slices.Equal(got, want) {
+		t.Errorf("Expected every step shown, got %q", got)
+	}
+	if got := m.output[len(m.output)-1]; !strings.HasPrefix(got, "[setup] failed:") {
+		t.Errorf("Expected the failure shown, got %q", got)
+	}
+}
+
+func TestNearMissNudge(t *testing.T) {
+	runtime := filepath.Join(t.TempDir(), "runtime")
+	// The container is running; nothing else is asked
+	if err := os.WriteFile(runtime, []byte("#!/bin/sh\necho true\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := &docker.Manager{Runtime: runtime, ContainerName: "goblin-test", CurrentDir: docker.DefaultHome}
+	quests := []game.Quest{{ID: 1, WinCondition: game.WinCondition{Type: game.UserOutputMatch, Expected: "hello goblin"}}}
+	for _, hard := range []bool{false, true} {
+		m := NewModel(quests, mgr, game.GameState{}, 0, Options{SkipIntro: true, HardMode: hard})
+		m.glitchText = "<'.'> ..."
+		m.lastOutput = "Hello Goblin"
+		updated, _ := m.Update(m.checkWinCondition()())
+		m = updated.(Model)
+		if m.currentQuestIdx != 0 {
+			t.Fatal("Expected a near miss to still fail the check")
+		}
+		nudged := m.glitchText == T("nearmiss.case")
+		if nudged == hard {
+			t.Errorf("Hard Mode %v: nudged = %v, glitch says %q", hard, nudged, m.glitchText)
+		}
+	}
+}
+
+func TestRetryCheck(t *testing.T) {
+	dir := t.TempDir()
+	runtime := filepath.Join(dir, "runtime")
+	written := filepath.Join(dir, "written")
+	// The file "appears" once the marker exists on the host
+	log := filepath.Join(dir, "log")
+	body := "#!/bin/sh\necho \"$*\" >> " + log + "\nif [ \"$1\" = container ]; then echo true; elif [ -e " + written + " ]; then echo yes; fi\n"
+	if err := os.WriteFile(runtime, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := &docker.Manager{Runtime: runtime, ContainerName: "goblin-test", CurrentDir: "/home/player"}
+	quests := []game.Quest{{ID: 1, WinCondition: game.WinCondition{Type: game.FileExists, Target: "notes.txt"}}, {ID: 2}}
+	m := NewModel(quests, mgr, game.GameState{}, 0, Options{SkipIntro: true})
+	m.ready = true
+
+	m.input = "retry"
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	updated, _ = m.Update(cmd())
+	m = updated.(Model)
+	if m.currentQuestIdx != 0 || m.output[len(m.output)-1] != T("retry.not_yet") {
+		t.Fatalf("Expected a failed retry to say so, got %q", m.output[len(m.output)-1])
+	}
+
+	// The file finished writing after the first check
+	os.WriteFile(written, nil, 0644)
+	m.input = "retry"
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	updated, _ = m.Update(cmd())
+	if m = updated.(Model); m.currentQuestIdx != 1 {
+		t.Error("Expected retry to complete the quest once the state is right")
+	}
+	if data, _ := os.ReadFile(log); strings.Contains(string(data), "retry") {
+		t.Error("Expected retry not to run anything in the container")
+	}
+}
+
+func TestPollOnlyForCurrentQuest(t *testing.T) {
+	quests := []game.Quest{{ID: 1, PollIntervalSeconds: 5}, {ID: 2}}
+	m := NewModel(quests, nil, game.GameState{}, 0, Options{SkipIntro: true})
+
+	if m.schedulePoll(quests[1]) != nil {
+		t.Error("Expected no polling when the interval is unset")
+	}
+	if m.schedulePoll(quests[0]) == nil {
+		t.Error("Expected a poll to be scheduled")
+	}
+
+	m.pollGen = 2
+	if _, cmd := m.Update(pollMsg{gen: 1}); cmd != nil {
+		t.Error("Expected a stale poll to stop")
+	}
+	if _, cmd := m.Update(pollMsg{gen: 2}); cmd == nil {
+		t.Error("Expected the current poll to check and reschedule")
+	}
+
+	// A second passing check for an already completed quest is ignored
+	m.currentQuestIdx = 1
+	updated, _ := m.Update(questCheckMsg{idx: 0, passed: true})
+	if got := updated.(Model).currentQuestIdx; got != 1 {
+		t.Errorf("Expected duplicate completion to be ignored, now on quest index %d", got)
+	}
+}
+
+func TestScrollbackSearch(t *testing.T) {
+	m := NewModel([]game.Quest{{ID: 1}}, nil, game.GameState{}, 0, Options{SkipIntro: true})
+	m.ready = true
+	m.output = []string{"Error: first", "ok", "\x1b[1mError: second\x1b[0m", "ok"}
+
+	send := func(msg tea.KeyMsg) {
+		updated, _ := m.Update(msg)
+		m = updated.(Model)
+	}
+	send(tea.KeyMsg{Type: tea.KeyCtrlF})
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Err.r")})
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.input != "" {
+		t.Errorf("Expected search keys not to reach the prompt, got %q", m.input)
+	}
+	if got := m.searchLine(); got != 2 {
+		t.Fatalf("Expected to start at the newest match, got line %d", got)
+	}
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if got := m.searchLine(); got != 0 {
+		t.Errorf("Expected n to move to the older match, got line %d", got)
+	}
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("N")})
+	if got := m.searchLine(); got != 2 {
+		t.Errorf("Expected N to move back to the newer match, got line %d", got)
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.search != nil {
+		t.Error("Expected Esc to close the search")
+	}
+
+	// '/' opens the search too, but only on an empty prompt
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	if m.search == nil || m.input != "" {
+		t.Fatalf("Expected / to open the search, got input %q", m.input)
+	}
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("second")})
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.searchLine(); got != 2 {
+		t.Errorf("Expected / to search like Ctrl+F, got line %d", got)
+	}
+	send(tea.KeyMsg{Type: tea.KeyEsc})
+	for _, r := range "ls /" {
+		send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if m.search != nil || m.input != "ls /" {
+		t.Errorf("Expected / to be typed mid-command, got input %q", m.input)
+	}
+
+	// Invalid regex falls back to a literal match
+	if got := findMatches([]string{"a[b", "ab"}, "a[b"); len(got) != 1 || got[0] != 0 {
+		t.Errorf("Expected literal fallback match, got %v", got)
+	}
+}
+
+func TestCheckReasonNudgesOnce(t *testing.T) {
+	m := NewModel([]game.Quest{{ID: 1}}, nil, game.GameState{}, 0, Options{SkipIntro: true})
+	before := len(m.output)
+
+	msg := questCheckMsg{idx: 0, reason: game.ReasonScriptNotExecutable}
+	updated, _ := m.Update(msg)
+	updated, _ = updated.(Model).Update(msg)
+	m = updated.(Model)
+
+	if got := len(m.output) - before; got != 1 {
+		t.Errorf("Expected one nudge for a repeated reason, got %d lines", got)
+	}
+}
+
+func TestInputWindow(t *testing.T) {
+	if got := inputWindow("ls -la", 20); got != "ls -la" {
+		t.Errorf("Expected short input unchanged, got %q", got)
+	}
+
+	got := inputWindow("echo "+strings.Repeat("x", 100)+" end", 20)
+	if lipgloss.Width(got) != 20 {
+		t.Errorf("Expected the window to fill exactly 20 cells, got %d (%q)", lipgloss.Width(got), got)
+	}
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, " end") {
+		t.Errorf("Expected the clipped tail of the input, got %q", got)
+	}
+
+	m := NewModel([]game.Quest{{ID: 1}}, nil, game.GameState{}, 0, Options{SkipIntro: true})
+	m.ready = true
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("echo a\necho b")})
+	if got := updated.(Model).input; got != "echo a echo b" {
+		t.Errorf("Expected pasted newlines to become spaces, got %q", got)
+	}
+}
+
+func TestShellRequiresFlag(t *testing.T) {
+	mgr := &docker.Manager{Runtime: "true", ContainerName: "goblin-test", CurrentDir: "/home/player"}
+	m := NewModel([]game.Quest{{ID: 1}}, mgr, game.GameState{}, 0, Options{SkipIntro: true})
+	m.ready = true
+	m.input = "!shell"
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if cmd != nil {
+		t.Error("Expected no shell without --allow-shell")
+	}
+	if last := m.output[len(m.output)-1]; last != T("shell.disabled") {
+		t.Errorf("Expected the disabled notice, got %q", last)
+	}
+
+	m.allowShell = true
+	m.input = "!shell"
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter}); cmd == nil {
+		t.Error("Expected the shell to be launched when allowed")
+	}
+}
+
+func TestBuffersAreCapped(t *testing.T) {
+	m := NewModel([]game.Quest{{ID: 1}}, nil, game.GameState{}, 0, Options{SkipIntro: true, MaxOutputLines: 10, MaxHistory: 3})
+	m.height = 4
+	for i := 0; i < 20; i++ {
+		m.output = append(m.output, fmt.Sprintf("line %d", i))
+		m.history = append(m.history, fmt.Sprintf("cmd %d", i))
+	}
+	m.historyIdx = len(m.history)
+	m.lastOutput = "line 0"
+	m.search = &scrollSearch{pattern: "line 1", matches: []int{2, 11, 20}, current: 2}
+
+	updated, _ := m.Update(nil)
+	m = updated.(Model)
+
+	if len(m.output) != 10 || m.output[9] != "line 19" {
+		t.Errorf("Expected the newest 10 output lines, got %v", m.output)
+	}
+	if len(m.history) != 3 || m.history[0] != "cmd 17" || m.historyIdx != 3 {
+		t.Errorf("Expected the newest 3 history entries, got %v (idx %d)", m.history, m.historyIdx)
+	}
+	if m.lastOutput != "line 0" {
+		t.Error("Expected lastOutput to be left alone")
+	}
+	if got := m.searchLine(); got != 9 {
+		t.Errorf("Expected the search to stay on the same line, got index %d", got)
+	}
+
+	// The cap never cuts into the visible screen
+	m.height = 15
+	for i := 0; i < 20; i++ {
+		m.output = append(m.output, "more")
+	}
+	updated, _ = m.Update(nil)
+	if got := len(updated.(Model).output); got != 15 {
+		t.Errorf("Expected the cap to grow to the screen height, got %d lines", got)
+	}
+}
+
+func TestQuestSecretRerolledOnReset(t *testing.T) {
+	m := NewModel([]game.Quest{{ID: 1}}, nil, game.GameState{}, 0, Options{SkipIntro: true})
+
+	m.startQuest(0)
+	first := m.secrets[1]
+	m.startQuest(0)
+	if first == "" || m.secrets[1] == first {
+		t.Errorf("Expected a fresh secret per quest start, got %q then %q", first, m.secrets[1])
+	}
+}
+
+func TestRedoSetupListsCommands(t *testing.T) {
+	mgr := &docker.Manager{Runtime: "true", ContainerName: "goblin-test", CurrentDir: "/home/player"}
+	quests := []game.Quest{{ID: 1, SetupCommands: []string{"mkdir -p hut", "echo '{{secret}}' > hut/flag"}}}
+	m := NewModel(quests, mgr, game.GameState{}, 0, Options{SkipIntro: true})
+	m.ready = true
+	m.secrets = map[int]string{1: "GOBLIN-TEST"}
+	m.input = "redo-setup"
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if cmd == nil {
+		t.Fatal("Expected setup to be scheduled")
+	}
+	if last := m.output[len(m.output)-1]; last != "  echo 'GOBLIN-TEST' > hut/flag" {
+		t.Errorf("Expected the commands to be listed with the current secret, got %q", last)
+	}
+}
+
+func TestDebugDiffShownOnce(t *testing.T) {
+	defer SetColor(colorEnabled)
+	SetColor(false)
+
+	m := NewModel([]game.Quest{{ID: 1}}, nil, game.GameState{}, 0, Options{SkipIntro: true, Debug: true})
+	before := len(m.output)
+	msg := questCheckMsg{idx: 0, target: "note.txt", diff: game.DiffLines("hello\n", "hello \n")}
+
+	updated, _ := m.Update(msg)
+	updated, _ = updated.(Model).Update(msg)
+	m = updated.(Model)
+
+	added := m.output[before:]
+	if len(added) != 3 {
+		t.Fatalf("Expected a header and two diff lines once, got %v", added)
+	}
+	if added[2] != "+ hello·" {
+		t.Errorf("Expected trailing whitespace to be visible, got %q", added[2])
+	}
+}
+
+func TestAutosaveSkipsUnchangedState(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	path, err := game.GetSavePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewModel([]game.Quest{{ID: 1}}, nil, game.GameState{}, 0, Options{SkipIntro: true, AutosaveInterval: time.Second})
+	if m.scheduleAutosave() == nil {
+		t.Fatal("Expected autosave to be scheduled")
+	}
+
+	updated, _ := m.Update(autosaveMsg{})
+	m = updated.(Model)
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Expected the first autosave to write, got %v", err)
+	}
+
+	os.Remove(path)
+	updated, _ = m.Update(autosaveMsg{})
+	m = updated.(Model)
+	if _, err := os.Stat(path); err == nil {
+		t.Error("Expected no write when nothing changed")
+	}
+
+	m.state.AwardXP(10)
+	m.Update(autosaveMsg{})
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected a changed state to be saved, got %v", err)
+	}
+}
+
+func TestSideLayoutFitsScreen(t *testing.T) {
+	mgr := &docker.Manager{CurrentDir: "/home/player"}
+	m := NewModel([]game.Quest{{ID: 1, Objective: "Run pwd"}}, mgr, game.GameState{}, 0, Options{SkipIntro: true, Layout: LayoutSide})
+	m.viewportReady = true
+	m.glitchText = strings.Repeat("A long intro line from Glitch.\n", 40)
+
+	for _, width := range []int{120, 80} {
+		m.width, m.height = width, 30
+		if width == 80 {
+			// The bottom layout grows with the intro, so keep it short there
+			m.glitchText = "Hello!"
+		}
+		view := m.View()
+		if h := lipgloss.Height(view); h != 30 {
+			t.Errorf("width %d: expected 30 rows, got %d", width, h)
+		}
+		if w := lipgloss.Width(view); w > width {
+			t.Errorf("width %d: view is %d cells wide", width, w)
+		}
+	}
+
+	m.width = 120
+	if !m.sideLayout() {
+		t.Error("Expected the side layout on a wide terminal")
+	}
+	m.width = 80
+	if m.sideLayout() {
+		t.Error("Expected a narrow terminal to fall back to the bottom layout")
+	}
+}
+
+func TestAutoHintAfterStuckCommands(t *testing.T) {
+	quests := []game.Quest{{ID: 1, Objective: "Run pwd", Hint: "Try pwd"}}
+	for _, hard := range []bool{false, true} {
+		m := NewModel(quests, nil, game.GameState{}, 0, Options{SkipIntro: true, AutoHintAfter: 2, HardMode: hard})
+		m.glitchText = "intro"
+
+		for i := 0; i < 2; i++ {
+			updated, _ := m.Update(commandResultMsg{output: "nope\n"})
+			updated, _ = updated.(Model).Update(questCheckMsg{idx: 0})
+			m = updated.(Model)
+			if i == 0 && m.glitchText != "intro" {
+				t.Fatalf("Expected no hint after one command, got %q", m.glitchText)
+			}
+		}
+
+		gotHint := strings.Contains(m.glitchText, "Try pwd")
+		if gotHint == hard {
+			t.Errorf("hard=%v: expected hint shown=%v, got glitch text %q", hard, !hard, m.glitchText)
+		}
+	}
+}
+
+func TestExitReportsCleanupFailure(t *testing.T) {
+	mgr := &docker.Manager{Runtime: "false", ContainerName: "goblin-test", GatewayName: "goblin-test_gateway", CurrentDir: "/home/player"}
+	m := NewModel(nil, mgr, game.GameState{}, 0, Options{SkipIntro: true})
+	m.ready = true
+	m.input = "exit"
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if cmd == nil || m.output[len(m.output)-1] != T("env.removing") {
+		t.Fatalf("Expected cleanup to be started, got %q", m.output[len(m.output)-1])
+	}
+
+	// Quitting waits for the cleanup result rather than racing it
+	msg := cmd()
+	if _, ok := msg.(shutdownMsg); !ok {
+		t.Fatalf("Expected the cleanup result, got %T", msg)
+	}
+	updated, _ = m.Update(msg)
+	m = updated.(Model)
+	if m.ShutdownError() == nil {
+		t.Fatal("Expected the StopContainer error to be kept for after exit")
+	}
+	if last := m.output[len(m.output)-1]; !strings.Contains(last, "goblin-test_gateway") {
+		t.Errorf("Expected the failure to be shown, got %q", last)
+	}
+}
+
+func TestSaveTranscript(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	mgr := &docker.Manager{Runtime: "false", ContainerName: "goblin-test", CurrentDir: "/home/player"}
+	m := NewModel(nil, mgr, game.GameState{}, 0, Options{SkipIntro: true, Transcript: true})
+	m.ready = true
+	m.output = append(m.output, "\x1b[1mloot\x1b[0m")
+
+	m.input = "save-transcript"
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if last := m.output[len(m.output)-1]; last != T("transcript.usage") {
+		t.Errorf("Expected usage without a name, got %q", last)
+	}
+
+	m.input = "save-transcript study"
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	path := filepath.Join(dir, "goblin-terminal", "transcripts", "study.txt")
+	if last := m.output[len(m.output)-1]; last != T("transcript.saved", path) {
+		t.Fatalf("Expected the transcript to be saved, got %q", last)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "loot\n") || strings.Contains(string(data), "\x1b") {
+		t.Errorf("Expected plain text output, got %q", data)
+	}
+
+	// --transcript saves one more on the way out
+	m.input = "exit"
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 2 {
+		t.Errorf("Expected the session transcript on exit, got %d files", len(entries))
+	}
+}
+
+func TestNewQuestsAfterUpdate(t *testing.T) {
+	quests := []game.Quest{{ID: 1, Title: "One"}, {ID: 2, Title: "Two"}, {ID: 3, Title: "Three"}}
+	state := game.GameState{CurrentQuestID: 2, QuestCount: 2, TotalXP: 50, SeenOnboarding: true}
+	mgr := &docker.Manager{Runtime: "true", ContainerName: "goblin-test", CurrentDir: "/home/player", NoRoot: true}
+
+	m := NewModel(quests, mgr, state, state.ResumeIndex(len(quests)), Options{SkipIntro: true})
+	updated, _ := m.Update(containerReadyMsg{})
+	m = updated.(Model)
+
+	if m.currentQuestIdx != 2 {
+		t.Errorf("Expected to resume at the first new quest, got index %d", m.currentQuestIdx)
+	}
+	if !slices.Contains(m.output, T("quest.new_available", 1)) {
+		t.Errorf("Expected the new quests to be announced, got %q", m.output)
+	}
+	if m.state.TotalXP != 50 {
+		t.Errorf("Expected XP to be kept, got %d", m.state.TotalXP)
+	}
+
+	// A save from a longer quest list lands on the last quest instead of crashing
+	long := game.GameState{CurrentQuestID: 7, QuestCount: 9, SeenOnboarding: true}
+	m = NewModel(quests, mgr, long, long.ResumeIndex(len(quests)), Options{SkipIntro: true})
+	updated, _ = m.Update(containerReadyMsg{})
+	m = updated.(Model)
+	if m.currentQuestIdx != 2 || slices.Contains(m.output, T("quest.new_available", 0)) {
+		t.Errorf("Expected to clamp to the last quest quietly, got index %d", m.currentQuestIdx)
+	}
+
+	// A finished game stays finished rather than replaying the last quest
+	done := game.GameState{CurrentQuestID: 3, QuestCount: 3, SeenOnboarding: true}
+	m = NewModel(quests, mgr, done, done.ResumeIndex(len(quests)), Options{SkipIntro: true})
+	updated, _ = m.Update(containerReadyMsg{})
+	m = updated.(Model)
+	m.width, m.height, m.viewportReady = 80, 24, true
+	_ = m.View()
+	if m.currentQuestIdx != len(quests) || m.glitchText != T("quest.all_done") {
+		t.Errorf("Expected a finished game to stay finished, got index %d", m.currentQuestIdx)
+	}
+}
+
+func TestResumeRecap(t *testing.T) {
+	quests := []game.Quest{{ID: 1, Title: "One"}, {ID: 2, Title: "Two", Objective: "Make a hut."}}
+	history := []string{"ls", "pwd", "cd /tmp", "cd", "ls -la", "mkdir hut"}
+	state := game.GameState{CurrentQuestID: 1, SeenOnboarding: true, History: history}
+	mgr := &docker.Manager{Runtime: "true", ContainerName: "goblin-test", CurrentDir: "/home/player", NoRoot: true}
+
+	m := NewModel(quests, mgr, state, 1, Options{SkipIntro: true, Recap: true})
+	if !slices.Equal(m.history, history) || m.historyIdx != len(history) {
+		t.Errorf("Expected the saved history behind the up arrow, got %q at %d", m.history, m.historyIdx)
+	}
+	updated, _ := m.Update(containerReadyMsg{})
+	m = updated.(Model)
+	var plain []string
+	for _, line := range m.output {
+		plain = append(plain, ansi.Strip(line))
+	}
+	if !slices.Contains(plain, T("recap.header")) || !slices.Contains(plain, T("recap.objective", m.objectiveText())) {
+		t.Fatalf("Expected a recap with the objective, got %q", plain)
+	}
+	if slices.Contains(plain, T("recap.command", "ls")) || !slices.Contains(plain, T("recap.command", "mkdir hut")) {
+		t.Errorf("Expected only the last %d commands replayed, got %q", recapCommands, plain)
+	}
+
+	// Toggled off, nothing is replayed
+	m = NewModel(quests, mgr, state, 1, Options{SkipIntro: true})
+	updated, _ = m.Update(containerReadyMsg{})
+	if slices.ContainsFunc(updated.(Model).output, func(line string) bool { return strings.Contains(line, T("recap.header")) }) {
+		t.Error("Expected no recap without the option")
+	}
+}
+
+func TestAccessibleView(t *testing.T) {
+	quests := []game.Quest{{ID: 1, Title: "Hello", Category: "files", Objective: "Run 'pwd'.", Banner: "/\\_/\\\n( o.o )", XPReward: 10}}
+	mgr := &docker.Manager{Runtime: "true", ContainerName: "goblin-test", CurrentDir: "/home/player", NoRoot: true}
+	m := NewModel(quests, mgr, game.GameState{}, 0, Options{SkipIntro: true, A11y: true})
+	m.viewportReady = true
+	m.width, m.height = 60, 20
+
+	updated, _ := m.Update(containerReadyMsg{})
+	m = updated.(Model)
+	if !slices.Contains(m.output, T("a11y.objective", "[files] Run 'pwd'.")) {
+		t.Errorf("Expected the objective to be announced, got %q", m.output)
+	}
+	if strings.Contains(strings.Join(m.output, "\n"), "( o.o )") {
+		t.Error("Expected the banner art to be left out")
+	}
+	if m.tick() != nil {
+		t.Error("Expected no once-a-second redraws")
+	}
+
+	m.glitchText = "<'.'> \"Hi!\""
+	view := m.View()
+	for _, decoration := range []string{"╭", "│", "█", "\x1b["} {
+		if strings.Contains(view, decoration) {
+			t.Errorf("Expected no %q in the accessible view:\n%s", decoration, view)
+		}
+	}
+	for _, label := range []string{T("objective.label", "[files] Run 'pwd'."), T("a11y.glitch"), m.promptString()} {
+		if !strings.Contains(view, label) {
+			t.Errorf("Expected %q in the accessible view:\n%s", label, view)
+		}
+	}
+	if h := lipgloss.Height(view); h > 20 {
+		t.Errorf("Expected the view to fit 20 rows, got %d", h)
+	}
+
+	if line := m.completionLine(quests[0]); line != T("a11y.complete", 1, "Hello", 10) {
+		t.Errorf("Expected a spoken-style completion, got %q", line)
+	}
+}
+
+func TestChecklistProgress(t *testing.T) {
+	quests := []game.Quest{
+		{ID: 1, Objective: "Set up camp", Checklist: []game.WinCondition{{Type: game.FileExists, Target: "camp/tent"}, {Type: game.FileExists, Target: "camp/fire"}}},
+		{ID: 2, Objective: "Run pwd"},
+	}
+	mgr := &docker.Manager{Runtime: "false", ContainerName: "goblin-test", CurrentDir: "/home/player"}
+	m := NewModel(quests, mgr, game.GameState{}, 0, Options{SkipIntro: true})
+	m.ready = true
+	m.viewportReady = true
+	m.width, m.height = 100, 24
+
+	updated, _ := m.Update(questCheckMsg{idx: 0, done: 2, total: 3})
+	m = updated.(Model)
+	if !strings.Contains(m.View(), "(2/3)") {
+		t.Error("Expected the header to show 2/3 objectives")
+	}
+	m.input = "checklist"
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if last := m.output[len(m.output)-1]; last != T("checklist.progress", 2, 3, 66) {
+		t.Errorf("Expected the checklist summary, got %q", last)
+	}
+
+	// Single-condition quests are all or nothing, and skip the header count
+	m.currentQuestIdx = 1
+	m.checksDone = 0
+	if strings.Contains(m.View(), "(0/1)") {
+		t.Error("Expected no count in the header for a single condition")
+	}
+	m.input = "checklist"
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if last := m.output[len(m.output)-1]; last != T("checklist.progress", 0, 1, 0) {
+		t.Errorf("Expected 0%% for an unfinished single condition, got %q", last)
+	}
+}
+
+func TestBonusObjectives(t *testing.T) {
+	quests := []game.Quest{
+		{ID: 1, Title: "Camp", XPReward: 20, OptionalObjectives: []game.BonusObjective{
+			{Description: "Lit a fire", XPBonus: 5},
+			{Description: "Locked it down", XPBonus: 15},
+		}},
+		{ID: 2, Title: "Next"},
+	}
+	mgr := &docker.Manager{Runtime: "false", ContainerName: "goblin-test", CurrentDir: "/home/player"}
+	m := NewModel(quests, mgr, game.GameState{}, 0, Options{SkipIntro: true, Demo: true})
+
+	updated, _ := m.Update(questCheckMsg{idx: 0, passed: true, bonuses: []int{1}})
+	m = updated.(Model)
+	if m.state.TotalXP != 35 {
+		t.Errorf("Expected quest and bonus XP (35), got %d", m.state.TotalXP)
+	}
+	if !strings.Contains(strings.Join(m.output, "\n"), T("quest.bonus", 15, "Locked it down")) {
+		t.Errorf("Expected the bonus to be announced, got %q", m.output)
+	}
+	if !m.state.BonusEarned(1, 1) || m.state.BonusEarned(1, 0) {
+		t.Errorf("Expected only the met bonus to be recorded, got %v", m.state.BonusesEarned)
+	}
+}
+
+func TestSuggestedCommandPrefill(t *testing.T) {
+	quests := []game.Quest{
+		{ID: 1, Title: "Look", SuggestedCommand: "ls -la"},
+		{ID: 2, Title: "Move", SuggestedCommand: "cd hut"},
+		{ID: 3, Title: "Done"},
+	}
+	mgr := &docker.Manager{Runtime: "false", ContainerName: "goblin-test", CurrentDir: "/home/player"}
+	m := NewModel(quests, mgr, game.GameState{}, 0, Options{SkipIntro: true})
+	m.ready = true
+	m.history = []string{"pwd"}
+
+	m.startQuest(0)
+	if m.input != "ls -la" || m.historyIdx != 1 {
+		t.Fatalf("Expected the suggestion at the end of history, got %q at %d", m.input, m.historyIdx)
+	}
+
+	// Editing works like typed input, and only the submitted command is kept
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	updated, _ = updated.(Model).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	updated, _ = updated.(Model).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if got := m.history[len(m.history)-1]; got != "ls -lh" || len(m.history) != 2 {
+		t.Errorf("Expected history [pwd ls -lh], got %v", m.history)
+	}
+
+	// Never overwrites what the player is already typing
+	m.input = "echo hi"
+	updated, _ = m.Update(questCheckMsg{idx: 0, passed: true})
+	m = updated.(Model)
+	if m.input != "echo hi" {
+		t.Errorf("Expected the player's typing to be kept, got %q", m.input)
+	}
+
+	m.hardMode = true
+	m.input = ""
+	m.startQuest(1)
+	if m.input != "" {
+		t.Errorf("Expected no suggestion in Hard Mode, got %q", m.input)
+	}
+}
+
+func TestContainerGoneOffersRestart(t *testing.T) {
+	quests := []game.Quest{{ID: 1, Title: "Look"}}
+	mgr := &docker.Manager{Runtime: "false", ContainerName: "goblin-test", CurrentDir: "/home/player"}
+	m := NewModel(quests, mgr, game.GameState{}, 0, Options{SkipIntro: true})
+	m.ready = true
+
+	updated, _ := m.Update(questCheckMsg{idx: 0, containerGone: true})
+	m = updated.(Model)
+	if m.dialog == nil || m.output[len(m.output)-1] != T("env.container_gone") {
+		t.Fatalf("Expected the stopped container to be reported with a restart offer, got %q", m.output)
+	}
+
+	// Declining doesn't nag on every later check
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	updated, _ = updated.(Model).Update(questCheckMsg{idx: 0, containerGone: true})
+	m = updated.(Model)
+	if m.dialog != nil || m.output[len(m.output)-1] != T("env.restart_later") {
+		t.Errorf("Expected one prompt only, got dialog %v and %q", m.dialog, m.output[len(m.output)-1])
+	}
+
+	m.input = "restart"
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if cmd == nil || m.containerDown || !slices.Contains(m.output, T("env.restarting")) {
+		t.Errorf("Expected 'restart' to start a fresh environment, got %q", m.output)
+	}
+}
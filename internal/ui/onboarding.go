@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// onboardingKeys are the tutorial lines, in display order
+var onboardingKeys = []string{
+	"onboarding.objective",
+	"onboarding.terminal",
+	"onboarding.glitch",
+	"onboarding.history",
+	"onboarding.builtins",
+	"onboarding.menu",
+}
+
+// updateOnboarding dismisses the tutorial on any key
+func (m Model) updateOnboarding(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyCtrlC {
+		return m.shutdown()
+	}
+
+	m.onboarding = false
+	if !m.state.SeenOnboarding {
+		m.state.SeenOnboarding = true
+		m.saveState()
+	}
+	return m, nil
+}
+
+// renderOnboarding draws the tutorial box
+func (m Model) renderOnboarding() string {
+	rows := []string{T("onboarding.title"), ""}
+	for _, key := range onboardingKeys {
+		rows = append(rows, "- "+T(key))
+	}
+	rows = append(rows, "", T("onboarding.dismiss"))
+
+	return m.boxStyle(theme.Glitch).
+		Padding(0, 2).
+		Render(strings.Join(rows, "\n"))
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// DefaultPrompt is the bash-like prompt shown before the input line
+const DefaultPrompt = "%u@%h:%w$ "
+
+// The player's identity inside the container, for %u and %h
+const (
+	promptUser = "player"
+	promptHost = "goblin"
+)
+
+// expandPrompt fills in a prompt template: %u is the user, %h host (the
+// player's own machine when empty), %w dir as given, and %% a literal percent.
+// Unknown placeholders are kept as typed.
+func expandPrompt(template, host, dir string) string {
+	var b strings.Builder
+	for i := 0; i < len(template); i++ {
+		if template[i] != '%' || i == len(template)-1 {
+			b.WriteByte(template[i])
+			continue
+		}
+		i++
+		switch template[i] {
+		case 'u':
+			b.WriteString(promptUser)
+		case 'h':
+			b.WriteString(cmp.Or(host, promptHost))
+		case 'w':
+			b.WriteString(dir)
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(template[i])
+		}
+	}
+	return b.String()
+}
+
+// promptString is the prompt for the current host and directory. The live
+// input line and the echoed command in the scrollback both use it, so they
+// always match.
+func (m Model) promptString() string {
+	return expandPrompt(m.prompt, m.manager.Host, m.displayDir())
+}
+
+// displayDir is the working directory as the player sees it, home as "~"
+func (m Model) displayDir() string {
+	return promptPath(m.manager.CurrentDir, m.manager.HomeDir())
+}
+
+// ValidatePrompt rejects templates that would break the single-line input
+func ValidatePrompt(template string) error {
+	if strings.IndexFunc(template, unicode.IsControl) >= 0 {
+		return fmt.Errorf("prompt %q contains a control character", template)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package ui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+)
+
+// recapCommands is how many of the last session's commands the resume recap replays
+const recapCommands = 5
+
+// showRecap reminds a returning player what they were doing: their last few
+// commands, muted, and the current objective where it can't be missed
+func (m *Model) showRecap() {
+	if !m.recap || m.currentQuestIdx >= len(m.quests) {
+		return
+	}
+	if recent := m.history[max(len(m.history)-recapCommands, 0):]; len(recent) > 0 {
+		muted := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+		m.output = append(m.output, muted.Render(T("recap.header")))
+		for _, cmd := range recent {
+			m.output = append(m.output, muted.Render(T("recap.command", cmd)))
+		}
+	}
+	objective := lipgloss.NewStyle().Bold(true).Render(T("recap.objective", m.objectiveText()))
+	m.output = append(m.output, objective)
+}
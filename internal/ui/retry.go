@@ -0,0 +1,23 @@
+package ui
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// retryCheck re-evaluates the win condition without re-running anything, for
+// when the state is right but the check after the command raced it (a file
+// still being written, a service still starting). The last command's output
+// still counts for the conditions that look at it.
+func (m *Model) retryCheck() tea.Cmd {
+	check := m.checkWinCondition()
+	if check == nil {
+		return nil
+	}
+	m.output = append(m.output, T("retry.checking"))
+	return func() tea.Msg {
+		msg := check()
+		if result, ok := msg.(questCheckMsg); ok {
+			result.retried = true
+			return result
+		}
+		return msg
+	}
+}
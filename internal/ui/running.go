@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"context"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// spinnerFrames animate the running indicator, one per spinnerInterval
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+const spinnerInterval = 100 * time.Millisecond
+
+// runningCommand is a player command still executing in the container
+type runningCommand struct {
+	command string
+	secret  bool // Typed after 'secret', so never shown in clear
+	cancel  context.CancelFunc
+	gen     int // Tells this run's spinner ticks from an earlier run's
+	frame   int
+}
+
+// spinnerMsg advances the spinner of the run started in generation gen
+type spinnerMsg struct{ gen int }
+
+// runCommand runs a player command in the container. The UI stays live
+// meanwhile: Glitch's box shows a spinner, and Ctrl+C cancels the command.
+func (m *Model) runCommand(command string, secret bool) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.runGen++
+	m.running = &runningCommand{command: command, secret: secret, cancel: cancel, gen: m.runGen}
+	manager := m.manager
+	run := func() tea.Msg {
+		defer cancel()
+		res, err := manager.RunCommandContext(ctx, command)
+		return commandResultMsg{command: command, output: res.Stdout, stderr: res.Stderr, combined: res.Combined, err: err}
+	}
+	return tea.Batch(run, m.spin())
+}
+
+// spin schedules the next spinner frame. A script waits for every command
+// anyway, so it gets no timer.
+func (m Model) spin() tea.Cmd {
+	if m.scripted || m.running == nil {
+		return nil
+	}
+	gen := m.running.gen
+	return tea.Tick(spinnerInterval, func(time.Time) tea.Msg { return spinnerMsg{gen: gen} })
+}
+
+// updateSpinner moves the spinner on while its command is still running
+func (m Model) updateSpinner(msg spinnerMsg) (tea.Model, tea.Cmd) {
+	if m.running == nil || m.running.gen != msg.gen {
+		return m, nil
+	}
+	running := *m.running
+	running.frame++
+	m.running = &running
+	return m, m.spin()
+}
+
+// updateRunning handles the keys that mean something different while a
+// command runs. Typing goes on as usual, so the next command can be ready.
+func (m Model) updateRunning(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.running.cancel()
+		return m, nil, true
+	case tea.KeyEnter:
+		// One command at a time; what's typed waits for this one
+		return m, nil, true
+	}
+	return m, nil, false
+}
+
+// shown is the command as Glitch's box may show it
+func (r runningCommand) shown() string {
+	if r.secret {
+		return mask(r.command)
+	}
+	return r.command
+}
+
+// runningLine is the spinner and the command it waits on, for Glitch's box
+func (m Model) runningLine() string {
+	frame := spinnerFrames[m.running.frame%len(spinnerFrames)]
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted)).Render(T("running.status", frame, m.running.shown()))
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// ParseScript reads a command file: one command per line, skipping blank
+// lines and lines starting with '#'
+func ParseScript(data string) []string {
+	var commands []string
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		commands = append(commands, line)
+	}
+	return commands
+}
+
+// RunScript plays commands through the model without a terminal, typing each
+// one at the prompt as a player would. Every command the model returns is run
+// to completion before the next line is typed, and output is written to w as
+// plain text. Timers are off and nothing is saved. Returns the final quest index.
+func RunScript(m Model, commands []string, w io.Writer) (int, error) {
+	m.scripted = true
+	m.onboarding = false
+	m.bell = false
+	m.windowTitle = false
+	m.maxOutput = 0 // Keep every line so none is missed when printing
+	// A script replays the same way whatever the save remembers
+	m.recap = false
+	m.history, m.historyIdx = nil, 0
+
+	printed := 0
+	flush := func() {
+		for ; printed < len(m.output); printed++ {
+			fmt.Fprintln(w, ansi.Strip(m.output[printed]))
+		}
+	}
+
+	m, quit := drive(m, m.Init())
+	flush()
+	if !m.ready {
+		return m.currentQuestIdx, errors.New("the game environment failed to start")
+	}
+
+	for _, command := range commands {
+		if quit {
+			break
+		}
+		m.input = command
+		updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m, quit = drive(updated.(Model), cmd)
+		flush()
+	}
+	return m.currentQuestIdx, nil
+}
+
+// drive runs cmd and everything it leads to, feeding each message back into
+// the model, until nothing is left to run. Reports whether the model quit.
+func drive(m Model, cmd tea.Cmd) (Model, bool) {
+	queue := []tea.Cmd{cmd}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if next == nil {
+			continue
+		}
+
+		switch msg := next().(type) {
+		case nil:
+		case tea.QuitMsg:
+			return m, true
+		case tea.BatchMsg:
+			queue = append(queue, msg...)
+		default:
+			// tea.Sequence's message type is unexported; its steps run first, in order
+			if steps, ok := sequenceSteps(msg); ok {
+				queue = append(steps, queue...)
+				continue
+			}
+			updated, cmd := m.Update(msg)
+			m = updated.(Model)
+			queue = append(queue, cmd)
+		}
+	}
+	return m, false
+}
+
+// sequenceSteps unpacks the message tea.Sequence produces, a slice of commands
+func sequenceSteps(msg tea.Msg) ([]tea.Cmd, bool) {
+	v := reflect.ValueOf(msg)
+	if v.Kind() != reflect.Slice || v.Type().Elem() != reflect.TypeOf(tea.Cmd(nil)) {
+		return nil, false
+	}
+	steps := make([]tea.Cmd, v.Len())
+	for i := range steps {
+		steps[i] = v.Index(i).Interface().(tea.Cmd)
+	}
+	return steps, true
+}
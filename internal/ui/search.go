@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"regexp"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// scrollSearch is a less-style search over the output buffer.
+// It searches backwards from the newest line, like less's '?', so n moves
+// to older matches and N to newer ones.
+type scrollSearch struct {
+	pattern string
+	typing  bool  // Still entering the pattern
+	matches []int // Indexes into m.output, oldest first
+	current int   // Index into matches of the line being shown
+}
+
+// compileSearch treats the pattern as a regex, falling back to a plain
+// substring when it isn't valid regex syntax
+func compileSearch(pattern string) *regexp.Regexp {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		re = regexp.MustCompile(regexp.QuoteMeta(pattern))
+	}
+	return re
+}
+
+// findMatches returns the indexes of output lines matching pattern
+func findMatches(output []string, pattern string) []int {
+	re := compileSearch(pattern)
+	var matches []int
+	for i, line := range output {
+		// Styled lines carry escape codes that shouldn't match
+		if re.MatchString(ansi.Strip(line)) {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+// updateSearch handles keys while a search is open
+func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := *m.search
+
+	if msg.Type == tea.KeyCtrlC {
+		return m.shutdown()
+	}
+	if msg.Type == tea.KeyEsc {
+		m.search = nil
+		return m, nil
+	}
+
+	if s.typing {
+		switch msg.Type {
+		case tea.KeyEnter:
+			if s.pattern == "" {
+				m.search = nil
+				return m, nil
+			}
+			s.typing = false
+			s.matches = findMatches(m.output, s.pattern)
+			s.current = len(s.matches) - 1
+		case tea.KeyBackspace:
+			if s.pattern == "" {
+				m.search = nil
+				return m, nil
+			}
+			runes := []rune(s.pattern)
+			s.pattern = string(runes[:len(runes)-1])
+		case tea.KeyRunes:
+			s.pattern += string(msg.Runes)
+		case tea.KeySpace:
+			s.pattern += " "
+		}
+		m.search = &s
+		return m, nil
+	}
+
+	switch {
+	case msg.Type == tea.KeyRunes && string(msg.Runes) == "n":
+		if s.current > 0 {
+			s.current--
+		}
+	case msg.Type == tea.KeyRunes && string(msg.Runes) == "N":
+		if s.current < len(s.matches)-1 {
+			s.current++
+		}
+	case msg.Type == tea.KeyCtrlF, msg.Type == tea.KeyRunes && string(msg.Runes) == "/":
+		s = scrollSearch{typing: true}
+	default:
+		// Anything else closes the search and returns to the prompt
+		m.search = nil
+		return m, nil
+	}
+	m.search = &s
+	return m, nil
+}
+
+// shiftSearch keeps match indexes pointing at the same lines after the
+// oldest drop lines of output were trimmed
+func (m *Model) shiftSearch(drop int) {
+	if m.search == nil || m.search.typing {
+		return
+	}
+	s := *m.search
+	var kept []int
+	for _, idx := range s.matches {
+		if idx >= drop {
+			kept = append(kept, idx-drop)
+		}
+	}
+	// Stay on the same line, or the oldest one left if it was trimmed
+	s.current -= len(s.matches) - len(kept)
+	if s.current < 0 {
+		s.current = 0
+	}
+	s.matches = kept
+	m.search = &s
+}
+
+// searchLine is the line a search is scrolled to, or -1 for the live tail
+func (m Model) searchLine() int {
+	if m.search == nil || m.search.typing || len(m.search.matches) == 0 {
+		return -1
+	}
+	return m.search.matches[m.search.current]
+}
+
+// isSearchMatch reports whether output line i matched the open search
+func (m Model) isSearchMatch(i int) bool {
+	if m.search == nil || m.search.typing {
+		return false
+	}
+	for _, idx := range m.search.matches {
+		if idx == i {
+			return true
+		}
+	}
+	return false
+}
+
+// searchPrompt replaces the input line while a search is open
+func (m Model) searchPrompt() string {
+	s := m.search
+	if s.typing {
+		return "/" + s.pattern
+	}
+	if len(s.matches) == 0 {
+		return T("search.none", s.pattern)
+	}
+	return T("search.status", s.pattern, len(s.matches)-s.current, len(s.matches))
+}
@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// secretCommands read passwords on stdin, so their input is masked as typed
+var secretCommands = map[string]bool{"passwd": true, "chpasswd": true}
+
+// readsSecret reports whether command's typed input should be masked
+func readsSecret(command string) bool {
+	fields := strings.Fields(command)
+	if len(fields) > 0 && fields[0] == "sudo" {
+		fields = fields[1:]
+	}
+	return len(fields) > 0 && secretCommands[fields[0]]
+}
+
+// shown is text as the screen shows it: a • per rune while the input is
+// masked. m.input always keeps the real text.
+func (m Model) shown(text string) string {
+	if !m.masked {
+		return text
+	}
+	return mask(text)
+}
+
+// mask hides text behind a • per rune
+func mask(text string) string {
+	return strings.Repeat("•", utf8.RuneCountInString(text))
+}
+
+// maskIndicator marks the input line while typing is masked
+func (m Model) maskIndicator() string {
+	if !m.masked {
+		return ""
+	}
+	if m.a11y {
+		return T("secret.a11y")
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Highlight)).Render(T("secret.indicator"))
+}
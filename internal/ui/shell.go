@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"io"
+	"os/exec"
+
+	"goblin-terminal/pkg/docker"
+)
+
+// resizingExec runs the !shell session for tea.Exec. The program's event loop
+// is blocked until the shell exits, so resizes can't be handled in Update;
+// they are forwarded to the runtime client for as long as it runs instead.
+type resizingExec struct{ *exec.Cmd }
+
+func (c resizingExec) SetStdin(r io.Reader) {
+	if c.Stdin == nil {
+		c.Stdin = r
+	}
+}
+
+func (c resizingExec) SetStdout(w io.Writer) {
+	if c.Stdout == nil {
+		c.Stdout = w
+	}
+}
+
+func (c resizingExec) SetStderr(w io.Writer) {
+	if c.Stderr == nil {
+		c.Stderr = w
+	}
+}
+
+func (c resizingExec) Run() error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	stop := docker.ForwardResizes(c.Cmd)
+	defer stop()
+	return c.Wait()
+}
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// shutdownLinger keeps the final status on screen before the program exits
+const shutdownLinger = 500 * time.Millisecond
+
+type shutdownMsg struct{ err error }
+
+// shutdown removes the containers and quits once that has finished.
+// Asking again while cleanup is still running quits immediately.
+func (m Model) shutdown() (Model, tea.Cmd) {
+	if m.shuttingDown {
+		return m, tea.Quit
+	}
+	m.shuttingDown = true
+	if m.transcript && !m.scripted {
+		m.saveTranscript(sessionTranscriptName(time.Now()))
+	}
+	m.menuOpen = false
+	m.dialog = nil
+	m.search = nil
+	m.output = append(m.output, T("env.shutdown"), T("env.removing"))
+
+	manager := m.manager
+	return m, func() tea.Msg {
+		return shutdownMsg{err: manager.StopContainer()}
+	}
+}
+
+// finishShutdown reports how cleanup went, then quits
+func (m Model) finishShutdown(msg shutdownMsg) (Model, tea.Cmd) {
+	m.shutdownErr = msg.err
+	if msg.err != nil {
+		m.output = append(m.output, T("env.remove_failed", msg.err))
+	} else {
+		m.output = append(m.output, T("env.removed"))
+	}
+	return m, tea.Tick(shutdownLinger, func(time.Time) tea.Msg {
+		return tea.Quit()
+	})
+}
+
+// ShutdownError is the error from removing the containers on exit, if any.
+// The alt screen is gone once the program returns, so callers should print it.
+func (m Model) ShutdownError() error {
+	return m.shutdownErr
+}
@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stdinAlways are commands that read stdin whatever their arguments
+var stdinAlways = map[string]bool{
+	"read": true, "tee": true, "tr": true, "chpasswd": true, "passwd": true,
+}
+
+// stdinWithoutFiles are commands that read stdin when given no file (or "-")
+var stdinWithoutFiles = map[string]bool{
+	"cat": true, "sort": true, "uniq": true, "wc": true, "rev": true, "base64": true,
+}
+
+// readsStdin reports whether command will wait for typed input, like `cat >
+// notes.txt` or `read name`. Pipelines, redirected input and compound
+// commands already have their input, so only a lone simple command counts.
+// Options taking a value (sort -k 2) read as files, which errs toward running
+// the command as before.
+func readsStdin(command string) bool {
+	if strings.ContainsAny(command, "|<;&`$(") {
+		return false
+	}
+	fields := strings.Fields(command)
+	if len(fields) > 0 && fields[0] == "sudo" {
+		// sudo passes its stdin on (sudo chpasswd)
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return false
+	}
+	if stdinAlways[fields[0]] {
+		return true
+	}
+	if !stdinWithoutFiles[fields[0]] {
+		return false
+	}
+	for i := 1; i < len(fields); i++ {
+		f := fields[i]
+		switch {
+		case f == ">" || f == ">>" || f == "2>" || f == "2>>":
+			i++ // Skip the redirect's target
+		case strings.HasPrefix(f, ">") || strings.HasPrefix(f, "2>"):
+		case f == "-":
+			return true
+		case !strings.HasPrefix(f, "-"):
+			return false
+		}
+	}
+	return true
+}
+
+// stdinCapture is a command collecting typed lines until Ctrl+D
+type stdinCapture struct {
+	command string
+	lines   []string
+}
+
+// updateStdin collects the command's input. Enter ends a line, Ctrl+D sends
+// everything typed (a partial line without its newline, as a terminal does)
+// and Ctrl+C or Esc drops the command without running it.
+func (m Model) updateStdin(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	capture := *m.stdin
+	switch msg.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		m.stdin = nil
+		m.output = append(m.output, m.shown(m.input)+"^C")
+		m.input = ""
+		m.masked = false
+		return m, nil
+	case tea.KeyEnter:
+		m.output = append(m.output, m.shown(m.input))
+		capture.lines = append(capture.lines, m.input)
+		m.input = ""
+	case tea.KeyCtrlD:
+		input := strings.Join(capture.lines, "\n")
+		if len(capture.lines) > 0 {
+			input += "\n"
+		}
+		if m.input != "" {
+			m.output = append(m.output, m.shown(m.input))
+			input += m.input
+		}
+		m.stdin = nil
+		m.input = ""
+		m.masked = false
+		return m, func() tea.Msg {
+			res, err := m.manager.RunCommandWithInput(capture.command, input)
+			return commandResultMsg{command: capture.command, output: res.Stdout, stderr: res.Stderr, combined: res.Combined, err: err}
+		}
+	case tea.KeyBackspace:
+		if runes := []rune(m.input); len(runes) > 0 {
+			m.input = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes:
+		// A pasted block is typed line by line
+		lines := strings.Split(strings.ReplaceAll(string(msg.Runes), "\r\n", "\n"), "\n")
+		for _, line := range lines[:len(lines)-1] {
+			m.output = append(m.output, m.shown(m.input+line))
+			capture.lines = append(capture.lines, m.input+line)
+			m.input = ""
+		}
+		m.input += lines[len(lines)-1]
+	case tea.KeySpace:
+		m.input += " "
+	}
+	m.stdin = &capture
+	return m, nil
+}
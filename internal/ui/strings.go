@@ -0,0 +1,244 @@
+package ui
+
+import "fmt"
+
+// DefaultLanguage is used when no language is selected or a key is missing
+const DefaultLanguage = "en"
+
+// catalog holds the UI chrome strings keyed by language code, then message key.
+// Quest content lives in quests/quests.<lang>.yaml and is not part of this table.
+var catalog = map[string]map[string]string{
+	"en": {
+		"init.title":                  "Initializing Goblin Terminal...",
+		"init.loading":                "Loading content...",
+		"init.viewport":               "Initializing...",
+		"env.building":                "Building simulation environment... (this may take a moment)",
+		"env.disk_warning":            "Warning: %v. The build may fail.",
+		"env.error":                   "Error starting environment: %v",
+		"env.ready":                   "Environment ready.",
+		"harden.sudo":                 "no-new-privileges blocks sudo, so quests that need root cannot be completed: %s",
+		"harden.read_only_root":       "read-only root blocks writes outside %s and /tmp: user management, /var/log and cron quests will fail",
+		"harden.ping":                 "NET_RAW is not granted, so ping will fail in quests: %s",
+		"env.harden_warning":          "Hardened mode: %s",
+		"env.no_ping":                 "Warning: Your container runtime refused NET_RAW, so ping won't work. Ping quests will accept a TCP connection to the gateway instead.",
+		"env.dynamic_ips":             "Note: Your container runtime couldn't give the game's hosts fixed IPs (common with rootless podman), so they got automatic ones. Reach them by name, e.g. 'gateway', as the quests do.",
+		"env.shell_fallback":          "Warning: %s isn't installed in the container, so commands run in sh. Some shell features may not work.",
+		"env.container_gone":          "The game container has stopped, so your progress can't be checked.",
+		"env.restart_later":           "Type 'restart' when you're ready to start a fresh environment.",
+		"env.no_root":                 "Warning: Safe mode is on because root exec is unavailable. Quests that need root will be skipped: %s.",
+		"env.home_repaired":           "Fixed file permissions in your home directory left over from an earlier session.",
+		"env.home_repair_failed":      "Warning: Some files in your home directory aren't accessible and couldn't be fixed: %v. 'goblin-terminal --reset' clears them, along with your progress.",
+		"env.restore_warning":         "Warning: State restoration issue: %v",
+		"env.retry_prompt":            "Press R to retry, any other key to quit.",
+		"env.retrying":                "Retrying build (attempt %d of %d)...",
+		"env.retry_exhausted":         "Giving up after repeated failures. Please check your container runtime and try again.",
+		"env.restarting":              "Resetting the environment for this quest...",
+		"env.resetting_home":          "Restoring your home directory to a fresh copy for this quest...",
+		"env.restart_error":           "Warning: Environment reset failed: %v",
+		"env.scenario_error":          "Warning: Could not start this quest's hosts: %v",
+		"env.shutdown":                "Shutting down simulation...",
+		"env.removing":                "Removing containers...",
+		"env.removed":                 "Removing containers... done",
+		"env.remove_failed":           "Warning: Could not remove containers: %v",
+		"quest.skipped_no_root":       "Skipping Quest %d: %s (needs root, unavailable in safe mode)",
+		"challenge.header":            "Time left %s  Try %d/%s",
+		"challenge.expired":           "Time's up for %s!",
+		"challenge.attempt":           "Attempt %d of %s. The clock is running again.",
+		"challenge.out_of_attempts":   "No attempts left (%d used). Finish it at your own pace; the clock has stopped.",
+		"challenge.declined":          "The clock has stopped. Finish it at your own pace.",
+		"quest.new_available":         "New quests available! %d added since you finished. Your XP is kept.",
+		"quest.resuming":              "Resuming from Quest %d...",
+		"recap.header":                "(previously)",
+		"recap.command":               "  $ %s",
+		"recap.objective":             ">> Where you left off: %s",
+		"quest.header":                "--- QUEST %d: %s ---",
+		"quest.complete":              ">>> QUEST COMPLETE! +%d XP <<<",
+		"quest.bonus":                 ">>> Bonus! +%d XP: %s",
+		"quest.next":                  "(Next: %s)\n%s",
+		"quest.all_done":              "You did it! All systems normal. <^.^>",
+		"cmd.error":                   "Error: %v",
+		"cmd.stderr":                  "stderr: %s",
+		"stdin.hint":                  "(type the input, Ctrl+D to finish, Ctrl+C to cancel)",
+		"running.status":              "%s running %s... (Ctrl+C to cancel)",
+		"running.a11y":                "Running %s. Press Ctrl+C to cancel.",
+		"command.cancel_failed":       "Warning: %v",
+		"command.cancelled":           "Command cancelled.",
+		"sudo.prompt":                 "[sudo] password for %s: ",
+		"sudo.try_again":              "Sorry, try again.",
+		"sudo.gave_up":                "sudo: %d incorrect password attempts",
+		"sudo.cancelled":              "sudo: cancelled, the command did not run",
+		"webhook.failed":              "(Couldn't report progress to the webhook: %v. The game carries on; this won't be repeated.)",
+		"retry.checking":              "Checking the quest again...",
+		"retry.not_yet":               "Not done yet. The quest's conditions still aren't met.",
+		"lore.header":                 "--- The story so far (%d entries) ---",
+		"lore.empty":                  "Nothing to re-read yet. Glitch hasn't said anything worth remembering.",
+		"leaderboard.header":          "--- Leaderboard (fastest full runs) ---",
+		"leaderboard.entry":           "%s%2d. %-12s %8s  %5d XP  %2d hints  %s",
+		"leaderboard.empty":           "No full runs yet. Finish every quest in one session to get on the board.",
+		"leaderboard.rank":            "Your run placed #%d on the leaderboard!",
+		"leaderboard.missed":          "Your run was too slow for the leaderboard's top %d.",
+		"leaderboard.error":           "Could not read the leaderboard: %v",
+		"leaderboard.save_error":      "Could not record your run on the leaderboard: %v",
+		"help.exit":                   "To quit the game, type 'exit'.",
+		"help.map":                    "Type 'map' to see your home directory as a tree.",
+		"help.usage":                  "Type 'usage' to see the container's CPU and memory use.",
+		"help.transcript":             "Type 'save-transcript <name>' to save this session's output as a text file.",
+		"help.checklist":              "Type 'checklist' to see how many of the quest's objectives are complete.",
+		"help.restart":                "Type 'restart' to start a fresh environment if the container stopped.",
+		"help.lore":                   "Type 'lore' to re-read everything Glitch has told you so far.",
+		"help.retry":                  "Type 'retry' to check the quest again without re-running your last command.",
+		"difficulty.easy":             "Easy",
+		"difficulty.normal":           "Normal",
+		"difficulty.hard":             "Hard",
+		"guard.confirm":               "'%s' would delete your whole home directory, and every quest's work in it. Run it anyway?",
+		"guard.cancelled":             "Cancelled. Nothing was deleted.",
+		"help.secret":                 "Type 'secret' to hide the next line as you type it, for flags and passwords.",
+		"secret.on":                   "The next line is hidden as you type it, and kept out of history.",
+		"secret.indicator":            "[hidden] ",
+		"secret.a11y":                 "(hidden input) ",
+		"help.ask":                    "Type 'ask <question>' to ask Glitch about permissions, paths, sudo and more.",
+		"ask.usage":                   "Ask Glitch something, like: ask how do permissions work",
+		"ask.hard_mode":               "Glitch is keeping quiet. No help from the goblin in Hard Mode!",
+		"ask.unknown":                 "<'.'> \"Hmm? Glitch doesn't know that one. Try 'man <command>', or 'hint' if you're stuck!\"",
+		"ask.permission":              "<'.'> \"ls -l shows who may read, write and run a file! chmod changes it: chmod u+x runs, chmod 644 is rw-r--r--.\"",
+		"ask.sudo":                    "<'.'> \"Put sudo in front to run one command as root. Careful, root can break everything!\"",
+		"ask.owner":                   "<'.'> \"Every file has an owner and a group. chown user:group file changes both, and usually needs sudo!\"",
+		"ask.users":                   "<'.'> \"id shows who you are and your groups. useradd makes users, groupadd makes groups, usermod -aG adds to one!\"",
+		"ask.ssh":                     "<'.'> \"ssh user@host logs in to another machine, and scp file user@host:path copies to it!\"",
+		"ask.network":                 "<'.'> \"ip addr shows your addresses, ping checks a host answers, and nc -zv host port knocks on a port!\"",
+		"ask.process":                 "<'.'> \"ps aux lists what's running. kill PID asks it to stop, kill -9 PID doesn't ask!\"",
+		"ask.search":                  "<'.'> \"find . -name 'x*' finds files by name, grep word file finds lines, and | pipes one into the other!\"",
+		"ask.path":                    "<'.'> \"A path starting with / begins at the root. Anything else starts where you are, and ~ is home!\"",
+		"ask.directory":               "<'.'> \"pwd says where you are, ls shows what's here, and cd moves you. cd .. goes up one!\"",
+		"ask.create":                  "<'.'> \"touch makes an empty file, mkdir makes a directory, and mkdir -p makes the whole path at once!\"",
+		"ask.stuck":                   "<'.'> \"Read the objective again! 'hint' costs XP but tells you more, and 'checklist' shows what's left.\"",
+		"help.leaderboard":            "Type 'leaderboard' to see your fastest full runs.",
+		"help.search":                 "Press / on an empty prompt, or Ctrl+F, to search earlier output (n/N for older/newer matches, Esc to close).",
+		"whereami.full":               "Full path: %s",
+		"whereami.prompt":             "Prompt:    %s",
+		"a11y.status":                 "Progress: %d percent. XP: %d.",
+		"a11y.challenge":              "Time left: %s. Try %d of %s.",
+		"a11y.glitch":                 "Glitch says:",
+		"a11y.objective":              "Objective: %s",
+		"a11y.complete":               "Quest %d, %s, complete. You earned %d XP.",
+		"checklist.progress":          "%d/%d objectives complete (%d%%)",
+		"checklist.header":            "(%d/%d)",
+		"objective.label":             "OBJECTIVE: %s",
+		"objective.default":           "Load Quests...",
+		"objective.hard":              "[HARD MODE] %s",
+		"objective.complete":          "All Objectives Complete!",
+		"timer.quest_best":            "New best! Quest time %s",
+		"timer.total_best":            "New best! Full run time %s",
+		"hint.text":                   "Hint: %s",
+		"hint.bought":                 "Spent %d XP on a hint. Balance: %d XP",
+		"hint.denied":                 "Hints cost %d XP. You only have %d XP.",
+		"xp.balance":                  "XP %d",
+		"menu.title":                  "=== PAUSED ===",
+		"menu.resume":                 "Resume",
+		"menu.hard_mode":              "Hard Mode",
+		"menu.theme":                  "Theme",
+		"menu.color":                  "Color",
+		"menu.layout":                 "Layout",
+		"menu.layout_bottom":          "bottom",
+		"menu.layout_side":            "side",
+		"menu.stats":                  "View stats",
+		"menu.tutorial":               "Show tutorial",
+		"menu.reset_quest":            "Reset current quest",
+		"menu.quit":                   "Quit",
+		"menu.on":                     "on",
+		"menu.off":                    "off",
+		"menu.help":                   "Up/Down to move, Enter to select, Esc to close",
+		"menu.resetting":              "Resetting quest...",
+		"stats.header":                "--- STATS ---",
+		"stats.quests":                "Quests completed: %d/%d",
+		"stats.xp":                    "Total XP: %d (balance %d)",
+		"stats.best_run":              "Best full run: %s",
+		"demo.skip":                   "[DEMO] Quest not solved by the demo script, moving on...",
+		"demo.restart":                "[DEMO] Restarting from the beginning...",
+		"numbers.on":                  "Line numbers on. Type 'numbers' again to turn them off.",
+		"numbers.off":                 "Line numbers off.",
+		"progress.header":             "--- PROGRESS BY CATEGORY ---",
+		"progress.category":           "%-12s %d/%d",
+		"transcript.usage":            "Usage: save-transcript <name>",
+		"transcript.saved":            "Transcript saved to %s",
+		"transcript.error":            "Couldn't save the transcript: %v",
+		"map.empty":                   "  (your home is empty)",
+		"map.truncated":               "  ... and %d more",
+		"map.summary":                 "%d directories, %d files",
+		"usage.header":                "--- CONTAINER USAGE ---",
+		"usage.cpu":                   "CPU",
+		"usage.memory":                "Memory",
+		"usage.processes":             "Processes",
+		"inventory.header":            "--- CHANGED THIS QUEST ---",
+		"inventory.empty":             "  (nothing yet)",
+		"inventory.file":              "file",
+		"inventory.dir":               "dir",
+		"inventory.link":              "link",
+		"confirm.keys":                "[y] Yes   [n] No",
+		"confirm.restart_env":         "Restart the environment? The current quest's setup will run again.",
+		"confirm.reset_quest":         "Restart this quest? Its setup will run again.",
+		"confirm.retry":               "Out of time! Reset the quest and try again?",
+		"confirm.quit":                "Quit the game?",
+		"title.quest":                 "GoblinTerminal — Quest %d: %s — %s",
+		"title.done":                  "GoblinTerminal — %s",
+		"onboarding.title":            "=== WELCOME TO GOBLIN TERMINAL ===",
+		"onboarding.objective":        "The bar at the top is your current objective.",
+		"onboarding.terminal":         "The middle is a real Linux shell. Type commands and press Enter.",
+		"onboarding.glitch":           "The box at the bottom is Glitch the goblin, who explains each quest.",
+		"onboarding.history":          "Up/Down recall earlier commands.",
+		"onboarding.builtins":         "Type 'help' for game commands, 'hint' if you get stuck.",
+		"onboarding.menu":             "Ctrl+P pauses, Ctrl+H toggles Hard Mode, Esc quits.",
+		"onboarding.dismiss":          "Press any key to begin.",
+		"search.status":               "/%s  match %d of %d  (n older, N newer, Esc to close)",
+		"search.none":                 "/%s  no matches (Esc to close)",
+		"check.script_not_executable": "<'.'> \"The script exists but won't run! Did you chmod +x it?\"",
+		"check.script_wrong_output":   "<'.'> \"The script runs, but it says the wrong thing! Check the shebang and what it echoes!\"",
+		"shell.disabled":              "The !shell escape hatch is off. Start the game with --allow-shell to use it.",
+		"shell.entering":              "Opening a raw shell in the container. Type 'exit' to return to the game.",
+		"shell.returned":              "Back in the game.",
+		"shell.error":                 "Shell exited with an error: %v",
+		"setup.redo":                  "Re-running this quest's setup:",
+		"setup.root_command":          "  %s (as root)",
+		"setup.failed":                "Warning: setup step '%s' failed: %v. The quest may not be winnable; try 'redo-setup'.",
+		"setup.verbose_command":       "[setup] $ %s",
+		"setup.verbose_root_command":  "[setup] # %s",
+		"setup.verbose_output":        "[setup]   %s",
+		"setup.verbose_error":         "[setup] failed: %v",
+		"setup.none":                  "This quest has no setup to re-run.",
+		"debug.check":                 "[check] %s expected=%s got=%s => %s",
+		"debug.diff_header":           "[DEBUG] %s differs from the expected content (- expected, + actual):",
+		"nearmiss.case":               "<'.'> \"So close! Check your capitalization!\"",
+		"nearmiss.whitespace":         "<'.'> \"Almost! The words are right, but the spacing or line breaks aren't.\"",
+		"nearmiss.typo":               "<'.'> \"Nearly there! A letter or two is off. Look closely!\"",
+		"hint.auto":                   "<'.'> \"Glitch notices you're stuck... Psst! %s\"",
+		"hint.exit":                   " (type 'exit' to quit)",
+	},
+}
+
+// language is the active catalog language
+var language = DefaultLanguage
+
+// SetLanguage selects the catalog used for UI strings.
+// Unknown languages fall back to English.
+func SetLanguage(lang string) {
+	if _, ok := catalog[lang]; !ok {
+		lang = DefaultLanguage
+	}
+	language = lang
+}
+
+// T looks up a UI string by key in the active language and formats it with args.
+// Missing keys fall back to English, then to the key itself.
+func T(key string, args ...interface{}) string {
+	text, ok := catalog[language][key]
+	if !ok {
+		text, ok = catalog[DefaultLanguage][key]
+		if !ok {
+			text = key
+		}
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"errors"
+
+	"goblin-terminal/pkg/docker"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sudoAttempts is how many wrong passwords are allowed before giving up, as sudo does
+const sudoAttempts = 3
+
+// sudoPrompt is a sudo command waiting for the player's password
+type sudoPrompt struct {
+	command  string
+	password string
+	attempts int // Wrong passwords entered so far
+}
+
+// sudoResultMsg is a sudo command's outcome after a password was given
+type sudoResultMsg struct {
+	prompt sudoPrompt
+	result commandResultMsg
+}
+
+// wantsSudoPassword reports whether a finished command should prompt for a
+// sudo password and be run again. Nobody is at the keyboard in the demo or a
+// script, so those get sudo's own error.
+func (m Model) wantsSudoPassword(msg commandResultMsg) bool {
+	return !m.demo && !m.scripted && docker.NeedsSudoPassword(msg.command, msg.err)
+}
+
+// sudoPromptText is the input line while a password is being typed
+func sudoPromptText() string {
+	return T("sudo.prompt", promptUser)
+}
+
+// updateSudo reads the password. Nothing typed is ever shown.
+func (m Model) updateSudo(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	prompt := *m.sudo
+	switch msg.Type {
+	case tea.KeyCtrlC, tea.KeyEsc:
+		m.sudo = nil
+		m.output = append(m.output, sudoPromptText(), T("sudo.cancelled"))
+		return m, nil
+	case tea.KeyEnter:
+		m.sudo = nil
+		m.output = append(m.output, sudoPromptText())
+		return m, func() tea.Msg {
+			res, err := m.manager.RunSudo(prompt.command, prompt.password)
+			return sudoResultMsg{prompt: prompt, result: commandResultMsg{
+				command: prompt.command, output: res.Stdout, stderr: res.Stderr, combined: res.Combined, err: err,
+			}}
+		}
+	case tea.KeyBackspace:
+		if runes := []rune(prompt.password); len(runes) > 0 {
+			prompt.password = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		prompt.password += string(msg.Runes)
+	}
+	m.sudo = &prompt
+	return m, nil
+}
+
+// sudoResult retries on a wrong password, up to sudoAttempts, and otherwise
+// handles the output like any other command
+func (m Model) sudoResult(msg sudoResultMsg) (tea.Model, tea.Cmd) {
+	if !docker.WrongSudoPassword(msg.result.err) {
+		return m.update(msg.result)
+	}
+	attempts := msg.prompt.attempts + 1
+	if attempts < sudoAttempts {
+		m.output = append(m.output, T("sudo.try_again"))
+		m.sudo = &sudoPrompt{command: msg.prompt.command, attempts: attempts}
+		return m, nil
+	}
+	return m.update(commandResultMsg{command: msg.prompt.command, err: errors.New(T("sudo.gave_up", sudoAttempts))})
+}
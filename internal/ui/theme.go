@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Theme holds the colors used to draw the game chrome
+type Theme struct {
+	Name      string
+	Header    string // Objective bar background
+	HardMode  string // Objective bar background in Hard Mode
+	Glitch    string // Glitch's lines and box border
+	System    string // [SYSTEM MESSAGE] lines
+	Highlight string // Highlighted process rows
+	Muted     string // Hints like "(type 'exit' to quit)"
+	Code      string // `Inline code` in quest text
+}
+
+// themes lists the available themes; the first is the default
+var themes = []Theme{
+	{
+		Name:      "goblin",
+		Header:    "#AAAAAA",
+		HardMode:  "#FF5555",
+		Glitch:    "#00FF00",
+		System:    "#FFA500",
+		Highlight: "#FFFF00",
+		Muted:     "#555555",
+		Code:      "#00BFFF",
+	},
+	{
+		Name:      "amber",
+		Header:    "#FFB000",
+		HardMode:  "#FF6F00",
+		Glitch:    "#FFCC66",
+		System:    "#FF8C00",
+		Highlight: "#FFFFFF",
+		Muted:     "#8A6A2A",
+		Code:      "#FFFFFF",
+	},
+	{
+		Name:      "ice",
+		Header:    "#88C0D0",
+		HardMode:  "#BF616A",
+		Glitch:    "#8FBCBB",
+		System:    "#EBCB8B",
+		Highlight: "#ECEFF4",
+		Muted:     "#4C566A",
+		Code:      "#81A1C1",
+	},
+}
+
+// theme is the active theme
+var theme = themes[0]
+
+// themeIdx is the position of the active theme in themes
+var themeIdx = 0
+
+// NextTheme switches to the next theme and returns its name
+func NextTheme() string {
+	themeIdx = (themeIdx + 1) % len(themes)
+	theme = themes[themeIdx]
+	return theme.Name
+}
+
+// colorEnabled tracks whether styles emit color codes
+var colorEnabled = true
+
+// SetColor turns colored output on or off for every lipgloss style
+func SetColor(enabled bool) {
+	colorEnabled = enabled
+	if enabled {
+		lipgloss.SetColorProfile(termenv.EnvColorProfile())
+	} else {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+}
@@ -0,0 +1,26 @@
+package ui
+
+import (
+	"time"
+
+	"goblin-terminal/internal/game"
+)
+
+// saveTranscript writes the scrollback so far to a text file named name
+func (m *Model) saveTranscript(name string) {
+	if name == "" {
+		m.output = append(m.output, T("transcript.usage"))
+		return
+	}
+	path, err := game.SaveTranscript(name, m.output)
+	if err != nil {
+		m.output = append(m.output, T("transcript.error", err))
+		return
+	}
+	m.output = append(m.output, T("transcript.saved", path))
+}
+
+// sessionTranscriptName names the automatic transcript after the time the session ended
+func sessionTranscriptName(now time.Time) string {
+	return "session-" + now.Format("20060102-150405")
+}
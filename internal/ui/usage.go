@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"goblin-terminal/pkg/docker"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// usageBarWidth is the number of cells in each usage bar
+const usageBarWidth = 20
+
+type usageMsg struct {
+	stats docker.ContainerStats
+	err   error
+}
+
+// usageBar draws percent (0-100, clamped) as a filled bar
+func usageBar(percent float64) string {
+	return meterBar(percent, usageBarWidth)
+}
+
+// meterBar draws percent (0-100, clamped) as a bar of width cells
+func meterBar(percent float64, width int) string {
+	filled := int(percent/100*float64(width) + 0.5)
+	filled = max(0, min(width, filled))
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", width-filled) + "]"
+}
+
+// formatUsage renders a stats sample as a small panel
+func formatUsage(s docker.ContainerStats) []string {
+	rows := []string{
+		T("usage.header"),
+		fmt.Sprintf("%-10s %s %6.1f%%", T("usage.cpu"), usageBar(s.CPUPercent), s.CPUPercent),
+		fmt.Sprintf("%-10s %s %6.1f%%  %s / %s", T("usage.memory"), usageBar(s.MemPercent), s.MemPercent, s.MemUsage, s.MemLimit),
+		fmt.Sprintf("%-10s %d", T("usage.processes"), s.PIDs),
+	}
+	panel := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color(theme.Muted)).
+		Padding(0, 1).
+		Render(strings.Join(rows, "\n"))
+	return strings.Split(panel, "\n")
+}
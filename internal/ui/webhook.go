@@ -0,0 +1,36 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// webhookMsg is the outcome of one completion report
+type webhookMsg struct {
+	err error
+}
+
+// reportCompletion sends a quest completion to the --webhook collector off
+// the UI goroutine. Demo and scripted runs aren't real progress, so they
+// never report.
+func (m Model) reportCompletion(questID int) tea.Cmd {
+	if m.onComplete == nil || m.demo || m.scripted {
+		return nil
+	}
+	send, xp := m.onComplete, m.state.TotalXP
+	return func() tea.Msg {
+		return webhookMsg{err: send(questID, xp)}
+	}
+}
+
+// webhookFailed notes a failed delivery once per session; the game goes on
+// either way, and a collector that's down would otherwise repeat it every quest
+func (m Model) webhookFailed(err error) Model {
+	if m.webhookWarned {
+		return m
+	}
+	m.webhookWarned = true
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Muted))
+	m.output = append(m.output, muted.Render(T("webhook.failed", err)))
+	return m
+}
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"cmp"
 	"flag"
 	"fmt"
 	"os"
+	"os/user"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"goblin-terminal/internal/game"
+	"goblin-terminal/internal/server"
 	"goblin-terminal/internal/ui"
 	"goblin-terminal/pkg/docker"
 
@@ -19,16 +24,149 @@ func main() {
 	questFlag := flag.Int("quest", 0, "Jump to specific quest ID (debug)")
 	resetFlag := flag.Bool("reset", false, "Reset save data")
 	hardFlag := flag.Bool("hard", false, "Enable Hard Mode (no command hints)")
+	difficultyFlag := flag.String("difficulty", "", "easy, normal or hard: sets hint cost, command timeout, suggested commands, XP and the rm -rf guard (default: the last choice, else normal)")
+	bellFlag := flag.Bool("bell", false, "Ring the terminal bell on quest completion and errors")
+	langFlag := flag.String("lang", ui.DefaultLanguage, "UI and quest language code (e.g. en)")
+	subnetFlag := flag.String("subnet", docker.DefaultSubnet, "Subnet for the game network (CIDR)")
+	gatewayIPFlag := flag.String("gateway-ip", docker.DefaultGatewayIP, "Static IP of the gateway container")
+	playerIPFlag := flag.String("player-ip", docker.DefaultPlayerIP, "Static IP of the player container")
+	shellFlag := flag.String("shell", docker.DefaultShell, "Shell that runs commands in the player container (falls back to sh if missing)")
+	noRootFlag := flag.Bool("no-root", false, "Safe mode: never exec as root in the container and skip quests that need root")
+	hardenFlag := flag.Bool("harden", false, "Drop capabilities, block privilege escalation and mount root read-only")
+	demoFlag := flag.Bool("demo", false, "Attract mode: auto-play every quest and loop (does not touch your save)")
+	verifyFlag := flag.Bool("verify", false, "Run every quest's solution and check it passes (for CI)")
+	checkImageFlag := flag.Bool("check-image", false, "Check the image has every tool the quests run, then exit")
+	listDepsFlag := flag.Bool("list-deps", false, "List the programs the quests run in the container and which quests need them, then exit")
+	numbersFlag := flag.Bool("numbers", false, "Prefix command output with line numbers")
+	pullFlag := flag.Bool("pull", false, "Pull the Dockerfile's base images with visible progress before building")
+	minDiskFlag := flag.Float64("min-disk-gb", float64(docker.DefaultMinFreeSpace)/(1<<30), "Warn before building when free disk space is below this many GB (0 disables)")
+	listFlag := flag.Bool("list-quests", false, "List quests with their categories and progress, then exit")
+	noTitleFlag := flag.Bool("no-title", false, "Don't set the terminal window title")
+	exportFlag := flag.String("export", "", "Write save progress to a portable file and exit")
+	importFlag := flag.String("import", "", "Load save progress from a portable file and exit")
+	forceFlag := flag.Bool("force", false, "Allow --import to overwrite an existing save")
+	skipIntroFlag := flag.Bool("skip-intro", false, "Don't show the first-run tutorial")
+	allowShellFlag := flag.Bool("allow-shell", false, "Enable the in-game !shell command for a raw container shell (debugging)")
+	maxOutputFlag := flag.Int("max-output", ui.DefaultMaxOutputLines, "Scrollback lines kept before the oldest are dropped")
+	maxHistoryFlag := flag.Int("max-history", ui.DefaultMaxHistory, "Command history entries kept before the oldest are dropped")
+	diffFSFlag := flag.Bool("diff-fs", false, "List the files the running game container added, changed or deleted compared to its image, then exit (debugging)")
+	doctorFlag := flag.Bool("doctor", false, "Check the container runtime, image, network, disk space and capabilities, then exit")
+	verboseSetupFlag := flag.Bool("verbose-setup", false, "Show each quest setup command with its output and errors, marked [setup] (failed steps are always reported)")
+	debugFlag := flag.Bool("debug", false, "Show quest-author diagnostics: a log of every win condition check and a diff when a file check fails (spoils answers)")
+	autosaveFlag := flag.Duration("autosave", ui.DefaultAutosaveInterval, "How often to save progress while playing (0 disables)")
+	a11yFlag := flag.Bool("a11y", false, "Screen-reader friendly mode: plain labeled text without boxes, colors or animation")
+	layoutFlag := flag.String("layout", ui.LayoutBottom, "Where Glitch's box goes: bottom, or side on wide terminals")
+	promptFlag := flag.String("prompt", ui.DefaultPrompt, "Prompt template: %u user, %h host, %w directory (~ for home), %% a percent sign")
+	autoHintFlag := flag.Int("auto-hint", 8, "Have Glitch offer the hint after this many commands without progress (0 disables, never in Hard Mode)")
+	questsFlag := flag.String("quests", "", "Quest file or https:// URL of a quest pack (default: bundled quests)")
+	insecureFlag := flag.Bool("insecure", false, "Allow --quests to fetch over plain http")
+	var dockerArgs stringList
+	flag.Var(&dockerArgs, "docker-arg", "Extra argument for the player container's run command, passed through as-is (repeatable, one argument each)")
+	execFileFlag := flag.String("exec-file", "", "Run the commands in this file (one per line, # comments) without the UI, print the output and exit with the final quest index as the status")
+	noRecapFlag := flag.Bool("no-recap", false, "Don't replay your last few commands and the objective when resuming a save")
+	splitOutputFlag := flag.Bool("split-output", false, "Show a command's stderr after its stdout, marked, instead of interleaved as written")
+	fastValidateFlag := flag.Bool("fast-validate", false, "Reuse file and directory check results until the target or a parent directory changes (faster checks on slow runtimes)")
+	transcriptFlag := flag.Bool("transcript", false, "Save the session's output as a text file in the config directory on exit")
+	webhookFlag := flag.String("webhook", "", "POST a JSON note ({profile, quest_id, xp, timestamp}) to this http(s) URL whenever a quest is completed")
+	profileFlag := flag.String("profile", defaultProfile(), "Player name on the leaderboard and in --webhook notes")
+	serveFlag := flag.String("serve", "", "Serve live progress as JSON at /progress on this address (e.g. :8080, localhost only unless a host is given)")
 	flag.Parse()
 
+	if err := ui.ValidatePrompt(*promptFlag); err != nil {
+		fmt.Printf("Invalid --prompt: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := game.LookupDifficulty(*difficultyFlag); err != nil {
+		fmt.Printf("Invalid --difficulty: %v\n", err)
+		os.Exit(1)
+	}
+	if *hardFlag && *difficultyFlag != "" && *difficultyFlag != game.DifficultyHard {
+		fmt.Printf("--hard plays the hard difficulty; it can't be combined with --difficulty %s\n", *difficultyFlag)
+		os.Exit(1)
+	}
+	if !slices.Contains(ui.Layouts, *layoutFlag) {
+		fmt.Printf("Unknown layout %q (expected one of: %s)\n", *layoutFlag, strings.Join(ui.Layouts, ", "))
+		os.Exit(1)
+	}
+
+	// Export/Import don't need a container runtime
+	if *exportFlag != "" {
+		if err := game.ExportState(*exportFlag); err != nil {
+			fmt.Printf("Error exporting progress: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Progress exported to %s\n", *exportFlag)
+		return
+	}
+	if *importFlag != "" {
+		if err := game.ImportState(*importFlag, *forceFlag); err != nil {
+			fmt.Printf("Error importing progress: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Progress imported from %s\n", *importFlag)
+		return
+	}
+
+	// Reading the quests is enough to know what they need
+	if *listDepsFlag {
+		runListDeps(loadQuests(*questsFlag, *langFlag, *insecureFlag))
+		return
+	}
+
 	// 1. Initialize Container Manager
 	// We use a fixed name for the game container
 	manager, err := docker.NewManager("goblin-terminal:latest", "goblin-game")
+	if err != nil && *doctorFlag {
+		fmt.Printf("FAIL  runtime  %v\n", err)
+		os.Exit(1)
+	}
 	if err != nil {
 		fmt.Printf("Error initializing container manager: %v\n", err)
 		os.Exit(1)
 	}
 
+	manager.Subnet = *subnetFlag
+	manager.GatewayIP = *gatewayIPFlag
+	manager.PlayerIP = *playerIPFlag
+	manager.MinFreeSpace = uint64(*minDiskFlag * (1 << 30))
+	if err := docker.ValidateRunArgs(dockerArgs); err != nil {
+		fmt.Printf("Error in container arguments: %v\n", err)
+		os.Exit(1)
+	}
+	manager.ExtraRunArgs = dockerArgs
+	if err := docker.ValidateShell(*shellFlag); err != nil {
+		fmt.Printf("Error in --shell: %v\n", err)
+		os.Exit(1)
+	}
+	manager.Shell = *shellFlag
+
+	// The doctor reports a bad network config instead of stopping on it
+	if *doctorFlag {
+		if runDoctor(manager) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Only reads the running container, so the settings below don't matter
+	if *diffFSFlag {
+		if err := runDiffFS(manager); err != nil {
+			fmt.Printf("Error comparing the container to its image: %v\n", err)
+			fmt.Println("Is the game running? --diff-fs looks at the container of a game in progress.")
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := manager.ValidateNetwork(); err != nil {
+		fmt.Printf("Error in network configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if *hardenFlag {
+		manager.Harden()
+	}
+	manager.NoRoot = *noRootFlag
+
 	// Handle Reset
 	if *resetFlag {
 		if err := game.ResetState(); err != nil {
@@ -46,17 +184,62 @@ func main() {
 	}
 
 	// 2. Load Quests
-	cwd, err := os.Getwd()
-	if err != nil {
-		fmt.Printf("Error getting current directory: %v\n", err)
-		os.Exit(1)
+	ui.SetLanguage(*langFlag)
+	quests := loadQuests(*questsFlag, *langFlag, *insecureFlag)
+
+	if *listFlag {
+		completed := 0
+		if state, err := game.LoadState(); err == nil {
+			completed = state.CurrentQuestID
+		}
+		for i, q := range quests {
+			mark := " "
+			if i < completed {
+				mark = "x"
+			}
+			fmt.Printf("[%s] %2d  %-12s %s\n", mark, q.ID, q.QuestCategory(), q.Title)
+		}
+		fmt.Println()
+		for _, c := range game.ProgressByCategory(quests, completed) {
+			fmt.Printf("%-12s %d/%d\n", c.Name, c.Done, c.Total)
+		}
+		return
 	}
 
-	questsPath := filepath.Join(cwd, "quests", "quests.yaml")
-	quests, err := game.LoadQuests(questsPath)
-	if err != nil {
-		fmt.Printf("Error loading quests: %v\n", err)
-		os.Exit(1)
+	if *verifyFlag {
+		failures, err := runVerify(quests, manager)
+		if err != nil {
+			fmt.Printf("Error during verification: %v\n", err)
+			os.Exit(1)
+		}
+		if failures > 0 {
+			fmt.Printf("%d quest(s) failed verification.\n", failures)
+			os.Exit(1)
+		}
+		fmt.Println("All quests verified.")
+		return
+	}
+
+	if *checkImageFlag {
+		missing, err := runCheckImage(quests, manager)
+		if err != nil {
+			fmt.Printf("Error checking the image: %v\n", err)
+			os.Exit(1)
+		}
+		if missing > 0 {
+			fmt.Printf("%d tool(s) missing from the image.\n", missing)
+			os.Exit(1)
+		}
+		fmt.Println("The image has every tool the quests need.")
+		return
+	}
+
+	// The build would pull these silently, which looks like a hang on first run
+	if *pullFlag {
+		if err := manager.PullBaseImage(os.Stdout); err != nil {
+			fmt.Printf("Error pulling base image: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
 	// Determine starting quest index
@@ -65,7 +248,25 @@ func main() {
 	// properties of LoadState
 	state, err := game.LoadState()
 	if err == nil {
-		startQuestIdx = state.CurrentQuestID
+		startQuestIdx = state.ResumeIndex(len(quests))
+	}
+
+	// Demo always starts fresh and never reads the player's save
+	if *demoFlag {
+		state = game.GameState{}
+		startQuestIdx = 0
+
+		// Each loop wipes storage, so play in throwaway containers and storage
+		// as --verify does, never the player's own
+		storage, err := os.MkdirTemp("", "goblin-demo-")
+		if err != nil {
+			fmt.Printf("Error creating demo storage: %v\n", err)
+			os.Exit(1)
+		}
+		manager.StoragePath = storage
+		manager.ContainerName += "_demo"
+		manager.GatewayName += "_demo"
+		defer manager.ResetStorage()
 	}
 
 	// Flag overrides save
@@ -75,11 +276,107 @@ func main() {
 		startQuestIdx = *questFlag - 1
 	}
 
+	// A difficulty chosen once sticks until another is chosen. A save from
+	// a newer build may name one this build lacks, which plays as normal.
+	difficulty, err := game.LookupDifficulty(cmp.Or(*difficultyFlag, state.Difficulty))
+	if err != nil {
+		difficulty = game.Difficulties[game.DifficultyNormal]
+	}
+	state.Difficulty = difficulty.Name
+	if *hardFlag {
+		// --hard is hard for this session, without replacing the saved choice
+		difficulty = game.Difficulties[game.DifficultyHard]
+	}
+	manager.CommandTimeout = difficulty.CommandTimeout
+
+	opts := ui.Options{HardMode: *hardFlag, Bell: *bellFlag, Demo: *demoFlag, Numbers: *numbersFlag, WindowTitle: !*noTitleFlag, SkipIntro: *skipIntroFlag, AllowShell: *allowShellFlag, Debug: *debugFlag, AutosaveInterval: *autosaveFlag, Layout: *layoutFlag, AutoHintAfter: *autoHintFlag, MaxOutputLines: *maxOutputFlag, MaxHistory: *maxHistoryFlag, Transcript: *transcriptFlag, Prompt: *promptFlag, A11y: *a11yFlag, FastValidate: *fastValidateFlag, SplitOutput: *splitOutputFlag, Recap: !*noRecapFlag, Profile: *profileFlag, VerboseSetup: *verboseSetupFlag, Difficulty: difficulty}
+	if *execFileFlag != "" {
+		os.Exit(runExecFile(*execFileFlag, quests, manager, state, startQuestIdx, opts))
+	}
+
+	if *serveFlag != "" {
+		progress := &server.ProgressServer{}
+		if err := progress.Listen(*serveFlag); err != nil {
+			fmt.Printf("Error starting progress server: %v\n", err)
+			os.Exit(1)
+		}
+		opts.OnProgress = progress.Publish
+	}
+
+	if *webhookFlag != "" {
+		hook, err := server.NewWebhook(*webhookFlag, *profileFlag)
+		if err != nil {
+			fmt.Printf("Error in --webhook: %v\n", err)
+			os.Exit(1)
+		}
+		opts.OnQuestComplete = hook.Send
+	}
+
+	if *a11yFlag {
+		ui.SetColor(false)
+	}
+
 	// 3. Start TUI
 	// The construction of the Image and Container will happen inside the UI for better feedback
-	p := tea.NewProgram(ui.NewModel(quests, manager, startQuestIdx, *hardFlag), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	p := tea.NewProgram(ui.NewModel(quests, manager, state, startQuestIdx, opts), tea.WithAltScreen())
+	final, err := p.Run()
+	if err != nil {
 		fmt.Printf("Alas, there's been an error: %v", err)
 		os.Exit(1)
 	}
+	if m, ok := final.(ui.Model); ok && m.ShutdownError() != nil {
+		names := []string{manager.ContainerName}
+		for _, spec := range manager.ActiveScenario() {
+			names = append(names, spec.Name)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v\nRemove them by hand with: %s rm -f %s\n",
+			m.ShutdownError(), manager.Runtime, strings.Join(names, " "))
+	}
+}
+
+// loadQuests reads the quest pack from --quests, or the bundled quests in
+// lang, exiting on failure
+func loadQuests(source, lang string, insecure bool) []game.Quest {
+	var quests []game.Quest
+	var err error
+	switch {
+	case game.IsRemoteQuestSource(source):
+		var cached bool
+		quests, cached, err = game.LoadRemoteQuests(source, insecure)
+		if cached {
+			fmt.Println("Couldn't download the quest pack, using the cached copy.")
+		}
+	case source != "":
+		quests, err = game.LoadQuests(source)
+	default:
+		cwd, cwdErr := os.Getwd()
+		if cwdErr != nil {
+			fmt.Printf("Error getting current directory: %v\n", cwdErr)
+			os.Exit(1)
+		}
+		quests, err = game.LoadQuests(game.LocalizedQuestPath(filepath.Join(cwd, "quests"), lang))
+	}
+	if err != nil {
+		fmt.Printf("Error loading quests: %v\n", err)
+		os.Exit(1)
+	}
+	return quests
+}
+
+// defaultProfile names the player after their login
+func defaultProfile() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return "player"
+}
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, " ") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
 }
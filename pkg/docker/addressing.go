@@ -0,0 +1,60 @@
+package docker
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// staticIPHint explains what to do when even the fallback to automatic
+// addresses didn't get a container running
+const staticIPHint = "the container runtime can't assign the game's static IPs. Rootless podman needs the netavark network backend for this (network_backend = \"netavark\" in containers.conf); running with docker, or podman as root, also works"
+
+// networkArgs joins a container to the game network with the given IP and
+// hostname. The hostname is also a network alias, so other containers can
+// reach it by name through the runtime's DNS, which is all that's left when
+// the IP can't be fixed (see DynamicIPs).
+func (m *Manager) networkArgs(ip, hostname string) []string {
+	args := []string{"--network", m.NetworkName, "--hostname", hostname, "--network-alias", hostname}
+	if !m.DynamicIPs {
+		args = append(args, "--ip", ip)
+	}
+	return args
+}
+
+// runContainer runs the container name with the run arguments from args. If
+// the runtime refuses a static IP, the half-made container is removed and
+// every container from then on starts with an automatic address instead.
+func (m *Manager) runContainer(name string, args func() []string) ([]byte, error) {
+	out, err := exec.Command(m.Runtime, args()...).CombinedOutput()
+	if err == nil || m.DynamicIPs || !isStaticIPError(string(out)) {
+		return out, err
+	}
+	_ = exec.Command(m.Runtime, "rm", "-f", name).Run()
+	m.DynamicIPs = true
+	out, err = exec.Command(m.Runtime, args()...).CombinedOutput()
+	if err != nil {
+		return out, fmt.Errorf("%v; %s", err, staticIPHint)
+	}
+	return out, nil
+}
+
+// staticIPRefusals are how the runtimes refuse a --ip they can't assign.
+// Anything broader (a bad --add-host address, say) must not match, or one
+// unrelated failure would switch the whole session to automatic addresses.
+var staticIPRefusals = []string{
+	"user specified ip address is supported",                  // docker, network without a user subnet
+	"static ip/mac address can only be used with bridge mode", // podman with slirp4netns
+	"requested ip address",                                    // podman with CNI, IPAM refusing the address
+}
+
+// isStaticIPError reports whether a failed run was refused because of its --ip
+func isStaticIPError(output string) bool {
+	lower := strings.ToLower(output)
+	for _, refusal := range staticIPRefusals {
+		if strings.Contains(lower, refusal) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// baselinePath is where the pristine copy of the image's home is kept, next
+// to the storage at localPath
+func baselinePath(localPath string) string {
+	return localPath + "-baseline"
+}
+
+// ResetHome puts the player's home back to the image's pristine copy, for
+// quests with reset_policy "home". The bind-mounted storage is emptied and
+// refilled from the host, so the running containers keep going and see the
+// change at once. The copy is taken from the image on first use each session,
+// so a rebuilt image is picked up. The working directory goes back to home.
+func (m *Manager) ResetHome() error {
+	localPath, err := m.storagePath()
+	if err != nil {
+		return err
+	}
+	baseline := baselinePath(localPath)
+	if !m.baselineTaken {
+		if err := m.snapshotHome(baseline); err != nil {
+			return fmt.Errorf("failed to copy the image's home: %v", err)
+		}
+		m.baselineTaken = true
+	}
+
+	// A file the player locked down would stop the removal below; if opening
+	// them up fails, the removal says which one
+	_ = m.unlockStorage(localPath)
+	entries, err := os.ReadDir(localPath)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := os.RemoveAll(filepath.Join(localPath, e.Name())); err != nil {
+			return fmt.Errorf("failed to clear the home directory: %v", err)
+		}
+	}
+	if err := os.CopyFS(localPath, os.DirFS(baseline)); err != nil {
+		return fmt.Errorf("failed to restore the home directory: %v", err)
+	}
+
+	m.CurrentDir = m.HomeDir()
+	return nil
+}
+
+// snapshotHome copies the image's own home, as it is before the storage is
+// mounted over it, into dir. Everything is made readable and writable so
+// the host can copy it back and later delete it.
+func (m *Manager) snapshotHome(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	script := fmt.Sprintf("cp -a %s/. /baseline/ && chmod -R a+rwX /baseline", shellQuote(m.HomeDir()))
+	out, err := exec.Command(m.Runtime, "run", "--rm", "-u", "0", "--network", "none", "--entrypoint", fallbackShell,
+		"-v", fmt.Sprintf("%s:/baseline:z", dir), m.ImageName, "-c", script).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v\nOutput: %s", err, string(out))
+	}
+	return nil
+}
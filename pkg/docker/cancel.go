@@ -0,0 +1,56 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// killTimeout bounds the exec that stops a cancelled command
+const killTimeout = 5 * time.Second
+
+// cancellableArgs runs command in its own session, so its process group can
+// be killed inside the container: killing the runtime's exec client leaves
+// the command running, and a runaway 'yes > file' fills the disk. The group
+// id is written to pidFile while the command runs. Without setsid only the
+// command's own pid is recorded.
+func (m *Manager) cancellableArgs(command, pidFile string) []string {
+	inner := fmt.Sprintf(`echo $$ > %[1]s; "$0" -c "$1"; s=$?; rm -f %[1]s; exit $s`, pidFile)
+	wrapper := fmt.Sprintf(`if command -v setsid >/dev/null; then exec setsid -w sh -c '%s' "$0" "$1"; fi; echo $$ > %s; exec "$0" -c "$1"`, inner, pidFile)
+	// The wrapper is POSIX sh whatever --shell says; the command gets the configured shell
+	return []string{fallbackShell, "-c", wrapper, m.shell(), command}
+}
+
+// killCommand stops the command that recorded pidFile, with everything it
+// started. It runs as root when it can, so commands under sudo stop too.
+func (m *Manager) killCommand(pidFile string) error {
+	target := m.execTarget()
+	args := []string{"exec"}
+	if m.NoRoot {
+		args = append(args, target...)
+	} else {
+		args = append(args, "-u", "0", target[len(target)-1])
+	}
+	_, err := m.runExec(append(args, fallbackShell, "-c", killScript(pidFile)), killTimeout)
+	return err
+}
+
+// killScript kills the process group recorded in pidFile, or just the pid
+// when the command couldn't get a group of its own. The command may not
+// have written its pid yet if it was cancelled at once.
+func killScript(pidFile string) string {
+	wait := fmt.Sprintf(`for i in 1 2 3 4 5 6 7 8 9 10; do [ -f %s ] && break; sleep 0.1; done`, pidFile)
+	return fmt.Sprintf(`%[1]s; p=$(cat %[2]s 2>/dev/null) || exit 0; kill -KILL -"$p" 2>/dev/null || kill -KILL "$p"; rm -f %[2]s`, wait, pidFile)
+}
+
+// commandPIDFile names the file a cancellable command records its process
+// group in. Each run gets its own, so a stale one is never killed.
+func commandPIDFile() string {
+	return fmt.Sprintf("/tmp/.goblin-command-%d.pid", time.Now().UnixNano())
+}
+
+// canCancel reports whether ctx can ever be cancelled; commands that can't
+// be run as they are, without the wrapper
+func canCancel(ctx context.Context) bool {
+	return ctx.Done() != nil
+}
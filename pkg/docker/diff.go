@@ -0,0 +1,44 @@
+package docker
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Kinds of filesystem change, as the runtime's diff prints them
+const (
+	FSAdded   = 'A'
+	FSChanged = 'C'
+	FSDeleted = 'D'
+)
+
+// FSChange is one path the player container changed relative to its image
+type FSChange struct {
+	Kind byte // FSAdded, FSChanged or FSDeleted
+	Path string
+}
+
+// DiffFilesystem lists what the running player container changed relative to
+// its image. The home directory is a bind mount, so files inside it never
+// appear; only the directory itself can.
+func (m *Manager) DiffFilesystem() ([]FSChange, error) {
+	out, err := exec.Command(m.Runtime, "diff", m.ContainerName).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%s diff %s: %v (%s)", m.Runtime, m.ContainerName, err, strings.TrimSpace(string(out)))
+	}
+	return parseDiff(string(out)), nil
+}
+
+// parseDiff reads "A /path" lines, skipping anything else the runtime printed
+func parseDiff(out string) []FSChange {
+	var changes []FSChange
+	for _, line := range strings.Split(out, "\n") {
+		kind, path, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok || len(kind) != 1 || !strings.Contains("ACD", kind) {
+			continue
+		}
+		changes = append(changes, FSChange{Kind: kind[0], Path: strings.TrimSpace(path)})
+	}
+	return changes
+}
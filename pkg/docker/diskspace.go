@@ -0,0 +1,85 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultMinFreeSpace is the free space wanted before building: the base image
+// plus apt packages need roughly a gigabyte, so leave some headroom
+const DefaultMinFreeSpace uint64 = 2 << 30
+
+// LowDiskSpaceError reports a storage location below the configured threshold
+type LowDiskSpaceError struct {
+	Path      string
+	Free      uint64
+	Threshold uint64
+}
+
+func (e *LowDiskSpaceError) Error() string {
+	return fmt.Sprintf("low disk space: %s has %.1f GB free, at least %.1f GB recommended",
+		e.Path, float64(e.Free)/(1<<30), float64(e.Threshold)/(1<<30))
+}
+
+// CheckDiskSpace checks the runtime's image storage and the player storage directory.
+// Returns a *LowDiskSpaceError for the first location under MinFreeSpace.
+// Locations that can't be inspected are skipped.
+func (m *Manager) CheckDiskSpace() error {
+	if m.MinFreeSpace == 0 {
+		return nil
+	}
+
+	var paths []string
+	if root := m.runtimeRootDir(); root != "" {
+		paths = append(paths, root)
+	}
+	if storage, err := m.storagePath(); err == nil {
+		paths = append(paths, existingParent(storage))
+	}
+
+	for _, path := range paths {
+		free, err := freeSpace(path)
+		if err != nil {
+			continue
+		}
+		if free < m.MinFreeSpace {
+			return &LowDiskSpaceError{Path: path, Free: free, Threshold: m.MinFreeSpace}
+		}
+	}
+	return nil
+}
+
+// runtimeRootDir asks the runtime where it keeps images, or "" if unknown
+func (m *Manager) runtimeRootDir() string {
+	format := "{{.DockerRootDir}}"
+	if m.Runtime == "podman" {
+		format = "{{.Store.GraphRoot}}"
+	}
+	out, err := exec.Command(m.Runtime, "info", "--format", format).Output()
+	if err != nil {
+		return ""
+	}
+	root := strings.TrimSpace(string(out))
+	if root == "" {
+		return ""
+	}
+	// The root dir is often not readable by the user (e.g. /var/lib/docker)
+	return existingParent(root)
+}
+
+// existingParent walks up from path until it finds something that exists
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package docker
+
+import "errors"
+
+// freeSpace is not implemented on Windows; the check is skipped
+func freeSpace(path string) (uint64, error) {
+	return 0, errors.New("disk space check not supported on windows")
+}
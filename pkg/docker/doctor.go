@@ -0,0 +1,111 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// CheckStatus grades one diagnostic check
+type CheckStatus string
+
+const (
+	CheckOK   CheckStatus = "OK"
+	CheckWarn CheckStatus = "WARN"
+	CheckFail CheckStatus = "FAIL"
+)
+
+// CheckResult is the outcome of one diagnostic check
+type CheckResult struct {
+	Name   string
+	Status CheckStatus
+	Detail string
+}
+
+// Diagnose runs the environment health checks behind --doctor. Each check
+// runs on its own, so one failure doesn't hide the others.
+func (m *Manager) Diagnose() []CheckResult {
+	var results []CheckResult
+	add := func(name string, status CheckStatus, detail string) {
+		results = append(results, CheckResult{Name: name, Status: status, Detail: detail})
+	}
+
+	// Runtime and version
+	if out, err := exec.Command(m.Runtime, "--version").CombinedOutput(); err != nil {
+		add("runtime", CheckFail, fmt.Sprintf("%s --version failed: %v", m.Runtime, err))
+	} else {
+		add("runtime", CheckOK, strings.TrimSpace(string(out)))
+	}
+
+	// Daemon (docker) or storage backend (podman) reachable
+	daemonUp := true
+	if out, err := exec.Command(m.Runtime, "info").CombinedOutput(); err != nil {
+		daemonUp = false
+		add("daemon", CheckFail, firstLine(string(out), err))
+	} else {
+		add("daemon", CheckOK, "reachable")
+	}
+
+	// Game image
+	imageBuilt := false
+	switch {
+	case !daemonUp:
+		add("image", CheckWarn, "skipped, runtime not reachable")
+	case exec.Command(m.Runtime, "image", "inspect", m.ImageName).Run() == nil:
+		imageBuilt = true
+		add("image", CheckOK, m.ImageName)
+	default:
+		add("image", CheckWarn, m.ImageName+" not built yet; it will be built on first launch")
+	}
+
+	// Network configuration and existing network
+	netErr := m.ValidateNetwork()
+	switch {
+	case netErr != nil:
+		add("network", CheckFail, netErr.Error())
+	case !daemonUp:
+		add("network", CheckWarn, "skipped, runtime not reachable")
+	case exec.Command(m.Runtime, "network", "inspect", m.NetworkName).Run() == nil:
+		add("network", CheckOK, fmt.Sprintf("%s exists (%s)", m.NetworkName, m.Subnet))
+	default:
+		add("network", CheckOK, fmt.Sprintf("%s will be created (%s)", m.NetworkName, m.Subnet))
+	}
+
+	// Disk space
+	var lowErr *LowDiskSpaceError
+	if err := m.CheckDiskSpace(); errors.As(err, &lowErr) {
+		add("disk", CheckWarn, err.Error())
+	} else if m.MinFreeSpace == 0 {
+		add("disk", CheckOK, "check disabled")
+	} else {
+		add("disk", CheckOK, fmt.Sprintf("at least %.1f GB free", float64(m.MinFreeSpace)/(1<<30)))
+	}
+
+	// Capabilities: can a container get NET_RAW for ping quests?
+	switch {
+	case !imageBuilt:
+		add("net_raw", CheckWarn, "skipped, image not built")
+	default:
+		out, err := exec.Command(m.Runtime, "run", "--rm", "--cap-add", "NET_RAW", m.ImageName, "true").CombinedOutput()
+		switch {
+		case err == nil:
+			add("net_raw", CheckOK, "permitted")
+		case isCapabilityError(string(out)):
+			add("net_raw", CheckWarn, "refused; ping quests will use a TCP check instead")
+		default:
+			add("net_raw", CheckWarn, firstLine(string(out), err))
+		}
+	}
+
+	return results
+}
+
+// firstLine summarises a failed command by its first line of output
+func firstLine(out string, err error) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(out), "\n")
+	if line == "" {
+		return err.Error()
+	}
+	return line
+}
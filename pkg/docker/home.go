@@ -0,0 +1,38 @@
+package docker
+
+import (
+	"os/exec"
+	"path"
+	"strings"
+)
+
+// DefaultHome is the player's home in the bundled image, used until the
+// image says otherwise
+const DefaultHome = "/home/player"
+
+// HomeDir is the player's home in the container: where the storage is
+// mounted, where cd goes with no argument, and what ~ stands for
+func (m *Manager) HomeDir() string {
+	if m.Home == "" {
+		return DefaultHome
+	}
+	return m.Home
+}
+
+// detectHome asks the image for the player user's $HOME, so an image built
+// with a different home still gets its storage and paths in the right place.
+// It runs before the player container starts, since the mount depends on it.
+// A home already set is kept, and an unusable answer leaves DefaultHome.
+func (m *Manager) detectHome() {
+	if m.Home != "" {
+		return
+	}
+	out, err := exec.Command(m.Runtime, "run", "--rm", "--network", "none", "--entrypoint", fallbackShell,
+		m.ImageName, "-c", `echo "$HOME"`).Output()
+	home := strings.TrimSpace(string(out))
+	// Mounting the storage over / (or a relative path) would be a disaster
+	if err != nil || !path.IsAbs(home) || path.Clean(home) == "/" {
+		return
+	}
+	m.Home = path.Clean(home)
+}
@@ -0,0 +1,73 @@
+package docker
+
+import (
+	"cmp"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// SetHost moves the player's commands to the scenario host with the given
+// hostname (say "gateway"), as if they had ssh'd there; empty brings them
+// back to the player container. Each host keeps its own working directory,
+// so returning finds the player where they left off.
+func (m *Manager) SetHost(host string) error {
+	if host == m.Host {
+		return nil
+	}
+	if host != "" {
+		if _, ok := m.hostContainer(host); !ok {
+			return fmt.Errorf("no host %q in the running scenario", host)
+		}
+		// The aux containers run as root; commands there run as the player
+		m.hostUser = m.playerUID()
+	}
+	if m.hostDirs == nil {
+		m.hostDirs = make(map[string]string)
+	}
+	m.hostDirs[m.Host] = m.CurrentDir
+	m.Host = host
+	m.CurrentDir = cmp.Or(m.hostDirs[host], m.HomeDir())
+	return nil
+}
+
+// leaveHost puts the player back on their own container and forgets the
+// other hosts' directories, for when the containers are recreated
+func (m *Manager) leaveHost() {
+	m.Host = ""
+	m.hostDirs = nil
+}
+
+// hostContainer finds the container of the scenario host with the given hostname
+func (m *Manager) hostContainer(host string) (string, bool) {
+	for _, spec := range m.ActiveScenario() {
+		if cmp.Or(spec.Hostname, spec.Name) == host {
+			return spec.Name, true
+		}
+	}
+	return "", false
+}
+
+// playerUID is the user player commands run as in the player container;
+// empty when it can't be told, leaving the host's default user
+func (m *Manager) playerUID() string {
+	out, err := exec.Command(m.Runtime, "exec", m.ContainerName, "id", "-u").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// execTarget is the exec arguments that run a player command in the tracked
+// directory of the current host: the player container unless SetHost moved them
+func (m *Manager) execTarget() []string {
+	name, ok := m.hostContainer(m.Host)
+	if m.Host == "" || !ok {
+		return []string{"-w", m.CurrentDir, m.ContainerName}
+	}
+	var args []string
+	if m.hostUser != "" {
+		args = append(args, "-u", m.hostUser)
+	}
+	return append(args, "-w", m.CurrentDir, name)
+}
@@ -1,8 +1,11 @@
 package docker
 
 import (
-	"bytes"
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -16,10 +19,88 @@ type Manager struct {
 	ContainerName string
 	GatewayName   string // New: Gateway container name
 	NetworkName   string // New: Custom network name
+	Subnet        string // CIDR of the custom network
+	GatewayIP     string // Static IP of the gateway container
+	PlayerIP      string // Static IP of the player container
 	Runtime       string // "docker" or "podman"
 	CurrentDir    string // Tracks the current working directory in the container
+	StoragePath   string // Host directory bind-mounted as the player's home; empty means the default
+	Home          string // Player's home in the container, detected at start; empty means DefaultHome
+	MinFreeSpace  uint64 // Bytes of free disk wanted before building; 0 disables the check
+
+	// CommandTimeout kills a player command that runs longer; zero means
+	// DefaultCommandTimeout
+	CommandTimeout time.Duration
+
+	// NetRawUnavailable is set when the host refused NET_RAW and the player
+	// container was started without it, so ping won't work
+	NetRawUnavailable bool
+
+	// DynamicIPs is set when the runtime refused the static IPs (common with
+	// rootless podman), so containers get automatic addresses and are reached
+	// by name instead
+	DynamicIPs bool
+
+	// NoRoot never execs as root in the container, for runtimes that refuse it.
+	// Set by --no-root, or by StartContainer when the root exec probe fails.
+	NoRoot bool
+
+	// HomeRepaired is set when StartContainer found files in the player's home
+	// they couldn't access and fixed them; HomeRepairErr when that fix failed
+	HomeRepaired  bool
+	HomeRepairErr error
+
+	// Hardening knobs for the player container
+	CapDropAll      bool     // Drop every capability before adding CapAdd
+	CapAdd          []string // Capabilities granted to the player container
+	NoNewPrivileges bool     // Block privilege escalation (this also blocks sudo)
+	ReadOnlyRoot    bool     // Read-only root filesystem; home and tmp dirs stay writable
+
+	// ExtraRunArgs are passed as-is to the player container's run command,
+	// before the image name (e.g. "--env=FOO=bar", "--add-host=db:10.0.0.5")
+	ExtraRunArgs []string
+
+	// Shell runs every command in the player container; empty means
+	// DefaultShell. StartContainer falls back to sh when it is missing.
+	Shell string
+	// ShellFallback names the configured shell when it wasn't found in the
+	// container and sh is used instead; Shell keeps the configured one
+	ShellFallback string
+
+	// Scenario lists the auxiliary containers started next to the player;
+	// nil means DefaultScenario (the SSH gateway)
+	Scenario []ContainerSpec
+
+	// Host is the scenario host the player's commands run on (see SetHost);
+	// empty means the player container
+	Host string
+
+	// baselineTaken is set once ResetHome has copied the image's home this session
+	baselineTaken bool
+	// hostDirs keeps each host's working directory while the player is elsewhere,
+	// and hostUser the player's uid for running commands on other hosts
+	hostDirs map[string]string
+	hostUser string
 }
 
+// HardenedCapabilities is the minimal set kept in hardened mode.
+// sudo needs the SETUID/SETGID/AUDIT_WRITE group, file quests need CHOWN/DAC_OVERRIDE/FOWNER,
+// killing processes needs KILL and ping needs NET_RAW.
+var HardenedCapabilities = []string{
+	"CHOWN", "DAC_OVERRIDE", "FOWNER", "SETUID", "SETGID", "AUDIT_WRITE", "KILL", "NET_RAW",
+}
+
+// DefaultCommandTimeout is how long a player command may run unless the
+// difficulty allows longer
+const DefaultCommandTimeout = 5 * time.Second
+
+// Default network layout, used unless overridden by flags
+const (
+	DefaultSubnet    = "10.10.10.0/24"
+	DefaultGatewayIP = "10.10.10.2"
+	DefaultPlayerIP  = "10.10.10.3"
+)
+
 // NewManager creates a new container manager
 func NewManager(imageName, containerName string) (*Manager, error) {
 	// Check for container runtime
@@ -38,8 +119,13 @@ func NewManager(imageName, containerName string) (*Manager, error) {
 		ContainerName: containerName,
 		GatewayName:   containerName + "_gateway",
 		NetworkName:   "goblin_net",
+		Subnet:        DefaultSubnet,
+		GatewayIP:     DefaultGatewayIP,
+		PlayerIP:      DefaultPlayerIP,
 		Runtime:       runtime,
-		CurrentDir:    "/home/player", // Default start dir
+		CurrentDir:    DefaultHome, // Default start dir
+		CapAdd:        []string{"NET_RAW"},
+		MinFreeSpace:  DefaultMinFreeSpace,
 	}, nil
 }
 
@@ -53,6 +139,32 @@ func (m *Manager) BuildImage() error {
 	return nil
 }
 
+// ValidateNetwork checks that the subnet parses and both container IPs fall inside it
+func (m *Manager) ValidateNetwork() error {
+	_, subnet, err := net.ParseCIDR(m.Subnet)
+	if err != nil {
+		return fmt.Errorf("invalid subnet %q: %v", m.Subnet, err)
+	}
+
+	for _, entry := range []struct{ name, ip string }{
+		{"gateway", m.GatewayIP},
+		{"player", m.PlayerIP},
+	} {
+		ip := net.ParseIP(entry.ip)
+		if ip == nil {
+			return fmt.Errorf("invalid %s IP %q", entry.name, entry.ip)
+		}
+		if !subnet.Contains(ip) {
+			return fmt.Errorf("%s IP %s is outside subnet %s", entry.name, entry.ip, m.Subnet)
+		}
+	}
+
+	if m.GatewayIP == m.PlayerIP {
+		return fmt.Errorf("gateway and player IPs must differ (both %s)", m.PlayerIP)
+	}
+	return nil
+}
+
 // EnsureNetwork creates the custom network if it doesn't exist
 func (m *Manager) EnsureNetwork() error {
 	// Check if network exists
@@ -63,7 +175,7 @@ func (m *Manager) EnsureNetwork() error {
 
 	// Create network with specific subnet
 	// docker network create --subnet=10.10.10.0/24 goblin_net
-	cmd := exec.Command(m.Runtime, "network", "create", "--subnet=10.10.10.0/24", m.NetworkName)
+	cmd := exec.Command(m.Runtime, "network", "create", "--subnet="+m.Subnet, m.NetworkName)
 	if out, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("failed to create network: %v\nOutput: %s", err, string(out))
 	}
@@ -80,31 +192,60 @@ func (m *Manager) StartContainer() error {
 		return err
 	}
 
-	// 3. Start Gateway Container (The Target)
-	// runs sshd
-	// IP: 10.10.10.2
-	// Needs to run as root (User 0) to bind port 22 and needs host keys generated
-	gatewayCmd := exec.Command(m.Runtime, "run", "-d", "--rm",
-		"--name", m.GatewayName,
-		"--network", m.NetworkName,
-		"--ip", "10.10.10.2",
-		"--hostname", "gateway",
-		"--user", "0",
-		m.ImageName,
-		"bash", "-c", "ssh-keygen -A && /usr/sbin/sshd -D")
-
-	if out, err := gatewayCmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to start gateway: %v\nOutput: %s", err, string(out))
+	// 3. Start the auxiliary containers (the gateway, unless a quest set a scenario)
+	if err := m.startAuxContainers(m.ActiveScenario()); err != nil {
+		return err
 	}
 
 	// 4. Start Player Container (The Terminal)
-	// IP: 10.10.10.3
+	return m.startPlayer()
+}
+
+// RestartPlayerOnly recreates the player container and leaves the gateway
+// (and any scenario hosts) running, so networking quests keep a stable target.
+// Progress-dependent state is restored for questID, and the working directory
+// is kept when it still exists, since the home directory survives the restart.
+func (m *Manager) RestartPlayerOnly(questID int) error {
+	dir := m.CurrentDir
+	if m.Host != "" {
+		// The player is on another host; it's their own directory that is kept
+		dir = m.hostDirs[""]
+	}
+	if err := m.removeContainer(m.ContainerName); err != nil {
+		return err
+	}
+	if err := m.EnsureNetwork(); err != nil {
+		return err
+	}
+	if err := m.startPlayer(); err != nil {
+		return err
+	}
+
+	m.CurrentDir = dir
+	m.RefreshCurrentDir()
+	return m.RestoreEnvironment(questID)
+}
+
+// RestartGatewayOnly recreates the gateway (and any scenario hosts) and leaves
+// the player container, with its shell state and processes, alone
+func (m *Manager) RestartGatewayOnly() error {
+	if err := m.removeContainers(m.ActiveScenario()); err != nil {
+		return err
+	}
+	if err := m.EnsureNetwork(); err != nil {
+		return err
+	}
+	return m.startAuxContainers(m.ActiveScenario())
+}
+
+// startPlayer runs the player container (The Terminal)
+// IP: PlayerIP (10.10.10.3 by default)
+func (m *Manager) startPlayer() error {
 	// Ensure local storage directory exists
-	homeDir, err := os.UserHomeDir()
+	localPath, err := m.storagePath()
 	if err != nil {
-		return fmt.Errorf("failed to get user home dir: %v", err)
+		return err
 	}
-	localPath := filepath.Join(homeDir, ".local", "share", "goblin-terminal", "fs")
 	if err := os.MkdirAll(localPath, 0755); err != nil {
 		return fmt.Errorf("failed to create local storage directory: %v", err)
 	}
@@ -112,134 +253,327 @@ func (m *Manager) StartContainer() error {
 		return fmt.Errorf("failed to chmod local storage directory: %v", err)
 	}
 
-	playerCmd := exec.Command(m.Runtime, "run", "-d", "--rm", "--init",
-		"--cap-add=NET_RAW",
-		"--name", m.ContainerName,
-		"--network", m.NetworkName,
-		"--ip", "10.10.10.3",
-		"--hostname", "goblin",
-		"-v", fmt.Sprintf("%s:/home/player:z", localPath),
-		m.ImageName)
+	m.detectHome()
+	if out, err := m.runContainer(m.ContainerName, func() []string { return m.playerRunArgs(localPath) }); err != nil {
+		// Rootless or locked-down hosts may refuse NET_RAW. Rather than failing the
+		// whole launch, start without it and let ping-based quests fall back.
+		if !m.hasCap("NET_RAW") || !isCapabilityError(string(out)) {
+			return fmt.Errorf("failed to start container: %v\nOutput: %s", err, string(out))
+		}
+		_ = exec.Command(m.Runtime, "rm", "-f", m.ContainerName).Run()
+		m.CapAdd = withoutCap(m.CapAdd, "NET_RAW")
+		if out, err := m.runContainer(m.ContainerName, func() []string { return m.playerRunArgs(localPath) }); err != nil {
+			return fmt.Errorf("failed to start container: %v\nOutput: %s", err, string(out))
+		}
+		m.NetRawUnavailable = true
+	}
 
-	if out, err := playerCmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to start container: %v\nOutput: %s", err, string(out))
+	// Minimal images may lack bash, which would break every exec
+	m.ensureShell()
+
+	// Fall back to safe mode rather than failing every root step later
+	if !m.NoRoot && !m.CanExecAsRoot() {
+		m.NoRoot = true
 	}
 
-	// Reset dir on start
-	m.CurrentDir = "/home/player"
+	// An earlier session may have left root-owned, locked-down files in the
+	// bind mount. Only costs a find when nothing is wrong.
+	m.HomeRepaired, m.HomeRepairErr = m.RepairHome()
+
+	// Reset dir on start, back on the player's own container
+	m.leaveHost()
+	m.CurrentDir = m.HomeDir()
 	return nil
 }
 
-// StopContainer stops and removes the containers
-func (m *Manager) StopContainer() error {
-	// Stop Player
-	cmd := exec.Command(m.Runtime, "rm", "-f", m.ContainerName)
-	_ = cmd.Run()
+// storagePath returns the host directory holding the player's home
+func (m *Manager) storagePath() (string, error) {
+	if m.StoragePath != "" {
+		return m.StoragePath, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home dir: %v", err)
+	}
+	return filepath.Join(homeDir, ".local", "share", "goblin-terminal", "fs"), nil
+}
 
-	// Stop Gateway
-	cmd2 := exec.Command(m.Runtime, "rm", "-f", m.GatewayName)
-	_ = cmd2.Run()
+// playerRunArgs builds the "run" arguments for the player container
+func (m *Manager) playerRunArgs(localPath string) []string {
+	args := []string{"run", "-d", "--rm", "--init"}
 
+	if m.CapDropAll {
+		args = append(args, "--cap-drop=ALL")
+	}
+	for _, capability := range m.CapAdd {
+		args = append(args, "--cap-add="+capability)
+	}
+	if m.NoNewPrivileges {
+		args = append(args, "--security-opt", "no-new-privileges")
+	}
+	if m.ReadOnlyRoot {
+		// Quests stage files in /tmp, and services need /run
+		args = append(args, "--read-only",
+			"--tmpfs", "/tmp",
+			"--tmpfs", "/run",
+			"--tmpfs", "/var/tmp")
+	}
+
+	args = append(args, "--name", m.ContainerName)
+	args = append(args, m.networkArgs(m.PlayerIP, "goblin")...)
+	args = append(args, "-v", fmt.Sprintf("%s:%s:z", localPath, m.HomeDir()))
+	args = append(args, m.ExtraRunArgs...)
+	return append(args, m.ImageName)
+}
+
+// ValidateRunArgs rejects extra run arguments that can't be passed through
+// safely. Arguments are never run through a shell, so only a few checks apply.
+func ValidateRunArgs(args []string) error {
+	for _, arg := range args {
+		if strings.TrimSpace(arg) == "" {
+			return fmt.Errorf("empty --docker-arg")
+		}
+		if strings.ContainsAny(arg, "\x00\n\r") {
+			return fmt.Errorf("--docker-arg %q contains a control character", arg)
+		}
+	}
 	return nil
 }
 
+// hasCap reports whether capability is in CapAdd
+func (m *Manager) hasCap(capability string) bool {
+	for _, c := range m.CapAdd {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutCap returns caps with capability removed
+func withoutCap(caps []string, capability string) []string {
+	var result []string
+	for _, c := range caps {
+		if c != capability {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// isCapabilityError guesses whether a failed run was refused because of a capability
+func isCapabilityError(output string) bool {
+	lower := strings.ToLower(output)
+	for _, hint := range []string{"net_raw", "capabilit", "operation not permitted"} {
+		if strings.Contains(lower, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// Harden turns on every hardening knob for classroom deployments
+func (m *Manager) Harden() {
+	m.CapDropAll = true
+	m.CapAdd = append([]string{}, HardenedCapabilities...)
+	m.NoNewPrivileges = true
+	m.ReadOnlyRoot = true
+}
+
+// What the hardening settings take away from the player, for HardeningRestrictions
+const (
+	RestrictSudo         = "sudo"           // no-new-privileges blocks sudo
+	RestrictReadOnlyRoot = "read_only_root" // Nothing outside home and /tmp can be written
+	RestrictPing         = "ping"           // NET_RAW isn't granted
+)
+
+// HardeningRestrictions lists what the current hardening settings block. Which
+// quests that breaks depends on the quest pack, so callers work that out.
+func (m *Manager) HardeningRestrictions() []string {
+	var restrictions []string
+	if m.NoNewPrivileges {
+		restrictions = append(restrictions, RestrictSudo)
+	}
+	if m.ReadOnlyRoot {
+		restrictions = append(restrictions, RestrictReadOnlyRoot)
+	}
+	if m.CapDropAll && !m.hasCap("NET_RAW") {
+		restrictions = append(restrictions, RestrictPing)
+	}
+	return restrictions
+}
+
+// StopContainer stops and removes the player and every auxiliary container.
+// Containers that are already gone don't count as failures.
+func (m *Manager) StopContainer() error {
+	return errors.Join(m.removeContainer(m.ContainerName), m.removeContainers(m.ActiveScenario()))
+}
+
 // ExecuteCommand runs a command inside the container and returns stdout/stderr
 func (m *Manager) ExecuteCommand(command string) (string, error) {
+	res, err := m.RunCommand(command)
+	if err != nil {
+		return "", err
+	}
+	return res.Stdout, nil
+}
+
+// CommandResult is what a player command wrote, kept stream by stream and
+// interleaved in the order it was written, as a terminal would show it
+type CommandResult struct {
+	Stdout   string
+	Stderr   string
+	Combined string
+}
+
+// RunCommand is ExecuteCommand keeping both streams. Plenty of tools (useradd,
+// for one) report on stderr while exiting 0, so stderr alone isn't a failure:
+// the error is set only when the command exits nonzero, carrying its stderr.
+func (m *Manager) RunCommand(command string) (CommandResult, error) {
+	return m.RunCommandContext(context.Background(), command)
+}
+
+// RunCommandContext is RunCommand giving up when ctx is cancelled, for a
+// player who doesn't want to wait. The error is then ctx's.
+func (m *Manager) RunCommandContext(ctx context.Context, command string) (CommandResult, error) {
 	// Handle 'cd' specially
 	trimmedCmd := strings.TrimSpace(command)
 	if strings.HasPrefix(trimmedCmd, "cd ") || trimmedCmd == "cd" {
-		target := "/home/player" // default cd
-		if len(trimmedCmd) > 3 {
-			target = strings.TrimSpace(trimmedCmd[3:])
+		// Parse quotes and escapes ourselves so the target reaches bash as one word
+		target, err := cdTarget(strings.TrimPrefix(trimmedCmd, "cd"), m.HomeDir())
+		if err != nil {
+			return CommandResult{}, err
 		}
 
 		// To safely change directory, we try to cd AND print pwd
 		// We execute this from the CURRENT tracked directory
-		// escaping is tricky so we construct a secure command
 
 		// "cd <current> && cd <target> && pwd"
-		fullCmd := fmt.Sprintf("cd %s && cd %s && pwd", m.CurrentDir, target)
-
-		args := []string{"exec", m.ContainerName, "bash", "-c", fullCmd}
-		cmd := exec.Command(m.Runtime, args...)
+		fullCmd := fmt.Sprintf("cd %s && cd %s && pwd", shellQuote(m.CurrentDir), target)
 
-		var out bytes.Buffer
-		var stderr bytes.Buffer
-		cmd.Stdout = &out
-		cmd.Stderr = &stderr
-
-		if err := cmd.Run(); err != nil {
+		args := append([]string{"exec"}, m.execTarget()...)
+		res, err := m.runExecContext(ctx, append(args, m.shell(), "-c", fullCmd), 0, "")
+		if ctx.Err() != nil {
+			return CommandResult{}, ctx.Err()
+		}
+		if err != nil {
 			// If cd fails, return the error (e.g. no such directory)
-			errStr := stderr.String()
+			errStr := res.stderr
 			if errStr == "" {
 				errStr = "No such file or directory" // default generic
 			}
-			return "", fmt.Errorf("%s", errStr)
+			return CommandResult{Stderr: res.stderr}, fmt.Errorf("%s", errStr)
 		}
 
 		// Update persistent state
-		newDir := strings.TrimSpace(out.String())
+		newDir := strings.TrimSpace(res.stdout)
 		if newDir != "" {
 			m.CurrentDir = newDir
 		}
-		return "", nil // cd produces no output on success usually, or we could return empty
+		return CommandResult{}, nil // cd produces no output on success usually, or we could return empty
 	}
 
 	// For normal commands, execute them in the current working directory
 	// We use the -w flag if possible, OR we chain cd.
 	// docker exec -w /current/path ...
 
-	args := []string{"exec", "-w", m.CurrentDir, m.ContainerName, "bash", "-c", command}
-	cmd := exec.Command(m.Runtime, args...)
-
-	// Create a timer to kill command if it hangs
-	time.AfterFunc(5*time.Second, func() {
-		if cmd.Process != nil {
-			cmd.Process.Kill()
-		}
-	})
-
-	var out bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
+	return m.execPlayer(ctx, command, "")
+}
 
-	output := out.String()
-	errOut := stderr.String()
+// RunCommandWithInput is RunCommand for commands that read stdin (cat >
+// file, read, chpasswd): stdin is fed to the command, which sees EOF after
+// it. With no input the command gets EOF straight away.
+func (m *Manager) RunCommandWithInput(command, stdin string) (CommandResult, error) {
+	if stdin == "" {
+		return m.RunCommand(command)
+	}
+	return m.execPlayer(context.Background(), command, stdin)
+}
 
+// ExecuteCommandWithInput is ExecuteCommand feeding stdin to the command
+func (m *Manager) ExecuteCommandWithInput(command, stdin string) (string, error) {
+	res, err := m.RunCommandWithInput(command, stdin)
 	if err != nil {
-		if errOut != "" {
-			return "", fmt.Errorf("%s", errOut)
-		}
 		return "", err
 	}
+	return res.Stdout, nil
+}
 
-	return output, nil
+// execPlayer runs a player command in the tracked directory of the current
+// host, feeding it stdin
+// if there is any. The error carries stderr when the command wrote some.
+func (m *Manager) execPlayer(ctx context.Context, command, stdin string) (CommandResult, error) {
+	args := []string{"exec"}
+	if stdin != "" {
+		args = append(args, "-i")
+	}
+	args = append(args, m.execTarget()...)
+	pidFile := ""
+	if canCancel(ctx) {
+		pidFile = commandPIDFile()
+		args = append(args, m.cancellableArgs(command, pidFile)...)
+	} else {
+		args = append(args, m.shell(), "-c", command)
+	}
+	res, err := m.runExecContext(ctx, args, cmp.Or(m.CommandTimeout, DefaultCommandTimeout), stdin)
+	result := CommandResult{Stdout: res.stdout, Stderr: res.stderr, Combined: res.combined}
+	if ctx.Err() != nil {
+		// Only the runtime's exec client was killed; stop the command itself
+		if killErr := m.killCommand(pidFile); killErr != nil {
+			return result, fmt.Errorf("%w, but the command may still be running: %v", err, killErr)
+		}
+		return result, err
+	}
+
+	if err != nil && res.stderr != "" {
+		return result, fmt.Errorf("%s", res.stderr)
+	}
+	return result, err
+}
+
+// ShellCommand builds an interactive bash session on the current host,
+// starting in the tracked directory. The caller hands it the terminal.
+func (m *Manager) ShellCommand() *exec.Cmd {
+	args := append([]string{"exec", "-it"}, m.execTarget()...)
+	return exec.Command(m.Runtime, append(args, m.shell())...)
+}
+
+// RefreshCurrentDir re-checks the tracked directory after something outside
+// ExecuteCommand ran in the container, falling back to home if it is gone
+func (m *Manager) RefreshCurrentDir() {
+	out, _ := m.ExecuteValidation(fmt.Sprintf("cd %s && pwd", shellQuote(m.CurrentDir)))
+	if dir := strings.TrimSpace(out); dir != "" {
+		m.CurrentDir = dir
+		return
+	}
+	m.CurrentDir = m.HomeDir()
 }
 
 // ExecuteValidation runs a command from the root directory to check win conditions
 // This ensures game logic is consistent regardless of where the user is cd'd to
 func (m *Manager) ExecuteValidation(command string) (string, error) {
 	// similar to ExecuteCommand but forcing -w "/" or just raw exec
-	// actually we probably want to run from /home/player or /
-	// Given the game context "target: hut/bed.txt", running from /home/player seems correct base
-
-	args := []string{"exec", "-w", "/home/player", m.ContainerName, "bash", "-c", command}
-	cmd := exec.Command(m.Runtime, args...)
-
-	var out bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
+	// actually we probably want to run from the player's home or /
+	// Given the game context "target: hut/bed.txt", running from home seems correct base
 
-	err := cmd.Run()
+	args := []string{"exec", "-w", m.HomeDir(), m.ContainerName, m.shell(), "-c", command}
+	res, err := m.runExec(args, 0)
 	if err != nil {
 		// validation checks might fail (exit 1), we still want the output usually
-		return out.String(), nil
+		return res.stdout, nil
+	}
+	return res.stdout, nil
+}
+
+// ExecuteValidationStrict is ExecuteValidation but reports a non-zero exit as an error,
+// for checks where the exit code is the answer
+func (m *Manager) ExecuteValidationStrict(command string) (string, error) {
+	args := []string{"exec", "-w", m.HomeDir(), m.ContainerName, m.shell(), "-c", command}
+	res, err := m.runExec(args, 0)
+	if err != nil && res.stderr != "" {
+		return res.stdout, fmt.Errorf("%v: %s", err, strings.TrimSpace(res.stderr))
 	}
-	return out.String(), nil
+	return res.stdout, err
 }
 
 // ResetStorage removes the persistent storage directory
@@ -247,37 +581,40 @@ func (m *Manager) ResetStorage() error {
 	// First, ensure the game container is stopped so it doesn't hold locks
 	_ = m.StopContainer()
 
-	homeDir, err := os.UserHomeDir()
+	localPath, err := m.storagePath()
 	if err != nil {
-		return fmt.Errorf("failed to get user home dir: %v", err)
+		return err
 	}
-	localPath := filepath.Join(homeDir, ".local", "share", "goblin-terminal", "fs")
 
 	// Check if exists
 	if _, err := os.Stat(localPath); os.IsNotExist(err) {
 		return nil // Nothing to do
 	}
 
-	// Permission Fix:
-	// Files created in the container might have restrictive permissions (like 700) or belong to root.
-	// We use a temporary container to chmod everything so we can delete it.
-	// We mount localPath to /clean_target
+	if err := m.unlockStorage(localPath); err != nil {
+		// Just log error but attempt local removal anyway
+		fmt.Printf("Warning: failed to fix permissions via docker: %v\n", err)
+	}
+
+	// Remove all contents, and the home baseline taken from the image
+	if err := os.RemoveAll(localPath); err != nil {
+		return fmt.Errorf("failed to remove storage directory: %v", err)
+	}
+	return os.RemoveAll(baselinePath(localPath))
+}
+
+// unlockStorage opens up everything under localPath so the host can delete it.
+// Files created in the container might have restrictive permissions (like 700)
+// or belong to root, so a temporary container running as root chmods them.
+func (m *Manager) unlockStorage(localPath string) error {
 	args := []string{"run", "--rm",
 		"-u", "0", // Run as root to override ownership/permissions
 		"-v", fmt.Sprintf("%s:/clean_target:z", localPath),
 		m.ImageName,
 		"chmod", "-R", "777", "/clean_target",
 	}
-
-	cmd := exec.Command(m.Runtime, args...)
-	if out, err := cmd.CombinedOutput(); err != nil {
-		// Just log error but attempt local removal anyway
-		fmt.Printf("Warning: failed to fix permissions via docker: %v\nOutput: %s\n", err, string(out))
-	}
-
-	// Remove all contents
-	if err := os.RemoveAll(localPath); err != nil {
-		return fmt.Errorf("failed to remove storage directory: %v", err)
+	if out, err := exec.Command(m.Runtime, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("%v\nOutput: %s", err, string(out))
 	}
 	return nil
 }
@@ -285,13 +622,16 @@ func (m *Manager) ResetStorage() error {
 // RestoreEnvironment ensures the container state matches the expected progress based on quest ID
 // This handles cases like re-creating the 'glitch' user if the container was recreated
 func (m *Manager) RestoreEnvironment(questID int) error {
+	// Every step needs root, and safe mode skips the quests that set them up
+	if m.NoRoot {
+		return nil
+	}
+
 	// Quest 10: Create glitch user
 	// If we are past quest 10, glitch user must exist
 	if questID > 10 {
-		// Check if user exists using RunAsRoot (which returns error on failure)
-		// ExecuteValidation swallows errors so it returns nil error even if user is missing
-		err := m.RunAsRoot("id glitch")
-		if err != nil {
+		// UserExists turns the exit code into output, since ExecuteValidation swallows errors
+		if !m.UserExists("glitch") {
 			// User missing, recreate
 			// We use useradd with -m usually, but the quest just said 'useradd glitch'
 			// However, for persistence validation, we should ensure it's usable.
@@ -306,7 +646,7 @@ func (m *Manager) RestoreEnvironment(questID int) error {
 			// Checking Dockerfile would be good, but assuming we can exec as root.
 
 			// We'll use a helper to run as root
-			if err := m.RunAsRoot("useradd glitch"); err != nil {
+			if _, err := m.RunAsRoot("useradd glitch"); err != nil {
 				// if fails (maybe it thinks it exists?), ignore or log
 				// assuming clean state if id glitch failed.
 				return fmt.Errorf("failed to restore glitch user: %v", err)
@@ -317,9 +657,12 @@ func (m *Manager) RestoreEnvironment(questID int) error {
 	// Quest 16: Add glitch to sudo
 	if questID > 16 {
 		// Check if glitch is sudoer
-		out, _ := m.ExecuteValidation("groups glitch")
-		if !strings.Contains(out, "sudo") {
-			if err := m.RunAsRoot("usermod -aG sudo glitch"); err != nil {
+		isSudoer, err := m.UserInGroup("glitch", "sudo")
+		if err != nil {
+			return fmt.Errorf("failed to restore glitch sudo access: %v", err)
+		}
+		if !isSudoer {
+			if _, err := m.RunAsRoot("usermod -aG sudo glitch"); err != nil {
 				return fmt.Errorf("failed to restore glitch sudo access: %v", err)
 			}
 		}
@@ -328,28 +671,119 @@ func (m *Manager) RestoreEnvironment(questID int) error {
 	// Ensure ownership of .safe_house if past Quest 11
 	if questID > 11 {
 		// Quest 11: "sudo chown glitch /home/player/.safe_house"
-		if _, err := m.ExecuteValidation("test -d /home/player/.safe_house"); err == nil {
-			_ = m.RunAsRoot("chown glitch /home/player/.safe_house")
+		// Both execs run from the player's home
+		if _, err := m.ExecuteValidation("test -d .safe_house"); err == nil {
+			_, _ = m.RunAsRoot("chown glitch .safe_house")
 		}
 	}
 
 	// Ensure permissions of .safe_house if past Quest 12
 	if questID > 12 {
 		// Quest 12: "sudo chmod 700 /home/player/.safe_house"
-		if _, err := m.ExecuteValidation("test -d /home/player/.safe_house"); err == nil {
-			_ = m.RunAsRoot("chmod 700 /home/player/.safe_house")
+		if _, err := m.ExecuteValidation("test -d .safe_house"); err == nil {
+			_, _ = m.RunAsRoot("chmod 700 .safe_house")
 		}
 	}
 
 	return nil
 }
 
-// RunAsRoot executes a command as root in the container
-func (m *Manager) RunAsRoot(command string) error {
-	args := []string{"exec", "-u", "0", m.ContainerName, "bash", "-c", command}
-	cmd := exec.Command(m.Runtime, args...)
-	if out, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("%v: %s", err, string(out))
+// UserExists reports whether a user account exists in the player container
+func (m *Manager) UserExists(name string) bool {
+	out, _ := m.ExecuteValidation(fmt.Sprintf("id -u %s >/dev/null 2>&1 && echo yes", name))
+	return strings.TrimSpace(out) == "yes"
+}
+
+// GroupExists reports whether a group exists in the player container
+func (m *Manager) GroupExists(name string) bool {
+	out, _ := m.ExecuteValidation(fmt.Sprintf("getent group %s >/dev/null 2>&1 && echo yes", name))
+	return strings.TrimSpace(out) == "yes"
+}
+
+// ErrNoSuchUser is returned by UserInGroup when the user account is missing
+var ErrNoSuchUser = errors.New("no such user")
+
+// UserInGroup reports whether user is a member of group.
+// A missing user returns ErrNoSuchUser rather than false.
+func (m *Manager) UserInGroup(user, group string) (bool, error) {
+	if !m.UserExists(user) {
+		return false, ErrNoSuchUser
 	}
-	return nil
+	out, _ := m.ExecuteValidation(fmt.Sprintf("id -nG %s", user))
+	return groupListContains(out, group), nil
+}
+
+// groupListContains checks a space separated "id -nG" listing for an exact group name
+func groupListContains(groups, group string) bool {
+	for _, g := range strings.Fields(groups) {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// questMarker is touched when a quest begins so Inventory can find newer files.
+// It lives outside the player's home to stay out of their way.
+const questMarker = "/var/tmp/.goblin_quest_start"
+
+// MarkQuestStart records the current time as the start of the quest
+func (m *Manager) MarkQuestStart() error {
+	_, err := m.ExecuteValidation("touch " + questMarker)
+	return err
+}
+
+// Inventory lists files and directories under the player's home and /tmp that were
+// created or modified since MarkQuestStart. Each line is "<type> <path>" as printed by find.
+func (m *Manager) Inventory() (string, error) {
+	cmd := fmt.Sprintf("test -e %[1]s && find %[2]s /tmp -mindepth 1 -newer %[1]s -printf '%%y %%p\\n' 2>/dev/null", questMarker, shellQuote(m.HomeDir()))
+	return m.ExecuteValidation(cmd)
+}
+
+// HomeTree lists everything under the player's home except hidden entries,
+// one "<type> <relative path>" line each as printed by find
+func (m *Manager) HomeTree() (string, error) {
+	return m.ExecuteValidation(fmt.Sprintf("find %s -mindepth 1 -name '.*' -prune -o -printf '%%y %%P\\n' 2>/dev/null", shellQuote(m.HomeDir())))
+}
+
+// IsRunning reports whether the player container is up. A container that no
+// longer exists isn't running; other runtime failures are returned as errors.
+func (m *Manager) IsRunning() (bool, error) {
+	out, err := exec.Command(m.Runtime, "container", "inspect", "-f", "{{.State.Running}}", m.ContainerName).CombinedOutput()
+	if err != nil {
+		if isNoSuchContainer(string(out)) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to inspect %s: %v (%s)", m.ContainerName, err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)) == "true", nil
+}
+
+// isNoSuchContainer recognizes the runtime's answer for a missing container
+func isNoSuchContainer(output string) bool {
+	return strings.Contains(strings.ToLower(output), "no such container")
+}
+
+// ErrNoRoot is returned by RunAsRoot in safe mode
+var ErrNoRoot = errors.New("root exec is disabled (safe mode)")
+
+// CanExecAsRoot probes whether the runtime lets us exec as root in the player container
+func (m *Manager) CanExecAsRoot() bool {
+	out, err := exec.Command(m.Runtime, "exec", "-u", "0", m.ContainerName, "id", "-u").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "0"
+}
+
+// RunAsRoot executes a command as root in the container and returns its stdout, which
+// root checks compare like ExecuteValidation's; stderr only goes in the error.
+// Like ExecuteValidation it runs from the player's home so relative targets resolve the same way.
+func (m *Manager) RunAsRoot(command string) (string, error) {
+	if m.NoRoot {
+		return "", ErrNoRoot
+	}
+	args := []string{"exec", "-u", "0", "-w", m.HomeDir(), m.ContainerName, m.shell(), "-c", command}
+	res, err := m.runExec(args, 0)
+	if err != nil {
+		return res.stdout, fmt.Errorf("%v: %s", err, res.stderr)
+	}
+	return res.stdout, nil
 }
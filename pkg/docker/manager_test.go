@@ -1,7 +1,15 @@
 package docker
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestManager_Lifecycle(t *testing.T) {
@@ -32,3 +40,978 @@ func TestManager_Lifecycle(t *testing.T) {
 		t.Error("Image name should be set")
 	}
 }
+
+func TestManager_ValidateNetwork(t *testing.T) {
+	cases := []struct {
+		name      string
+		subnet    string
+		gatewayIP string
+		playerIP  string
+		wantErr   bool
+	}{
+		{"defaults", DefaultSubnet, DefaultGatewayIP, DefaultPlayerIP, false},
+		{"custom subnet", "172.30.5.0/24", "172.30.5.10", "172.30.5.11", false},
+		{"gateway outside subnet", DefaultSubnet, "10.10.11.2", DefaultPlayerIP, true},
+		{"player outside subnet", DefaultSubnet, DefaultGatewayIP, "192.168.1.3", true},
+		{"bad subnet", "10.10.10.0/99", DefaultGatewayIP, DefaultPlayerIP, true},
+		{"bad ip", DefaultSubnet, "not-an-ip", DefaultPlayerIP, true},
+		{"same ip", DefaultSubnet, DefaultPlayerIP, DefaultPlayerIP, true},
+	}
+
+	for _, tc := range cases {
+		mgr := &Manager{Subnet: tc.subnet, GatewayIP: tc.gatewayIP, PlayerIP: tc.playerIP}
+		err := mgr.ValidateNetwork()
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: expected error=%v, got %v", tc.name, tc.wantErr, err)
+		}
+	}
+}
+
+func TestManager_PlayerRunArgsHardened(t *testing.T) {
+	mgr := &Manager{ImageName: "img", ContainerName: "c", NetworkName: "n", PlayerIP: DefaultPlayerIP, CapAdd: []string{"NET_RAW"}}
+
+	args := strings.Join(mgr.playerRunArgs("/data"), " ")
+	if strings.Contains(args, "--cap-drop=ALL") || strings.Contains(args, "--read-only") {
+		t.Errorf("Expected default args to be unhardened, got %s", args)
+	}
+	if !strings.Contains(args, "--cap-add=NET_RAW") {
+		t.Errorf("Expected NET_RAW by default, got %s", args)
+	}
+
+	mgr.Harden()
+	args = strings.Join(mgr.playerRunArgs("/data"), " ")
+	for _, want := range []string{"--cap-drop=ALL", "--cap-add=SETUID", "no-new-privileges", "--read-only", "--tmpfs /tmp"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("Expected hardened args to contain %q, got %s", want, args)
+		}
+	}
+	if got := mgr.HardeningRestrictions(); !slices.Equal(got, []string{RestrictSudo, RestrictReadOnlyRoot}) {
+		t.Errorf("Expected hardened mode to block sudo and root writes, got %v", got)
+	}
+	mgr.CapAdd = withoutCap(mgr.CapAdd, "NET_RAW")
+	if got := mgr.HardeningRestrictions(); !slices.Contains(got, RestrictPing) {
+		t.Errorf("Expected ping blocked without NET_RAW, got %v", got)
+	}
+}
+
+func TestGroupListContains(t *testing.T) {
+	if !groupListContains("glitch sudo\n", "sudo") {
+		t.Error("Expected sudo to be found")
+	}
+	if groupListContains("glitch sudoers", "sudo") {
+		t.Error("Expected partial group names not to match")
+	}
+	if groupListContains("", "sudo") {
+		t.Error("Expected empty listing not to match")
+	}
+}
+
+func TestManager_CheckDiskSpace(t *testing.T) {
+	dir := t.TempDir()
+	mgr := &Manager{Runtime: "true", StoragePath: filepath.Join(dir, "missing", "fs")}
+
+	// Disabled threshold never warns
+	if err := mgr.CheckDiskSpace(); err != nil {
+		t.Errorf("Expected no warning with check disabled, got %v", err)
+	}
+
+	// An impossible threshold always warns, and checks the nearest existing parent
+	mgr.MinFreeSpace = ^uint64(0)
+	err := mgr.CheckDiskSpace()
+	lowErr, ok := err.(*LowDiskSpaceError)
+	if !ok {
+		t.Fatalf("Expected LowDiskSpaceError, got %v", err)
+	}
+	if lowErr.Path != dir {
+		t.Errorf("Expected check on %s, got %s", dir, lowErr.Path)
+	}
+}
+
+func TestIsCapabilityError(t *testing.T) {
+	if !isCapabilityError("Error: crun: set capabilities: Operation not permitted") {
+		t.Error("Expected capability failure to be detected")
+	}
+	if isCapabilityError("Error: image not known") {
+		t.Error("Expected unrelated failure not to be treated as a capability error")
+	}
+	if got := withoutCap([]string{"NET_RAW", "KILL"}, "NET_RAW"); len(got) != 1 || got[0] != "KILL" {
+		t.Errorf("Expected only KILL to remain, got %v", got)
+	}
+}
+
+func TestManager_RunAsRoot(t *testing.T) {
+	// echo stands in for the runtime, so the output is the exec invocation itself
+	mgr := &Manager{Runtime: "echo", ContainerName: "goblin-test"}
+	out, err := mgr.RunAsRoot("cat /etc/shadow")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(out, "-u 0") || !strings.Contains(out, "cat /etc/shadow") {
+		t.Errorf("Expected root exec output, got %q", out)
+	}
+
+	mgr.Runtime = "false"
+	if _, err := mgr.RunAsRoot("true"); err == nil {
+		t.Error("Expected failing command to return an error")
+	}
+
+	// Warnings on stderr don't spoil a root check's expected output
+	script := filepath.Join(t.TempDir(), "runtime")
+	body := "#!/bin/sh\necho 'sudo: unable to resolve host goblin' >&2\necho root\n"
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr.Runtime = script
+	out, err = mgr.RunAsRoot("stat -c %U /root/vault")
+	if err != nil || strings.TrimSpace(out) != "root" {
+		t.Errorf("Expected only stdout, to match a check's expected output, got %q, %v", out, err)
+	}
+}
+
+func TestManager_NoRoot(t *testing.T) {
+	// echo prints the exec args instead of "0", like a runtime that ignores -u 0
+	mgr := &Manager{Runtime: "echo", ContainerName: "goblin-test", ImageName: "goblin"}
+	if mgr.CanExecAsRoot() {
+		t.Error("Expected the probe to fail when id doesn't report uid 0")
+	}
+
+	mgr.NoRoot = true
+	if _, err := mgr.RunAsRoot("useradd glitch"); !errors.Is(err, ErrNoRoot) {
+		t.Errorf("Expected ErrNoRoot in safe mode, got %v", err)
+	}
+	if err := mgr.RestoreEnvironment(20); err != nil {
+		t.Errorf("Expected restoration to be skipped in safe mode, got %v", err)
+	}
+	if args := strings.Join(mgr.auxRunArgs(ContainerSpec{Name: "gw", IP: DefaultGatewayIP}), " "); strings.Contains(args, "--user") {
+		t.Errorf("Expected aux containers to keep the image's user, got %q", args)
+	}
+}
+
+func TestCdTarget(t *testing.T) {
+	cases := []struct {
+		args string
+		want string
+	}{
+		{``, `'/home/player'`},
+		{`hut`, `'hut'`},
+		{`"a b"`, `'a b'`},
+		{`'a b'`, `'a b'`},
+		{`a\ b`, `'a b'`},
+		{`"it's"`, `'it'\''s'`},
+		{`~/hut`, `'/home/player/hut'`},
+		{`"$HOME"/hut`, `"$HOME"'/hut'`},
+		{`${HOME}`, `"${HOME}"`},
+		{`""`, `''`},
+	}
+	for _, tc := range cases {
+		got, err := cdTarget(tc.args, DefaultHome)
+		if err != nil {
+			t.Errorf("cd %s: unexpected error %v", tc.args, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("cd %s: expected %s, got %s", tc.args, tc.want, got)
+		}
+	}
+
+	for _, bad := range []string{`a b`, `"a b`, `${HOME`} {
+		if _, err := cdTarget(bad, DefaultHome); err == nil {
+			t.Errorf("cd %s: expected an error", bad)
+		}
+	}
+}
+
+func TestManager_Diagnose(t *testing.T) {
+	statuses := func(results []CheckResult) map[string]CheckStatus {
+		got := make(map[string]CheckStatus)
+		for _, r := range results {
+			got[r.Name] = r.Status
+		}
+		return got
+	}
+
+	// "true" answers every runtime command successfully
+	healthy := &Manager{Runtime: "true", ImageName: "goblin", NetworkName: "net",
+		Subnet: DefaultSubnet, GatewayIP: DefaultGatewayIP, PlayerIP: DefaultPlayerIP}
+	for name, status := range statuses(healthy.Diagnose()) {
+		if status != CheckOK {
+			t.Errorf("Expected %s to be OK on a healthy runtime, got %s", name, status)
+		}
+	}
+
+	// "false" fails every command; later checks still report
+	broken := &Manager{Runtime: "false", ImageName: "goblin", NetworkName: "net",
+		Subnet: DefaultSubnet, GatewayIP: DefaultGatewayIP, PlayerIP: "192.168.1.1"}
+	got := statuses(broken.Diagnose())
+	if got["daemon"] != CheckFail || got["network"] != CheckFail {
+		t.Errorf("Expected daemon and network failures, got %v", got)
+	}
+	if got["image"] != CheckWarn || got["net_raw"] != CheckWarn {
+		t.Errorf("Expected dependent checks to be skipped with a warning, got %v", got)
+	}
+	if len(got) != 6 {
+		t.Errorf("Expected all 6 checks to report, got %v", got)
+	}
+}
+
+func TestManager_ExecuteValidationStrict(t *testing.T) {
+	mgr := &Manager{Runtime: "true", ContainerName: "goblin-test"}
+	if _, err := mgr.ExecuteValidationStrict("test -f hut"); err != nil {
+		t.Errorf("Expected exit 0 to succeed, got %v", err)
+	}
+	mgr.Runtime = "false"
+	if _, err := mgr.ExecuteValidationStrict("test -f hut"); err == nil {
+		t.Error("Expected a non-zero exit to be reported")
+	}
+}
+
+func TestManager_ExtraRunArgs(t *testing.T) {
+	mgr := &Manager{ImageName: "img", ContainerName: "c", NetworkName: "n", PlayerIP: DefaultPlayerIP,
+		ExtraRunArgs: []string{"--env=FOO=bar", "--add-host=db:10.10.10.9"}}
+
+	args := mgr.playerRunArgs("/data")
+	if args[len(args)-1] != "img" {
+		t.Fatalf("Expected the image name last, got %v", args)
+	}
+	if got := strings.Join(args[len(args)-3:len(args)-1], " "); got != "--env=FOO=bar --add-host=db:10.10.10.9" {
+		t.Errorf("Expected extra args just before the image, got %v", args)
+	}
+
+	if err := ValidateRunArgs([]string{"--env=A=b"}); err != nil {
+		t.Errorf("Expected a plain argument to pass, got %v", err)
+	}
+	for _, bad := range []string{"", "  ", "--env=A\nB"} {
+		if err := ValidateRunArgs([]string{bad}); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestManager_StopContainerErrors(t *testing.T) {
+	mgr := &Manager{Runtime: "true", ContainerName: "goblin-test", GatewayName: "goblin-test_gateway"}
+	if err := mgr.StopContainer(); err != nil {
+		t.Errorf("Expected a clean removal to succeed, got %v", err)
+	}
+
+	mgr.Runtime = "false"
+	err := mgr.StopContainer()
+	if err == nil {
+		t.Fatal("Expected a failed removal to be reported")
+	}
+	if !strings.Contains(err.Error(), "goblin-test_gateway") {
+		t.Errorf("Expected both containers to be attempted, got %v", err)
+	}
+
+	// A runtime that reports the container as already gone isn't a failure
+	script := filepath.Join(t.TempDir(), "runtime")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho 'Error: No such container: '\"$3\" >&2\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr.Runtime = script
+	if err := mgr.StopContainer(); err != nil {
+		t.Errorf("Expected missing containers to be ignored, got %v", err)
+	}
+}
+
+func TestManager_Scenario(t *testing.T) {
+	mgr := &Manager{Runtime: "true", ImageName: "goblin", ContainerName: "goblin-test", GatewayName: "goblin-test_gateway",
+		NetworkName: "net", Subnet: DefaultSubnet, GatewayIP: DefaultGatewayIP, PlayerIP: DefaultPlayerIP}
+
+	if got := mgr.ActiveScenario(); len(got) != 1 || got[0].Name != "goblin-test_gateway" || got[0].IP != DefaultGatewayIP {
+		t.Fatalf("Expected the gateway as the default scenario, got %+v", got)
+	}
+
+	web := ContainerSpec{Name: mgr.AuxContainerName("web"), Hostname: "web", Image: "nginx", IP: "10.10.10.10"}
+	db := ContainerSpec{Name: mgr.AuxContainerName("db"), IP: "10.10.10.11", Command: "sleep infinity"}
+	if err := mgr.StartScenario([]ContainerSpec{web, db}); err != nil {
+		t.Fatalf("StartScenario failed: %v", err)
+	}
+	if len(mgr.ActiveScenario()) != 2 {
+		t.Errorf("Expected the new scenario to be active, got %+v", mgr.ActiveScenario())
+	}
+
+	args := strings.Join(mgr.auxRunArgs(db), " ")
+	if !strings.Contains(args, "--hostname goblin-test_db") || !strings.HasSuffix(args, "goblin bash -c sleep infinity") {
+		t.Errorf("Expected the name as hostname and the game image with the command, got %q", args)
+	}
+	if args := mgr.auxRunArgs(web); args[len(args)-1] != "nginx" {
+		t.Errorf("Expected the spec's image with its default command, got %v", args)
+	}
+
+	// Teardown covers every scenario container
+	mgr.Runtime = "false"
+	err := mgr.StopContainer()
+	if err == nil || !strings.Contains(err.Error(), "goblin-test_web") || !strings.Contains(err.Error(), "goblin-test_db") {
+		t.Errorf("Expected every scenario container to be removed, got %v", err)
+	}
+
+	mgr.Runtime = "true"
+	if err := mgr.StartScenario(nil); err != nil || mgr.ActiveScenario()[0].Name != "goblin-test_gateway" {
+		t.Errorf("Expected nil to restore the gateway, got %+v (%v)", mgr.ActiveScenario(), err)
+	}
+
+	for _, bad := range [][]ContainerSpec{
+		{{Name: "a", IP: "192.168.1.5"}},
+		{{Name: "a", IP: DefaultPlayerIP}},
+		{{Name: "a", IP: "10.10.10.20"}, {Name: "a", IP: "10.10.10.21"}},
+		{{IP: "10.10.10.20"}},
+	} {
+		if err := mgr.StartScenario(bad); err == nil {
+			t.Errorf("Expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestParseStats(t *testing.T) {
+	s, err := ParseStats("3.25%|12.5MiB / 1.944GiB|0.63%|4\n")
+	if err != nil {
+		t.Fatalf("ParseStats failed: %v", err)
+	}
+	want := ContainerStats{CPUPercent: 3.25, MemUsage: "12.5MiB", MemLimit: "1.944GiB", MemPercent: 0.63, PIDs: 4}
+	if s != want {
+		t.Errorf("Expected %+v, got %+v", want, s)
+	}
+
+	// Podman prints "--" before it has a CPU sample
+	if s, err := ParseStats("--|1MB / 512MB|0.20%|1"); err != nil || s.CPUPercent != 0 {
+		t.Errorf("Expected a missing sample to read as 0, got %+v (%v)", s, err)
+	}
+
+	for _, bad := range []string{"", "3%|12MiB|1%|4", "x%|1MB / 2MB|1%|1", "1%|1MB / 2MB|1%|many"} {
+		if _, err := ParseStats(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestManager_RestartOnly(t *testing.T) {
+	// The fake runtime logs each invocation so we can see which containers were touched
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls")
+	script := filepath.Join(dir, "runtime")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"$@\" >> "+log+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	calls := func() string {
+		data, _ := os.ReadFile(log)
+		_ = os.Remove(log)
+		return string(data)
+	}
+
+	mgr := &Manager{Runtime: script, ImageName: "goblin", ContainerName: "goblin-test", GatewayName: "goblin-test_gateway",
+		NetworkName: "net", Subnet: DefaultSubnet, GatewayIP: DefaultGatewayIP, PlayerIP: DefaultPlayerIP,
+		StoragePath: filepath.Join(dir, "fs"), CurrentDir: "/home/player/hut"}
+
+	if err := mgr.RestartPlayerOnly(5); err != nil {
+		t.Fatalf("RestartPlayerOnly failed: %v", err)
+	}
+	got := calls()
+	if !strings.Contains(got, "rm -f goblin-test\n") || !strings.Contains(got, "--name goblin-test ") {
+		t.Errorf("Expected the player container to be recreated, got:\n%s", got)
+	}
+	if strings.Contains(got, "goblin-test_gateway") {
+		t.Errorf("Expected the gateway to be left alone, got:\n%s", got)
+	}
+	if !strings.Contains(got, "cd '/home/player/hut' && pwd") {
+		t.Errorf("Expected the working directory to be re-synced, got:\n%s", got)
+	}
+
+	if err := mgr.RestartGatewayOnly(); err != nil {
+		t.Fatalf("RestartGatewayOnly failed: %v", err)
+	}
+	got = calls()
+	if !strings.Contains(got, "rm -f goblin-test_gateway") || !strings.Contains(got, "--name goblin-test_gateway") {
+		t.Errorf("Expected the gateway to be recreated, got:\n%s", got)
+	}
+	if strings.Contains(got, "rm -f goblin-test\n") || strings.Contains(got, "--name goblin-test ") {
+		t.Errorf("Expected the player container to be left alone, got:\n%s", got)
+	}
+}
+
+func TestManager_ExecRetry(t *testing.T) {
+	defer func(backoff time.Duration) { execBackoff = backoff }(execBackoff)
+	execBackoff = time.Millisecond
+
+	// The fake runtime fails the first exec like a restarting container, then works
+	dir := t.TempDir()
+	count := filepath.Join(dir, "count")
+	script := filepath.Join(dir, "runtime")
+	body := "#!/bin/sh\necho x >> " + count + "\n" +
+		"if [ $(wc -l < " + count + ") -eq 1 ]; then\n" +
+		"  echo 'Error response from daemon: Container abc is restarting, wait until the container is running' >&2\n" +
+		"  exit 1\nfi\necho ok\n"
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	calls := func() int {
+		data, _ := os.ReadFile(count)
+		_ = os.Remove(count)
+		return strings.Count(string(data), "x")
+	}
+
+	mgr := &Manager{Runtime: script, ContainerName: "goblin-test", CurrentDir: "/home/player"}
+	out, err := mgr.ExecuteCommand("ls")
+	if err != nil || out != "ok\n" {
+		t.Errorf("Expected the retry to succeed, got %q (%v)", out, err)
+	}
+	if n := calls(); n != 2 {
+		t.Errorf("Expected one retry, got %d calls", n)
+	}
+
+	// A command's own failure isn't retried, even if it mentions a transient message
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho x >> "+count+"\necho 'ls: hut is not running' >&2\nexit 2\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mgr.ExecuteCommand("ls hut"); err == nil {
+		t.Error("Expected the command's failure to be returned")
+	}
+	if n := calls(); n != 1 {
+		t.Errorf("Expected no retry for a genuine failure, got %d calls", n)
+	}
+
+	// Persistent runtime failures give up after execRetries
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho x >> "+count+"\necho 'Error: container state improper' >&2\nexit 125\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mgr.ExecuteValidationStrict("true"); err == nil {
+		t.Error("Expected a persistent runtime failure to be returned")
+	}
+	if n := calls(); n != execRetries+1 {
+		t.Errorf("Expected %d attempts, got %d", execRetries+1, n)
+	}
+}
+
+func TestManager_RepairHome(t *testing.T) {
+	// The fake runtime reports a locked file until the root fixup has run
+	dir := t.TempDir()
+	fixed := filepath.Join(dir, "fixed")
+	script := filepath.Join(dir, "runtime")
+	body := `#!/bin/sh
+case "$*" in
+  *"-u 0"*chown*) touch ` + fixed + ` ;;
+  *"-print -quit"*) [ -e ` + fixed + ` ] || echo /home/player/notes ;;
+esac
+`
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := &Manager{Runtime: script, ContainerName: "goblin-test"}
+
+	if got := mgr.HomeProblem(); got != "/home/player/notes" {
+		t.Errorf("Expected the locked path to be reported, got %q", got)
+	}
+	repaired, err := mgr.RepairHome()
+	if err != nil || !repaired {
+		t.Fatalf("Expected a repair, got repaired=%v err=%v", repaired, err)
+	}
+	if repaired, _ := mgr.RepairHome(); repaired {
+		t.Error("Expected no repair once the home is accessible")
+	}
+
+	// Safe mode can't fix anything, but says so instead of failing the launch
+	_ = os.Remove(fixed)
+	mgr.NoRoot = true
+	if _, err := mgr.RepairHome(); !errors.Is(err, ErrNoRoot) {
+		t.Errorf("Expected ErrNoRoot in safe mode, got %v", err)
+	}
+}
+
+func TestBaseImages(t *testing.T) {
+	dockerfile := `# syntax=docker/dockerfile:1
+ARG BASE=alpine
+FROM --platform=linux/amd64 golang:1.25 AS build
+RUN go build ./...
+from ubuntu:24.04
+FROM build AS test
+FROM scratch
+FROM ${BASE}
+FROM ubuntu:24.04
+COPY --from=build /out /usr/local/bin/
+`
+	images, err := BaseImages(strings.NewReader(dockerfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"golang:1.25", "ubuntu:24.04"}
+	if !slices.Equal(images, want) {
+		t.Errorf("Expected %v, got %v", want, images)
+	}
+}
+
+func TestManager_Shell(t *testing.T) {
+	// The fake runtime logs every call and has no zsh installed
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls")
+	script := filepath.Join(dir, "runtime")
+	body := `#!/bin/sh
+echo "$@" >> ` + log + `
+case "$*" in
+  *"command -v 'zsh'"*) echo missing ;;
+esac
+`
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	calls := func() []string {
+		data, _ := os.ReadFile(log)
+		_ = os.Remove(log)
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+
+	mgr := &Manager{Runtime: script, ContainerName: "goblin-test", CurrentDir: "/home/player", Shell: "fish"}
+	mgr.ExecuteCommand("ls")
+	mgr.ExecuteCommand("cd hut")
+	mgr.ExecuteValidation("test -d hut")
+	mgr.ExecuteValidationStrict("test -d hut")
+	mgr.RunAsRoot("id")
+	mgr.HomeProblem()
+	got := calls()
+	if len(got) != 6 {
+		t.Fatalf("Expected 6 execs, got %d:\n%s", len(got), strings.Join(got, "\n"))
+	}
+	for _, call := range got {
+		if !strings.Contains(call, " goblin-test fish -c ") {
+			t.Errorf("Expected the configured shell, got %q", call)
+		}
+	}
+	if args := mgr.ShellCommand().Args; args[len(args)-1] != "fish" {
+		t.Errorf("Expected !shell to open fish, got %v", args)
+	}
+
+	// A shell the image doesn't have falls back to sh, with a warning
+	mgr.Shell = "zsh"
+	mgr.ensureShell()
+	if mgr.shell() != "sh" || mgr.ShellFallback != "zsh" {
+		t.Errorf("Expected a fallback from zsh to sh, got %q (fallback %q)", mgr.shell(), mgr.ShellFallback)
+	}
+	// A restart checks again, and warns again
+	mgr.ensureShell()
+	if mgr.Shell != "zsh" || mgr.shell() != "sh" || mgr.ShellFallback != "zsh" {
+		t.Errorf("Expected the fallback kept across restarts, got shell %q (fallback %q)", mgr.Shell, mgr.ShellFallback)
+	}
+	mgr.Shell = ""
+	mgr.ensureShell()
+	if mgr.shell() != DefaultShell || mgr.ShellFallback != "" {
+		t.Errorf("Expected bash to be kept when installed, got %q", mgr.shell())
+	}
+
+	if ValidateShell("bash -x") == nil || ValidateShell("") == nil || ValidateShell("/bin/zsh") != nil {
+		t.Error("Expected --shell to take a single program name or path")
+	}
+}
+
+func TestManager_IsRunning(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "runtime")
+	state := filepath.Join(dir, "state")
+	body := `#!/bin/sh
+case "$(cat ` + state + `)" in
+  up) echo true ;;
+  exited) echo false ;;
+  gone) echo "Error: No such container: goblin-test" >&2; exit 1 ;;
+  *) echo "Cannot connect to the Docker daemon" >&2; exit 1 ;;
+esac
+`
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := &Manager{Runtime: script, ContainerName: "goblin-test"}
+
+	for _, tc := range []struct {
+		state   string
+		running bool
+		err     bool
+	}{
+		{"up", true, false},
+		{"exited", false, false},
+		{"gone", false, false},
+		{"daemon down", false, true},
+	} {
+		_ = os.WriteFile(state, []byte(tc.state), 0644)
+		running, err := mgr.IsRunning()
+		if running != tc.running || (err != nil) != tc.err {
+			t.Errorf("%s: expected running=%v err=%v, got running=%v err=%v", tc.state, tc.running, tc.err, running, err)
+		}
+	}
+}
+
+func TestManager_RunCommandStderr(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "runtime")
+	body := `#!/bin/sh
+case "$*" in
+  *useradd*) echo "useradd: warning: the home directory already exists." >&2 ;;
+  *bogus*) echo "partial"; echo "bogus: command not found" >&2; exit 127 ;;
+esac
+`
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := &Manager{Runtime: script, ContainerName: "goblin-test", CurrentDir: "/home/player"}
+
+	// Exit 0 with stderr: a success that still has something to say
+	res, err := mgr.RunCommand("useradd grub")
+	if err != nil {
+		t.Fatalf("Expected exit 0 with stderr to succeed, got %v", err)
+	}
+	if !strings.Contains(res.Stderr, "already exists") {
+		t.Errorf("Expected stderr kept on success, got %q", res.Stderr)
+	}
+	if out, err := mgr.ExecuteCommand("useradd grub"); err != nil || out != "" {
+		t.Errorf("Expected ExecuteCommand to return only stdout, got %q, %v", out, err)
+	}
+
+	// Nonzero exit: stderr becomes the error, stdout is still there
+	res, err = mgr.RunCommand("bogus")
+	if err == nil || !strings.Contains(err.Error(), "command not found") {
+		t.Errorf("Expected the failure to carry stderr, got %v", err)
+	}
+	if res.Stdout != "partial\n" || !strings.Contains(res.Stderr, "command not found") {
+		t.Errorf("Expected both streams kept on failure, got %+v", res)
+	}
+}
+
+func TestManager_RunCommandCombined(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "runtime")
+	// Pauses keep the two pipes from racing each other to the reader
+	body := "#!/bin/sh\necho fetching >&2; sleep 0.05; echo result; sleep 0.05; echo done >&2\n"
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := &Manager{Runtime: script, ContainerName: "goblin-test", CurrentDir: "/home/player"}
+
+	res, err := mgr.RunCommand("wget -O - site")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Combined != "fetching\nresult\ndone\n" {
+		t.Errorf("Expected streams interleaved as written, got %q", res.Combined)
+	}
+	if res.Stdout != "result\n" || res.Stderr != "fetching\ndone\n" {
+		t.Errorf("Expected the streams kept apart too, got %+v", res)
+	}
+}
+
+func TestManager_DetectHome(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "runtime")
+	answer := filepath.Join(dir, "home")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncat "+answer+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct{ reply, want string }{
+		{"/home/grub\n", "/home/grub"},
+		{"/srv/goblin/\n", "/srv/goblin"},
+		{"/\n", DefaultHome},    // Never mount over the root
+		{"\n", DefaultHome},     // Nothing useful came back
+		{"grub\n", DefaultHome}, // Not a path
+	} {
+		_ = os.WriteFile(answer, []byte(tc.reply), 0644)
+		mgr := &Manager{Runtime: script, ImageName: "goblin-test:latest"}
+		mgr.detectHome()
+		if got := mgr.HomeDir(); got != tc.want {
+			t.Errorf("reply %q: expected home %q, got %q", tc.reply, tc.want, got)
+		}
+	}
+
+	// The detected home is used for the mount and for a bare cd
+	mgr := &Manager{Runtime: script, Home: "/home/grub", ContainerName: "goblin-test"}
+	if args := mgr.playerRunArgs("/data"); !slices.Contains(args, "/data:/home/grub:z") {
+		t.Errorf("Expected the storage mounted at the detected home, got %v", args)
+	}
+	if got, _ := cdTarget("~/hut", mgr.HomeDir()); got != "'/home/grub/hut'" {
+		t.Errorf("Expected ~ to expand to the detected home, got %s", got)
+	}
+}
+
+func TestManager_RunSudo(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "runtime")
+	log := filepath.Join(dir, "log")
+	body := `#!/bin/sh
+echo "$*" > ` + log + `
+read -r password
+if [ "$password" = "goblin" ]; then echo "root"; exit 0; fi
+echo "Sorry, try again." >&2
+echo "sudo: 1 incorrect password attempt" >&2
+exit 1
+`
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := &Manager{Runtime: script, ContainerName: "goblin-test", CurrentDir: "/home/player"}
+
+	res, err := mgr.RunSudo("sudo whoami", "goblin")
+	if err != nil || res.Stdout != "root\n" {
+		t.Fatalf("Expected the right password to run the command, got %q, %v", res.Stdout, err)
+	}
+	args, _ := os.ReadFile(log)
+	if !strings.Contains(string(args), "exec -i ") || !strings.Contains(string(args), "sudo -S -p '' whoami") {
+		t.Errorf("Expected an interactive exec of sudo -S, got %q", args)
+	}
+
+	_, err = mgr.RunSudo("sudo whoami", "troll")
+	if !WrongSudoPassword(err) {
+		t.Errorf("Expected a wrong password to be recognized, got %v", err)
+	}
+}
+
+func TestNeedsSudoPassword(t *testing.T) {
+	cases := []struct {
+		command string
+		err     error
+		want    bool
+	}{
+		{"sudo useradd glitch", errors.New("sudo: a password is required"), true},
+		{"sudo useradd glitch", errors.New("sudo: a terminal is required to read the password; either use the -S option"), true},
+		{"sudo useradd glitch", errors.New("useradd: user 'glitch' already exists"), false},
+		{"useradd glitch", errors.New("sudo: a password is required"), false},
+		{"sudo useradd glitch", nil, false},
+	}
+	for _, tc := range cases {
+		if got := NeedsSudoPassword(tc.command, tc.err); got != tc.want {
+			t.Errorf("NeedsSudoPassword(%q, %v) = %v, want %v", tc.command, tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestManager_RunCommandWithInput(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "runtime")
+	// Echo the exec flags, then whatever arrives on stdin
+	body := "#!/bin/sh\necho \"$2\"\ncat\n"
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := &Manager{Runtime: script, ContainerName: "goblin-test", CurrentDir: "/home/player"}
+
+	out, err := mgr.ExecuteCommandWithInput("cat > notes.txt", "gold\nsilver\n")
+	if err != nil || out != "-i\ngold\nsilver\n" {
+		t.Errorf("Expected the input fed to an interactive exec, got %q, %v", out, err)
+	}
+
+	// No input runs the command as usual, with stdin already at EOF
+	out, err = mgr.ExecuteCommandWithInput("cat > notes.txt", "")
+	if err != nil || out != "-w\n" {
+		t.Errorf("Expected a plain exec without input, got %q, %v", out, err)
+	}
+}
+
+func TestManager_MissingTools(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "runtime")
+	// Run the check script on the host in place of the image
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nshift 7\nexec sh \"$@\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := &Manager{Runtime: script, ImageName: "goblin-terminal:latest"}
+
+	missing, err := mgr.MissingTools([]string{"sh", "goblin-no-such-tool", "cat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 1 || missing[0] != "goblin-no-such-tool" {
+		t.Errorf("Expected only the made-up tool to be missing, got %v", missing)
+	}
+}
+
+func TestManager_ResetHome(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "runtime")
+	log := filepath.Join(dir, "snapshots")
+	// The image's home holds just a .bashrc; unlocking the storage is a no-op
+	body := `#!/bin/sh
+for arg in "$@"; do
+	case "$arg" in
+	*:/baseline:z)
+		echo snapshot >> ` + log + `
+		echo "alias ll='ls -l'" > "${arg%:/baseline:z}/.bashrc" ;;
+	esac
+done
+`
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	storage := filepath.Join(dir, "fs")
+	if err := os.MkdirAll(filepath.Join(storage, "camp"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(storage, "camp", "notes.txt"), []byte("gold"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mgr := &Manager{Runtime: script, ImageName: "goblin-terminal:latest", StoragePath: storage, CurrentDir: "/home/player/camp"}
+
+	for range 2 {
+		if err := mgr.ResetHome(); err != nil {
+			t.Fatalf("ResetHome failed: %v", err)
+		}
+		for _, name := range []string{"camp", "loot.txt"} {
+			if _, err := os.Stat(filepath.Join(storage, name)); !os.IsNotExist(err) {
+				t.Errorf("Expected the player's %s to be gone", name)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(storage, ".bashrc")); err != nil {
+			t.Errorf("Expected the image's home restored: %v", err)
+		}
+		if mgr.CurrentDir != DefaultHome {
+			t.Errorf("Expected to be back home, got %s", mgr.CurrentDir)
+		}
+		os.WriteFile(filepath.Join(storage, "loot.txt"), []byte("x"), 0644)
+		mgr.CurrentDir = "/tmp"
+	}
+	if data, _ := os.ReadFile(log); string(data) != "snapshot\n" {
+		t.Errorf("Expected the image's home copied once per session, got %q", data)
+	}
+}
+
+func TestIsStaticIPError(t *testing.T) {
+	cases := map[string]bool{
+		"Error response from daemon: user specified IP address is supported only when connecting to networks with user configured subnets":      true,
+		"Error: invalid config provided: networks and static ip/mac address can only be used with Bridge mode networking":                       true,
+		"Error: plugin type=\"bridge\" failed (add): failed to allocate for range 0: requested IP address 10.10.10.2 is not available in range": true,
+		"invalid argument \"db:10.0.0\" for \"--add-host\" flag: invalid IP address in add-host: \"10.0.0\"":                                    false,
+		"Error response from daemon: Conflict. The container name \"/goblin-game\" is already in use":                                           false,
+		"Error: short-name \"goblin-terminal:latest\" did not resolve to an alias":                                                              false,
+	}
+	for output, want := range cases {
+		if got := isStaticIPError(output); got != want {
+			t.Errorf("isStaticIPError(%q) = %v, want %v", output, got, want)
+		}
+	}
+}
+
+func TestManager_StaticIPFallback(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "runtime")
+	log := filepath.Join(dir, "log")
+	// Refuse any run with a static IP, as rootless podman with CNI does
+	body := `#!/bin/sh
+echo "$*" >> ` + log + `
+case "$*" in
+*--ip*) echo "Error: invalid config provided: networks and static ip/mac address can only be used with Bridge mode networking" >&2; exit 125 ;;
+esac
+`
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := &Manager{Runtime: script, NetworkName: "goblin_net", ImageName: "goblin-terminal:latest", GatewayName: "goblin-test_gateway"}
+
+	if err := mgr.startAuxContainers(mgr.DefaultScenario()); err != nil {
+		t.Fatalf("Expected the gateway to start without a static IP, got %v", err)
+	}
+	if !mgr.DynamicIPs {
+		t.Error("Expected DynamicIPs to be set")
+	}
+	data, _ := os.ReadFile(log)
+	runs := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(runs) != 3 || !strings.HasPrefix(runs[1], "rm -f goblin-test_gateway") || strings.Contains(runs[2], "--ip") {
+		t.Errorf("Expected a refused run, a cleanup and a run without --ip, got %q", runs)
+	}
+	if !strings.Contains(runs[2], "--network-alias gateway") {
+		t.Errorf("Expected the gateway reachable by name, got %q", runs[2])
+	}
+}
+
+func TestManager_SetHost(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "runtime")
+	// Answer the uid lookup, and echo every other exec
+	body := "#!/bin/sh\nif [ \"$3\" = id ]; then echo 1000; exit 0; fi\necho \"$@\"\n"
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := &Manager{Runtime: script, ContainerName: "goblin-test", GatewayName: "goblin-test_gateway", CurrentDir: "/home/player/hut"}
+
+	if err := mgr.SetHost("web"); err == nil {
+		t.Error("Expected a host outside the scenario to be refused")
+	}
+	if err := mgr.SetHost("gateway"); err != nil {
+		t.Fatal(err)
+	}
+	if mgr.CurrentDir != DefaultHome {
+		t.Errorf("Expected a new host to start at home, got %q", mgr.CurrentDir)
+	}
+	out, err := mgr.ExecuteCommand("ls")
+	if want := "exec -u 1000 -w /home/player goblin-test_gateway bash -c ls\n"; err != nil || out != want {
+		t.Errorf("Expected the command run on the gateway as the player, got %q, %v", out, err)
+	}
+
+	// Each host remembers where the player was
+	mgr.CurrentDir = "/tmp"
+	if err := mgr.SetHost(""); err != nil {
+		t.Fatal(err)
+	}
+	if mgr.CurrentDir != "/home/player/hut" {
+		t.Errorf("Expected the player's own directory back, got %q", mgr.CurrentDir)
+	}
+	if out, _ := mgr.ExecuteCommand("ls"); out != "exec -w /home/player/hut goblin-test bash -c ls\n" {
+		t.Errorf("Expected the command back on the player container, got %q", out)
+	}
+	if err := mgr.SetHost("gateway"); err != nil || mgr.CurrentDir != "/tmp" {
+		t.Errorf("Expected the gateway's directory kept, got %q, %v", mgr.CurrentDir, err)
+	}
+}
+
+func TestManager_DiffFilesystem(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "runtime")
+	body := "#!/bin/sh\n[ \"$1 $2\" = \"diff goblin-test\" ] || exit 1\nprintf 'C /etc\\nA /etc/cron.d/backup\\nD /tmp/old.log\\nWARN[0000] noise\\n'\n"
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := &Manager{Runtime: script, ContainerName: "goblin-test"}
+
+	changes, err := mgr.DiffFilesystem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FSChange{{FSChanged, "/etc"}, {FSAdded, "/etc/cron.d/backup"}, {FSDeleted, "/tmp/old.log"}}
+	if !slices.Equal(changes, want) {
+		t.Errorf("DiffFilesystem() = %v, want %v", changes, want)
+	}
+
+	mgr.ContainerName = "goblin-gone"
+	if _, err := mgr.DiffFilesystem(); err == nil {
+		t.Error("Expected an error when the container isn't there")
+	}
+}
+
+func TestManager_RunCommandContext(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "runtime")
+	log := filepath.Join(dir, "calls")
+	// Log every exec; the command sleeps, and the kill answers at once
+	body := "#!/bin/sh\necho \"$@\" >> " + log + "\ncase \"$*\" in *kill*) exit 0;; esac\nexec sleep 5\n"
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	mgr := &Manager{Runtime: script, ContainerName: "goblin-test", CurrentDir: "/home/player", CommandTimeout: time.Minute}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := mgr.RunCommandContext(ctx, "sleep 5")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled command to report it, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("Expected cancelling to stop waiting for the command")
+	}
+
+	// Killing the runtime's exec leaves the command running in the container,
+	// so it is killed there too, as root, by the pid file it recorded
+	data, _ := os.ReadFile(log)
+	calls := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(calls) != 2 {
+		t.Fatalf("Expected the command and a kill, got:\n%s", data)
+	}
+	pidFile := regexp.MustCompile(`/tmp/\.goblin-command-\d+\.pid`).FindString(calls[0])
+	if pidFile == "" || !strings.HasSuffix(calls[0], "bash sleep 5") {
+		t.Errorf("Expected the command to record its pid, got %q", calls[0])
+	}
+	if !strings.HasPrefix(calls[1], "exec -u 0 goblin-test sh -c ") || !strings.Contains(calls[1], pidFile) {
+		t.Errorf("Expected the recorded process killed as root, got %q", calls[1])
+	}
+}
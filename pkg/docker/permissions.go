@@ -0,0 +1,41 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+)
+
+// homeProblemProbe prints the first root-owned entry under the player's home.
+// Files owned by other users (glitch's .safe_house) are quest state and are
+// left alone. Runs from / so an unreadable home doesn't stop the exec itself.
+const homeProblemProbe = `find %s -mindepth 1 -user root -print -quit 2>/dev/null; true`
+
+// homeRepairCommand hands root-owned entries back to the player and makes sure
+// the player can use them. Contents are never touched.
+const homeRepairCommand = `find %[1]s -mindepth 1 -user root ! -type l -exec chmod u+rwX {} + && ` +
+	`find %[1]s -mindepth 1 -user root -exec chown -h player:player {} +`
+
+// HomeProblem returns the first path in the player's home they are locked out
+// of, e.g. a root-owned 700 directory left by an earlier session, or "" if none
+func (m *Manager) HomeProblem() string {
+	probe := fmt.Sprintf(homeProblemProbe, shellQuote(m.HomeDir()))
+	args := []string{"exec", "-w", "/", m.ContainerName, m.shell(), "-c", probe}
+	res, err := m.runExec(args, 0)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(res.stdout)
+}
+
+// RepairHome gives the player back access to their home directory. It is
+// cheap when nothing is wrong: the root fixup only runs after the probe finds
+// a problem. It reports whether a repair was made.
+func (m *Manager) RepairHome() (bool, error) {
+	if m.HomeProblem() == "" {
+		return false, nil
+	}
+	if _, err := m.RunAsRoot(fmt.Sprintf(homeRepairCommand, shellQuote(m.HomeDir()))); err != nil {
+		return false, err
+	}
+	return true, nil
+}
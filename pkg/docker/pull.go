@@ -0,0 +1,73 @@
+package docker
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// DockerfilePath is the Dockerfile BuildImage builds from
+const DockerfilePath = "Dockerfile"
+
+// BaseImages lists the images a Dockerfile's FROM lines pull, in order and
+// without repeats. Stages built FROM an earlier stage, "scratch" and images
+// named by a build ARG are skipped, since there is nothing to pull for them.
+func BaseImages(r io.Reader) ([]string, error) {
+	var images, stages []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.EqualFold(fields[0], "FROM") {
+			continue
+		}
+		// FROM [--platform=<platform>] <image> [AS <name>]
+		args := slices.DeleteFunc(fields[1:], func(f string) bool { return strings.HasPrefix(f, "--") })
+		if len(args) == 0 {
+			continue
+		}
+		image := args[0]
+
+		pullable := image != "scratch" && !strings.Contains(image, "$") &&
+			!slices.Contains(stages, strings.ToLower(image))
+		if pullable && !slices.Contains(images, image) {
+			images = append(images, image)
+		}
+		if len(args) >= 3 && strings.EqualFold(args[1], "AS") {
+			stages = append(stages, strings.ToLower(args[2]))
+		}
+	}
+	return images, scanner.Err()
+}
+
+// PullBaseImage pulls every base image in the Dockerfile ahead of the build,
+// streaming the runtime's progress to w so a slow first download is visible
+func (m *Manager) PullBaseImage(w io.Writer) error {
+	file, err := os.Open(DockerfilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	images, err := BaseImages(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", DockerfilePath, err)
+	}
+	if len(images) == 0 {
+		return fmt.Errorf("no base image found in %s", DockerfilePath)
+	}
+
+	for _, image := range images {
+		fmt.Fprintf(w, "Pulling %s...\n", image)
+		cmd := exec.Command(m.Runtime, "pull", image)
+		cmd.Stdout = w
+		cmd.Stderr = w
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to pull %s: %v", image, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+//go:build !windows
+
+package docker
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+)
+
+// ForwardResizes passes terminal resizes (SIGWINCH) on to a started
+// ShellCommand until the returned stop is called. The runtime client answers
+// by resizing the container's PTY, so an editor open in the shell redraws at
+// the new size. The terminal signals its whole foreground process group, but
+// the client isn't always in it (the game may run under a wrapper that owns
+// the terminal), and an extra signal only makes the client read the size again.
+func ForwardResizes(cmd *exec.Cmd) (stop func()) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGWINCH)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-sig:
+				if cmd.Process != nil {
+					cmd.Process.Signal(syscall.SIGWINCH)
+				}
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
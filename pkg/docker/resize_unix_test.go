@@ -0,0 +1,43 @@
+//go:build !windows
+
+package docker
+
+import (
+	"bytes"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestForwardResizes(t *testing.T) {
+	// Stands in for the runtime client: reports the first resize it sees
+	var out bytes.Buffer
+	cmd := exec.Command("sh", "-c", `trap 'echo resized; exit 0' WINCH; echo ready; while :; do sleep 0.05; done`)
+	cmd.Stdout = &out
+	// Its own process group, so only a forwarded signal can reach it
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+	stop := ForwardResizes(cmd)
+	defer stop()
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		select {
+		case <-done:
+			if out.String() != "ready\nresized\n" {
+				t.Errorf("Expected the resize to reach the client, got %q", out.String())
+			}
+			return
+		case <-time.After(50 * time.Millisecond):
+			if time.Now().After(deadline) {
+				t.Fatal("The resize was never forwarded")
+			}
+			syscall.Kill(syscall.Getpid(), syscall.SIGWINCH)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package docker
+
+import "os/exec"
+
+// ForwardResizes does nothing on Windows, where the runtime client polls the
+// console size itself
+func ForwardResizes(cmd *exec.Cmd) (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,127 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transient exec failures are retried execRetries times, waiting execBackoff
+// before the first retry and twice as long before each one after
+var (
+	execRetries = 2
+	execBackoff = 250 * time.Millisecond
+)
+
+// transientExecErrors are runtime messages meaning the exec never reached the
+// command because the daemon or container wasn't ready yet
+var transientExecErrors = []string{
+	"is restarting",
+	"is not running",
+	"container state improper",
+	"cannot connect to the docker daemon",
+	"connection refused",
+	"i/o timeout",
+	"resource temporarily unavailable",
+}
+
+// execResult is the output of one runtime invocation
+type execResult struct {
+	stdout   string
+	stderr   string
+	combined string // stdout and stderr interleaved as written
+}
+
+// isTransientExecError reports whether stderr is the runtime failing to start
+// the exec, as opposed to the command itself exiting nonzero. Runtime errors
+// carry the runtime's own prefix; a command's stderr comes through untouched.
+func isTransientExecError(stderr string) bool {
+	s := strings.ToLower(strings.TrimSpace(stderr))
+	if !strings.HasPrefix(s, "error response from daemon") &&
+		!strings.HasPrefix(s, "error: ") &&
+		!strings.HasPrefix(s, "cannot connect") {
+		return false
+	}
+	for _, pattern := range transientExecErrors {
+		if strings.Contains(s, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// runExec runs the runtime with args, retrying with backoff when the runtime
+// failed transiently. A command that ran and exited nonzero is never retried.
+// A timeout above zero kills each attempt that runs longer.
+func (m *Manager) runExec(args []string, timeout time.Duration) (execResult, error) {
+	return m.runExecInput(args, timeout, "")
+}
+
+// runExecInput is runExec feeding stdin to every attempt
+func (m *Manager) runExecInput(args []string, timeout time.Duration, stdin string) (execResult, error) {
+	return m.runExecContext(context.Background(), args, timeout, stdin)
+}
+
+// runExecContext is runExecInput stopping when ctx is cancelled. The
+// runtime's exec is killed, as on a timeout, and ctx's error returned.
+func (m *Manager) runExecContext(ctx context.Context, args []string, timeout time.Duration, stdin string) (execResult, error) {
+	delay := execBackoff
+	for attempt := 0; ; attempt++ {
+		res, err := m.execOnce(ctx, args, timeout, stdin)
+		if ctx.Err() != nil {
+			return res, ctx.Err()
+		}
+		if err == nil || attempt >= execRetries || !isTransientExecError(res.stderr) {
+			return res, err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// execOnce is a single runtime invocation for runExec
+func (m *Manager) execOnce(ctx context.Context, args []string, timeout time.Duration, stdin string) (execResult, error) {
+	cmd := exec.CommandContext(ctx, m.Runtime, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	if timeout > 0 {
+		// Kill the command if it hangs
+		timer := time.AfterFunc(timeout, func() {
+			if cmd.Process != nil {
+				cmd.Process.Kill()
+			}
+		})
+		defer timer.Stop()
+	}
+
+	var out, stderr bytes.Buffer
+	combined := &lockedBuffer{}
+	cmd.Stdout = io.MultiWriter(&out, combined)
+	cmd.Stderr = io.MultiWriter(&stderr, combined)
+	err := cmd.Run()
+	return execResult{stdout: out.String(), stderr: stderr.String(), combined: combined.String()}, err
+}
+
+// lockedBuffer collects both streams in arrival order. exec copies each pipe
+// on its own goroutine, so the writes must not interleave mid-buffer.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
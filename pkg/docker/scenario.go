@@ -0,0 +1,161 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+)
+
+// ContainerSpec describes an auxiliary container on the game network,
+// such as the SSH gateway or a quest's web and database hosts
+type ContainerSpec struct {
+	Name     string // Container name
+	Hostname string // Hostname inside the container; defaults to Name
+	Image    string // Empty means the game image
+	IP       string // Static IP, inside the game subnet
+	Command  string // Run with bash -c as root; empty keeps the image's default
+}
+
+// gatewayCommand runs sshd so the player can practice ssh and scp
+const gatewayCommand = "ssh-keygen -A && /usr/sbin/sshd -D"
+
+// DefaultScenario is the single SSH gateway used unless a quest asks for more
+func (m *Manager) DefaultScenario() []ContainerSpec {
+	return []ContainerSpec{{
+		Name:     m.GatewayName,
+		Hostname: "gateway",
+		IP:       m.GatewayIP,
+		Command:  gatewayCommand,
+	}}
+}
+
+// ActiveScenario returns the auxiliary containers in use
+func (m *Manager) ActiveScenario() []ContainerSpec {
+	if m.Scenario == nil {
+		return m.DefaultScenario()
+	}
+	return m.Scenario
+}
+
+// AuxContainerName namespaces a quest-defined host under the player container's
+// name, so parallel games (and --verify) don't collide
+func (m *Manager) AuxContainerName(host string) string {
+	return m.ContainerName + "_" + host
+}
+
+// StartScenario replaces the running auxiliary containers with the given set;
+// nil brings back the default gateway. The player container is left alone,
+// so the player's shell and files survive.
+func (m *Manager) StartScenario(containers []ContainerSpec) error {
+	next := containers
+	if next == nil {
+		next = m.DefaultScenario()
+	}
+	if err := m.validateScenario(next); err != nil {
+		return err
+	}
+	if err := m.removeContainers(m.ActiveScenario()); err != nil {
+		return err
+	}
+	m.Scenario = containers
+	if _, ok := m.hostContainer(m.Host); m.Host != "" && !ok {
+		// The host the player was on is gone; they're back on their own
+		_ = m.SetHost("")
+	}
+
+	if err := m.EnsureNetwork(); err != nil {
+		return err
+	}
+	return m.startAuxContainers(next)
+}
+
+// validateScenario checks the specs can all share the game network
+func (m *Manager) validateScenario(containers []ContainerSpec) error {
+	_, subnet, err := net.ParseCIDR(m.Subnet)
+	if err != nil {
+		return fmt.Errorf("invalid subnet %q: %v", m.Subnet, err)
+	}
+
+	names := map[string]bool{m.ContainerName: true}
+	ips := map[string]string{m.PlayerIP: "player"}
+	for _, spec := range containers {
+		if spec.Name == "" {
+			return fmt.Errorf("scenario container is missing a name")
+		}
+		if names[spec.Name] {
+			return fmt.Errorf("duplicate container name %q", spec.Name)
+		}
+		names[spec.Name] = true
+
+		ip := net.ParseIP(spec.IP)
+		if ip == nil {
+			return fmt.Errorf("invalid IP %q for %s", spec.IP, spec.Name)
+		}
+		if !subnet.Contains(ip) {
+			return fmt.Errorf("%s IP %s is outside subnet %s", spec.Name, spec.IP, m.Subnet)
+		}
+		if other, taken := ips[ip.String()]; taken {
+			return fmt.Errorf("%s and %s both use IP %s", spec.Name, other, spec.IP)
+		}
+		ips[ip.String()] = spec.Name
+	}
+	return nil
+}
+
+// startAuxContainers runs each spec on the game network
+func (m *Manager) startAuxContainers(containers []ContainerSpec) error {
+	for _, spec := range containers {
+		if out, err := m.runContainer(spec.Name, func() []string { return m.auxRunArgs(spec) }); err != nil {
+			return fmt.Errorf("failed to start %s: %v\nOutput: %s", spec.Name, err, string(out))
+		}
+	}
+	return nil
+}
+
+// auxRunArgs builds the "run" arguments for an auxiliary container.
+// They run as root (User 0) so services can bind low ports and generate keys,
+// except in safe mode, where the image's own user is kept.
+func (m *Manager) auxRunArgs(spec ContainerSpec) []string {
+	hostname := spec.Hostname
+	if hostname == "" {
+		hostname = spec.Name
+	}
+	image := spec.Image
+	if image == "" {
+		image = m.ImageName
+	}
+
+	args := []string{"run", "-d", "--rm", "--name", spec.Name}
+	args = append(args, m.networkArgs(spec.IP, hostname)...)
+	if !m.NoRoot {
+		args = append(args, "--user", "0")
+	}
+	args = append(args, image)
+	if spec.Command != "" {
+		args = append(args, "bash", "-c", spec.Command)
+	}
+	return args
+}
+
+// removeContainers force-removes containers by spec.
+// Containers that are already gone don't count as failures.
+func (m *Manager) removeContainers(containers []ContainerSpec) error {
+	var errs []error
+	for _, spec := range containers {
+		if err := m.removeContainer(spec.Name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// removeContainer force-removes one container, ignoring one that doesn't exist
+func (m *Manager) removeContainer(name string) error {
+	out, err := exec.Command(m.Runtime, "rm", "-f", name).CombinedOutput()
+	if err != nil && !isNoSuchContainer(string(out)) {
+		return fmt.Errorf("failed to remove %s: %v (%s)", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
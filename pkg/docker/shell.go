@@ -0,0 +1,50 @@
+package docker
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"unicode"
+)
+
+// DefaultShell is the shell commands run in unless --shell picks another
+const DefaultShell = "bash"
+
+// fallbackShell is always present in the images the game supports
+const fallbackShell = "sh"
+
+// shell is the shell commands are run with in the player container
+func (m *Manager) shell() string {
+	if m.ShellFallback != "" {
+		return fallbackShell
+	}
+	if m.Shell == "" {
+		return DefaultShell
+	}
+	return m.Shell
+}
+
+// ensureShell falls back to sh, recording ShellFallback, when the configured
+// shell isn't installed in the player container. Shell is left as it is, so
+// each restart checks the shell the player asked for again.
+func (m *Manager) ensureShell() {
+	m.ShellFallback = ""
+	want := m.shell()
+	if want == fallbackShell {
+		return
+	}
+	// Only a clear "missing" counts; a failed exec says nothing about the shell
+	probe := fmt.Sprintf("command -v %s >/dev/null || echo missing", shellQuote(want))
+	out, _ := exec.Command(m.Runtime, "exec", m.ContainerName, fallbackShell, "-c", probe).Output()
+	if strings.TrimSpace(string(out)) == "missing" {
+		m.ShellFallback = want
+	}
+}
+
+// ValidateShell rejects --shell values that can't name a program
+func ValidateShell(shell string) error {
+	if shell == "" || strings.IndexFunc(shell, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0 {
+		return fmt.Errorf("invalid shell %q: give a program name or path, like bash or /bin/zsh", shell)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package docker
+
+import (
+	"errors"
+	"strings"
+)
+
+// shellQuote wraps s in single quotes so bash treats it as one literal word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// cdTarget parses the argument of a cd command with shell word rules
+// (quotes, backslash escapes, ~ and $VAR) and returns it re-quoted as a
+// single bash word. Variables are kept for bash to expand, but can't split.
+// A bare cd and a leading ~ go to home.
+func cdTarget(args, home string) (string, error) {
+	args = strings.TrimSpace(args)
+	if args == "" {
+		return shellQuote(home), nil
+	}
+
+	var out, literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			out.WriteString(shellQuote(literal.String()))
+			literal.Reset()
+		}
+	}
+
+	runes := []rune(args)
+	// Leading ~ or ~/ is the player's home
+	if runes[0] == '~' && (len(runes) == 1 || runes[1] == '/') {
+		literal.WriteString(home)
+		runes = runes[1:]
+	}
+
+	var quote rune // ', " or 0 when unquoted
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				literal.WriteRune(r)
+			}
+		case r == '\\' && i+1 < len(runes):
+			next := runes[i+1]
+			// Inside double quotes only a few characters are escapable
+			if quote == '"' && !strings.ContainsRune("\"\\$`", next) {
+				literal.WriteRune(r)
+				continue
+			}
+			literal.WriteRune(next)
+			i++
+		case r == '$' && i+1 < len(runes) && isVarStart(runes[i+1]):
+			end := i + 1
+			if runes[end] == '{' {
+				for end < len(runes) && runes[end] != '}' {
+					end++
+				}
+				if end == len(runes) {
+					return "", errors.New("cd: bad substitution")
+				}
+				end++
+			} else {
+				for end < len(runes) && isVarChar(runes[end]) {
+					end++
+				}
+			}
+			flush()
+			out.WriteString(`"` + string(runes[i:end]) + `"`)
+			i = end - 1
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				literal.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ' ' || r == '\t':
+			return "", errors.New("cd: too many arguments")
+		default:
+			literal.WriteRune(r)
+		}
+	}
+	if quote != 0 {
+		return "", errors.New("cd: unterminated quote")
+	}
+
+	flush()
+	if out.Len() == 0 {
+		// cd "" stays put, same as bash
+		return "''", nil
+	}
+	return out.String(), nil
+}
+
+func isVarStart(r rune) bool {
+	return r == '{' || r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isVarChar(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
@@ -0,0 +1,68 @@
+package docker
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ContainerStats is one resource usage sample of the player container
+type ContainerStats struct {
+	CPUPercent float64 // Of one core, so it can pass 100 on multi-core hosts
+	MemUsage   string  // As the runtime prints it, e.g. "12.5MiB"
+	MemLimit   string  // The container's memory limit, or the host's memory when unlimited
+	MemPercent float64
+	PIDs       int
+}
+
+// statsFormat asks for the fields ContainerStats needs; docker and podman share the names
+const statsFormat = "{{.CPUPerc}}|{{.MemUsage}}|{{.MemPerc}}|{{.PIDs}}"
+
+// Stats takes a single resource usage sample of the player container
+func (m *Manager) Stats() (ContainerStats, error) {
+	out, err := exec.Command(m.Runtime, "stats", "--no-stream", "--format", statsFormat, m.ContainerName).CombinedOutput()
+	if err != nil {
+		return ContainerStats{}, fmt.Errorf("failed to read container stats: %v (%s)", err, strings.TrimSpace(string(out)))
+	}
+	return ParseStats(string(out))
+}
+
+// ParseStats reads a line printed with statsFormat
+func ParseStats(line string) (ContainerStats, error) {
+	fields := strings.Split(strings.TrimSpace(line), "|")
+	if len(fields) != 4 {
+		return ContainerStats{}, fmt.Errorf("unexpected stats output %q", line)
+	}
+
+	var s ContainerStats
+	var err error
+	if s.CPUPercent, err = parsePercent(fields[0]); err != nil {
+		return ContainerStats{}, err
+	}
+	usage, limit, ok := strings.Cut(fields[1], "/")
+	if !ok {
+		return ContainerStats{}, fmt.Errorf("unexpected memory usage %q", fields[1])
+	}
+	s.MemUsage, s.MemLimit = strings.TrimSpace(usage), strings.TrimSpace(limit)
+	if s.MemPercent, err = parsePercent(fields[2]); err != nil {
+		return ContainerStats{}, err
+	}
+	if s.PIDs, err = strconv.Atoi(strings.TrimSpace(fields[3])); err != nil {
+		return ContainerStats{}, fmt.Errorf("unexpected process count %q", fields[3])
+	}
+	return s, nil
+}
+
+// parsePercent reads "3.25%"; runtimes print "--" before the first sample
+func parsePercent(field string) (float64, error) {
+	field = strings.TrimSuffix(strings.TrimSpace(field), "%")
+	if field == "--" || field == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(field, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected percentage %q", field)
+	}
+	return v, nil
+}
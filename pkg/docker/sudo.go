@@ -0,0 +1,54 @@
+package docker
+
+import (
+	"context"
+	"strings"
+)
+
+// sudoPasswordErrors are how sudo says it wanted a password it had no way to
+// ask for, across the versions shipped by common base images
+var sudoPasswordErrors = []string{
+	"a password is required",
+	"a terminal is required to read the password",
+	"no tty present",
+}
+
+// IsSudoCommand reports whether command runs through sudo, i.e. starts with
+// it. Only that form gets the password prompt; sudo deeper in a pipeline
+// doesn't.
+func IsSudoCommand(command string) bool {
+	fields := strings.Fields(command)
+	return len(fields) > 1 && fields[0] == "sudo"
+}
+
+// NeedsSudoPassword reports whether a sudo command failed only because sudo
+// wanted a password, before it ran anything
+func NeedsSudoPassword(command string, err error) bool {
+	if err == nil || !IsSudoCommand(command) {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range sudoPasswordErrors {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// WrongSudoPassword reports whether sudo rejected the password RunSudo gave it
+func WrongSudoPassword(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "incorrect password") || strings.Contains(msg, "sorry, try again")
+}
+
+// RunSudo runs a command starting with sudo, handing sudo the password on
+// stdin. With -S sudo reads it from there, and an empty -p keeps sudo from
+// printing a prompt of its own, as the game draws the prompt itself.
+func (m *Manager) RunSudo(command, password string) (CommandResult, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(command), "sudo")
+	return m.execPlayer(context.Background(), "sudo -S -p ''"+rest, password+"\n")
+}
@@ -0,0 +1,21 @@
+package docker
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// MissingTools reports which of tools the image has no command for, in the
+// order given. Like detectHome it asks a throwaway container from the image,
+// as the image's default user, so no game container has to be running.
+func (m *Manager) MissingTools(tools []string) ([]string, error) {
+	script := `for t in "$@"; do command -v "$t" >/dev/null 2>&1 || echo "$t"; done`
+	args := append([]string{"run", "--rm", "--network", "none", "--entrypoint", fallbackShell,
+		m.ImageName, "-c", script, fallbackShell}, tools...)
+	out, err := exec.Command(m.Runtime, args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not run the image: %v", err)
+	}
+	return strings.Fields(string(out)), nil
+}
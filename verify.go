@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"goblin-terminal/internal/game"
+	"goblin-terminal/pkg/docker"
+)
+
+// runVerify plays every quest's solution against a fresh environment and
+// reports whether each win condition passes. Returns the number of failures.
+func runVerify(quests []game.Quest, manager *docker.Manager) (int, error) {
+	fmt.Println("Building image...")
+	if err := manager.BuildImage(); err != nil {
+		return 0, err
+	}
+
+	// Use throwaway containers and storage so the player's game is untouched
+	storage, err := os.MkdirTemp("", "goblin-verify-")
+	if err != nil {
+		return 0, err
+	}
+	manager.StoragePath = storage
+	manager.ContainerName += "_verify"
+	manager.GatewayName += "_verify"
+	defer manager.ResetStorage()
+
+	if err := manager.StartContainer(); err != nil {
+		return 0, err
+	}
+	defer manager.StopContainer()
+
+	failures := 0
+	for idx, q := range quests {
+		q = q.WithSecret(game.NewSecret())
+		if len(q.Solution) == 0 {
+			fmt.Printf("SKIP  Quest %2d: %s (no solution)\n", q.ID, q.Title)
+			continue
+		}
+
+		if q.NeedsRoot() && manager.NoRoot {
+			fmt.Printf("SKIP  Quest %2d: %s (needs root)\n", q.ID, q.Title)
+			continue
+		}
+
+		if q.RestartsContainer() {
+			if err := manager.StartContainer(); err != nil {
+				return failures, err
+			}
+			if err := manager.RestoreEnvironment(idx); err != nil {
+				fmt.Printf("      Warning: State restoration issue: %v\n", err)
+			}
+		} else if q.RestartPlayer {
+			if err := manager.RestartPlayerOnly(idx); err != nil {
+				fmt.Printf("      Warning: Player restart issue: %v\n", err)
+			}
+		}
+		if q.ResetsHome() {
+			if err := manager.ResetHome(); err != nil {
+				fmt.Printf("      Warning: Home reset issue: %v\n", err)
+			}
+		}
+
+		if err := manager.SetHost(q.Host); err != nil {
+			fmt.Printf("      Warning: Host switch issue: %v\n", err)
+		}
+		for _, r := range game.SetupFailures(q.RunSetup(manager)) {
+			fmt.Printf("      Warning: Setup step %q failed: %v\n", r.Command, r.Err)
+		}
+
+		// Mirror the UI: only successful commands update the last output
+		lastOutput := ""
+		var lastErr error
+		for _, cmd := range q.Solution {
+			out, err := manager.ExecuteCommand(cmd)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			lastOutput = out
+		}
+
+		if game.CheckQuest(q.ActiveWinConditions(!manager.NetRawUnavailable), manager, lastOutput, manager.CurrentDir).Passed() {
+			fmt.Printf("PASS  Quest %2d: %s\n", q.ID, q.Title)
+			continue
+		}
+
+		failures++
+		fmt.Printf("FAIL  Quest %2d: %s (%s)\n", q.ID, q.Title, q.WinCondition.Type)
+		if lastErr != nil {
+			fmt.Printf("      Last error: %v\n", lastErr)
+		}
+	}
+
+	return failures, nil
+}